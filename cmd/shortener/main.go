@@ -29,6 +29,7 @@ import (
 	"github.com/spf13/viper"
 	"resty.dev/v3"
 
+	"go.xoder.cn/shortener/internal/pkgs/hmacsign"
 	"go.xoder.cn/shortener/internal/types"
 )
 
@@ -36,6 +37,8 @@ import (
 type Config struct {
 	APIURL string `mapstructure:"url"`
 	APIKEY string `mapstructure:"key"`
+	KeyID  string `mapstructure:"key_id"` // 请求签名的密钥ID
+	Secret string `mapstructure:"secret"` // 请求签名密钥，设置后请求改为签名发送，不再发送 API Key
 }
 
 const (
@@ -71,9 +74,13 @@ func init() {
 
 	rootCmd.PersistentFlags().StringP("url", "u", "", "API URL")
 	rootCmd.PersistentFlags().StringP("key", "k", "", "API KEY")
+	rootCmd.PersistentFlags().String("key-id", "", "API KEY ID for request signing")
+	rootCmd.PersistentFlags().String("secret", "", "Signing secret for request signing")
 
 	_ = viper.BindPFlag("url", rootCmd.PersistentFlags().Lookup("url"))
 	_ = viper.BindPFlag("key", rootCmd.PersistentFlags().Lookup("key"))
+	_ = viper.BindPFlag("key_id", rootCmd.PersistentFlags().Lookup("key-id"))
+	_ = viper.BindPFlag("secret", rootCmd.PersistentFlags().Lookup("secret"))
 
 	rootCmd.AddCommand(newInitCmd())
 	rootCmd.AddCommand(newEnvCmd())
@@ -83,6 +90,7 @@ func init() {
 	rootCmd.AddCommand(newShortenUpdateCmd())
 	rootCmd.AddCommand(newShortenGetCmd())
 	rootCmd.AddCommand(newShortenListCmd())
+	rootCmd.AddCommand(newShortenLoopsCmd())
 }
 
 func initConfig() error {
@@ -108,7 +116,7 @@ func initConfig() error {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	if cfg.APIKEY == "" {
+	if cfg.APIKEY == "" && cfg.Secret == "" {
 		return nil
 	}
 
@@ -123,7 +131,30 @@ func isURL(url string) bool {
 	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
 }
 
+// newClient 创建请求客户端，设置签名密钥时为每个请求签名，否则发送 API Key
+func newClient() *resty.Client {
+	client := resty.New()
+	if cfg.Secret != "" {
+		client.SetTransport(&hmacsign.Transport{
+			KeyID:  cfg.KeyID,
+			Secret: cfg.Secret,
+			Base:   client.Transport(),
+		})
+	} else {
+		client.SetHeader("X-API-KEY", cfg.APIKEY)
+	}
+	return client
+}
+
 func checkConfig() error {
+	if cfg.Secret != "" && cfg.KeyID == "" {
+		return errors.New(`
+  使用签名密钥时必须提供密钥ID，可用方式：
+    1. 命令行参数: --key-id
+    2. 环境变量: export SHORTENER_KEY_ID=your_key_id
+    3. 配置文件: 在 ~/.shortener/config.toml 添加 key_id
+	`)
+	}
 	if !isURL(APIRequestURL) {
 		return errors.New(`
   必须提供API地址，可用方式：
@@ -147,6 +178,8 @@ func newInitCmd() *cobra.Command {
 
 			apiURL := cmd.Flags().Lookup("url").Value.String()
 			apiKey := cmd.Flags().Lookup("key").Value.String()
+			keyID := cmd.Flags().Lookup("key-id").Value.String()
+			secret := cmd.Flags().Lookup("secret").Value.String()
 
 			if apiURL != "" {
 				viper.Set("url", apiURL)
@@ -154,6 +187,12 @@ func newInitCmd() *cobra.Command {
 			if apiKey != "" {
 				viper.Set("key", apiKey)
 			}
+			if keyID != "" {
+				viper.Set("key_id", keyID)
+			}
+			if secret != "" {
+				viper.Set("secret", secret)
+			}
 
 			configFile := filepath.Join(configDir, configName+"."+configType)
 			if err := viper.WriteConfigAs(configFile); err != nil {
@@ -175,6 +214,8 @@ func newEnvCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Printf("SHORTENER_URL: %s\n", viper.GetString("url"))
 			fmt.Printf("SHORTENER_KEY: %s\n", viper.GetString("key"))
+			fmt.Printf("SHORTENER_KEY_ID: %s\n", viper.GetString("key_id"))
+			fmt.Printf("SHORTENER_SECRET: %s\n", viper.GetString("secret"))
 		},
 	}
 }
@@ -225,14 +266,13 @@ func newShortenCreateCmd() *cobra.Command {
 				Describe:    description,
 			}
 
-			client := resty.New()
+			client := newClient()
 			defer client.Close()
 
 			var response types.ResShorten
 			var resErr types.ResErr
 
 			res, err := client.R().
-				SetHeader("X-API-KEY", cfg.APIKEY).
 				SetContentType("application/json").
 				SetBody(req).
 				SetResult(&response).
@@ -284,13 +324,12 @@ func newShortenDeleteCmd() *cobra.Command {
 
 			code := args[0]
 
-			client := resty.New()
+			client := newClient()
 			defer client.Close()
 
 			var resErr types.ResErr
 
 			res, err := client.R().
-				SetHeader("X-API-KEY", cfg.APIKEY).
 				SetContentType("application/json").
 				SetError(&resErr).
 				Delete(APIShortenURL + "/" + code)
@@ -346,11 +385,10 @@ func newShortenUpdateCmd() *cobra.Command {
 			var response types.ResShorten
 			var resErr types.ResErr
 
-			client := resty.New()
+			client := newClient()
 			defer client.Close()
 
 			res, err := client.R().
-				SetHeader("X-API-KEY", cfg.APIKEY).
 				SetContentType("application/json").
 				SetBody(req).
 				SetResult(&response).
@@ -402,14 +440,13 @@ func newShortenGetCmd() *cobra.Command {
 
 			code := args[0]
 
-			client := resty.New()
+			client := newClient()
 			defer client.Close()
 
 			var response types.ResShorten
 			var resErr types.ResErr
 
 			res, err := client.R().
-				SetHeader("X-API-KEY", cfg.APIKEY).
 				SetContentType("application/json").
 				SetResult(&response).
 				SetError(&resErr).
@@ -450,7 +487,7 @@ func newShortenListCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			isAll, _ := cmd.Flags().GetBool("all")
 
-			client := resty.New()
+			client := newClient()
 			defer client.Close()
 
 			var allData []types.ResShorten
@@ -470,7 +507,6 @@ func newShortenListCmd() *cobra.Command {
 					query.Set("order", "asc")
 
 					res, err := client.R().
-						SetHeader("X-API-KEY", cfg.APIKEY).
 						SetContentType("application/json").
 						SetResult(&response).
 						SetError(&resErr).
@@ -542,7 +578,6 @@ func newShortenListCmd() *cobra.Command {
 				}
 
 				res, err := client.R().
-					SetHeader("X-API-KEY", cfg.APIKEY).
 					SetContentType("application/json").
 					SetResult(&response).
 					SetError(&resErr).
@@ -600,6 +635,57 @@ func newShortenListCmd() *cobra.Command {
 	return cmd
 }
 
+func newShortenLoopsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "loops",
+		Short:   "Scan short links for redirect loops",
+		Example: `  shortener loops`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return checkConfig()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := newClient()
+			defer client.Close()
+
+			var response struct {
+				Data []types.ResShortenLoop `json:"data"`
+			}
+			var resErr types.ResErr
+
+			res, err := client.R().
+				SetContentType("application/json").
+				SetResult(&response).
+				SetError(&resErr).
+				Get(APIRequestURL + "/maintenance/loops")
+			if err != nil {
+				return fmt.Errorf("failed to scan redirect loops: \n  %w", err)
+			}
+
+			if res.StatusCode() != http.StatusOK {
+				return fmt.Errorf("failed to scan redirect loops: \n  status code: %d \n      errcode: %d \n      errinfo: %s",
+					res.StatusCode(),
+					resErr.ErrCode,
+					resErr.ErrInfo)
+			}
+
+			if len(response.Data) == 0 {
+				fmt.Println("No redirect loops found")
+				return nil
+			}
+
+			for _, item := range response.Data {
+				fmt.Printf("  Short Code: %s\n", item.Code)
+				fmt.Printf("Original URL: %s\n", item.OriginalURL)
+				fmt.Printf("      Reason: %s\n", item.ErrInfo)
+				fmt.Println("--------------------------------")
+			}
+			fmt.Printf("  Total Items: %d\n", len(response.Data))
+
+			return nil
+		},
+	}
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(0)
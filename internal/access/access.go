@@ -0,0 +1,26 @@
+package access
+
+// 用户角色
+const (
+	RoleAdmin = "admin" // 管理员，可管理所有用户及短链接
+	RoleUser  = "user"  // 普通用户，仅可管理自己的短链接
+)
+
+// 工作区成员角色
+const (
+	WorkspaceRoleAdmin  = "admin"  // 工作区管理员，可管理工作区内所有短链接及访问记录
+	WorkspaceRoleMember = "member" // 普通成员，仅可管理自己的短链接
+)
+
+// API Key 权限范围
+const (
+	ScopeLinksRead   = "links:read"   // 查看短链接
+	ScopeLinksWrite  = "links:write"  // 添加、修改、删除短链接及其访问记录
+	ScopeHistoryRead = "history:read" // 查看访问记录
+	ScopeAdmin       = "admin"        // 管理员接口及 API Key 管理
+)
+
+// Scopes 所有权限范围，每次调用返回新的切片
+func Scopes() []string {
+	return []string{ScopeLinksRead, ScopeLinksWrite, ScopeHistoryRead, ScopeAdmin}
+}
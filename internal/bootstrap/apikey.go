@@ -0,0 +1,65 @@
+package bootstrap
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+// apiKeyImportedKey 保存已导入的配置密钥哈希的设置项
+const apiKeyImportedKey = "apikey.imported"
+
+// initAPIKey 将配置或环境变量中的 API Key 导入数据库，归属默认工作区的第一个管理员并具有全部权限
+// 每个密钥只导入一次，在接口中删除后不会再次导入
+func initAPIKey() {
+	if shared.GlobalAPIKey == "" {
+		return
+	}
+
+	keyHash := utils.HashSecret(shared.GlobalAPIKey)
+	settingLogic := logics.NewSettingLogic()
+	stored, err := settingLogic.SettingGet(apiKeyImportedKey)
+	if err != nil {
+		panic("load imported api key failed: " + err.Error())
+	}
+	if stored == keyHash {
+		return
+	}
+
+	var admin model.User
+	if err := shared.GlobalDB.Where("role = ?", access.RoleAdmin).Order("id ASC").First(&admin).Error; err != nil {
+		panic("find admin for api key failed: " + err.Error())
+	}
+
+	var count int64
+	if err := shared.GlobalDB.Model(&model.ApiKey{}).Where("key_hash = ?", keyHash).Count(&count).Error; err != nil {
+		panic("count api keys failed: " + err.Error())
+	}
+	if count == 0 {
+		nowTime := time.Now().Local()
+		apiKey := model.ApiKey{
+			WorkspaceID: shared.GlobalWorkspace.DefaultID,
+			UserID:      admin.ID,
+			Name:        "default",
+			Prefix:      shared.GlobalAPIKey[:min(len(shared.GlobalAPIKey), 4)],
+			KeyHash:     keyHash,
+			Scopes:      strings.Join(access.Scopes(), " "),
+			CreatedAt:   nowTime,
+			UpdatedAt:   nowTime,
+		}
+		if err := shared.GlobalDB.Create(&apiKey).Error; err != nil {
+			panic("import api key failed: " + err.Error())
+		}
+		slog.Info("imported api key from config", "username", admin.Username)
+	}
+
+	if err := settingLogic.SettingSet(apiKeyImportedKey, keyHash); err != nil {
+		panic("save imported api key failed: " + err.Error())
+	}
+}
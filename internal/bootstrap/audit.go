@@ -0,0 +1,37 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initAudit 初始化审计日志配置并定期清理超过保留天数的日志
+func initAudit() {
+	var auditCfg types.CfgAudit
+	if err := viper.UnmarshalKey("audit", &auditCfg); err != nil {
+		panic("audit config unmarshal failed: " + err.Error())
+	}
+
+	if !auditCfg.Enabled {
+		return
+	}
+
+	if auditCfg.RetentionDays < 0 {
+		auditCfg.RetentionDays = 0
+	}
+	if auditCfg.PurgeInterval <= 0 {
+		auditCfg.PurgeInterval = 86400
+	}
+
+	shared.GlobalAudit = &auditCfg
+
+	if auditCfg.RetentionDays > 0 {
+		auditLogic := logics.NewAuditLogic()
+		go auditLogic.AuditPurgeRun(backgroundCtx, time.Duration(auditCfg.PurgeInterval)*time.Second)
+	}
+}
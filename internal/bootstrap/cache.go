@@ -1,11 +1,14 @@
 package bootstrap
 
 import (
+	"strconv"
+
 	"github.com/bytedance/sonic"
 	"github.com/spf13/viper"
 
 	"go.xoder.cn/shortener/internal/cache"
 	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/logics"
 	"go.xoder.cn/shortener/internal/shared"
 	"go.xoder.cn/shortener/internal/types"
 )
@@ -68,7 +71,7 @@ func valkeyCache() *cache.ValkeyCache {
 	return cacheClient
 }
 
-// loadAllShorten 加载所有短链接
+// loadAllShorten 加载所有短链接及其短码索引
 func loadAllShorten() {
 	var shortens []model.Url
 	if err := shared.GlobalDB.Find(&shortens).Error; err != nil {
@@ -79,10 +82,12 @@ func loadAllShorten() {
 		panic("cache clear prefix failed: " + err.Error())
 	}
 
-	items := make(map[string]string, len(shortens))
+	items := make(map[string]string, len(shortens)*2)
 	for _, shorten := range shortens {
 		item, _ := sonic.Marshal(shorten)
-		items[shared.GlobalCache.GetKey(shorten.ShortCode)] = string(item)
+		entry, index := logics.ShortenCacheKeys(shorten.WorkspaceID, shorten.ShortCode)
+		items[shared.GlobalCache.GetKey(entry)] = string(item)
+		items[shared.GlobalCache.GetKey(index)] = strconv.FormatInt(shorten.WorkspaceID, 10)
 	}
 
 	if err := shared.GlobalCache.BatchSet(items); err != nil {
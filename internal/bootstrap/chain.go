@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initChain 初始化跳转链路检测配置
+func initChain() {
+	var chainCfg types.CfgChain
+	if err := viper.UnmarshalKey("chain", &chainCfg); err != nil {
+		panic("chain config unmarshal failed: " + err.Error())
+	}
+
+	if chainCfg.MaxHops <= 0 {
+		chainCfg.MaxHops = 10
+	}
+	if chainCfg.Timeout <= 0 {
+		chainCfg.Timeout = 5
+	}
+
+	shared.GlobalChain = &chainCfg
+}
@@ -39,8 +39,44 @@ func initDefaultConfig() {
 	// 服务器配置
 	viper.SetDefault("server.address", ":8080")
 	viper.SetDefault("server.trusted-platform", "")
+	viper.SetDefault("server.trusted_proxies", []string{})
 	viper.SetDefault("server.site_url", "http://localhost:8080")
 	viper.SetDefault("server.api_key", "")
+	viper.SetDefault("server.socket", "")
+	viper.SetDefault("server.socket_mode", "0660")
+	viper.SetDefault("server.read_timeout", 15)
+	viper.SetDefault("server.write_timeout", 30)
+	viper.SetDefault("server.idle_timeout", 120)
+	viper.SetDefault("server.shutdown_timeout", 30)
+	viper.SetDefault("server.tls.enabled", false)
+	viper.SetDefault("server.tls.cert_file", "")
+	viper.SetDefault("server.tls.key_file", "")
+	viper.SetDefault("server.tls.client_ca", "")
+	viper.SetDefault("server.tls.client_auth", "optional")
+	viper.SetDefault("server.tls.min_version", "1.2")
+	viper.SetDefault("server.tls.cipher_suites", []string{})
+	viper.SetDefault("server.tls.reload_interval", 30)
+	viper.SetDefault("server.tls.redirect_address", "")
+	viper.SetDefault("server.tls.hsts_max_age", 0)
+	viper.SetDefault("server.tls.hsts_include_subdomains", false)
+	viper.SetDefault("server.tls.hsts_preload", false)
+	viper.SetDefault("server.http3.enabled", false)
+	viper.SetDefault("server.http3.address", "")
+	viper.SetDefault("server.http3.alt_svc_max_age", 86400)
+	viper.SetDefault("log.level", "info")
+	viper.SetDefault("log.format", "text")
+	viper.SetDefault("log.access", true)
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("metrics.address", "")
+	viper.SetDefault("metrics.token", "")
+	viper.SetDefault("server.cors.enabled", false)
+	viper.SetDefault("server.cors.allow_origins", []string{})
+	viper.SetDefault("server.cors.allow_methods", []string{"GET", "HEAD", "POST", "PUT", "DELETE"})
+	viper.SetDefault("server.cors.allow_headers", []string{"Authorization", "Content-Type", "X-API-KEY", "X-Workspace-ID"})
+	viper.SetDefault("server.cors.expose_headers", []string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "X-Request-ID"})
+	viper.SetDefault("server.cors.allow_credentials", false)
+	viper.SetDefault("server.cors.max_age", 600)
 
 	// 短链生成配置
 	viper.SetDefault("shortener.code_length", 6)
@@ -50,6 +86,78 @@ func initDefaultConfig() {
 	viper.SetDefault("admin.username", "")
 	viper.SetDefault("admin.password", "")
 
+	// 登录会话配置
+	viper.SetDefault("session.ttl", 86400)
+	viper.SetDefault("session.remember_ttl", 2592000)
+	viper.SetDefault("session.purge_interval", 3600)
+
+	// 工作区配置
+	viper.SetDefault("workspace.join_default", true)
+
+	// 审计日志配置
+	viper.SetDefault("audit.enabled", true)
+	viper.SetDefault("audit.retention_days", 180)
+	viper.SetDefault("audit.purge_interval", 86400)
+
+	// 登录失败锁定配置
+	viper.SetDefault("auth.lockout.enabled", true)
+	viper.SetDefault("auth.lockout.attempts", 5)
+	viper.SetDefault("auth.lockout.ip_attempts", 20)
+	viper.SetDefault("auth.lockout.backoff", 30)
+	viper.SetDefault("auth.lockout.max_lockout", 900)
+	viper.SetDefault("auth.lockout.window", 900)
+
+	// 请求限流配置
+	viper.SetDefault("ratelimit.enabled", false)
+	viper.SetDefault("ratelimit.redirect.limit", 120)
+	viper.SetDefault("ratelimit.redirect.window", 60)
+	viper.SetDefault("ratelimit.login.limit", 10)
+	viper.SetDefault("ratelimit.login.window", 60)
+	viper.SetDefault("ratelimit.api.limit", 300)
+	viper.SetDefault("ratelimit.api.window", 60)
+
+	// 请求签名配置
+	viper.SetDefault("auth.hmac.enabled", true)
+	viper.SetDefault("auth.hmac.max_skew", 300)
+	viper.SetDefault("auth.hmac.max_body", 1048576)
+	viper.SetDefault("auth.hmac.static_keys", true)
+
+	// 客户端证书认证配置
+	viper.SetDefault("auth.mtls.enabled", false)
+	viper.SetDefault("auth.mtls.crl_files", []string{})
+	viper.SetDefault("auth.mtls.crl_reload", 3600)
+	viper.SetDefault("auth.mtls.deny_fingerprints", []string{})
+
+	// JWT 配置
+	viper.SetDefault("auth.jwt.enabled", false)
+	viper.SetDefault("auth.jwt.algorithm", "HS256")
+	viper.SetDefault("auth.jwt.secret", "")
+	viper.SetDefault("auth.jwt.private_key", "")
+	viper.SetDefault("auth.jwt.public_key", "")
+	viper.SetDefault("auth.jwt.issuer", "shortener")
+	viper.SetDefault("auth.jwt.audience", "")
+	viper.SetDefault("auth.jwt.access_ttl", 900)
+	viper.SetDefault("auth.jwt.leeway", 30)
+
+	// 两步验证配置
+	viper.SetDefault("auth.totp.issuer", "Shortener")
+
+	// OIDC 单点登录配置
+	viper.SetDefault("auth.oidc.enabled", false)
+	viper.SetDefault("auth.oidc.issuer", "")
+	viper.SetDefault("auth.oidc.client_id", "")
+	viper.SetDefault("auth.oidc.client_secret", "")
+	viper.SetDefault("auth.oidc.redirect_url", "")
+	viper.SetDefault("auth.oidc.scopes", []string{"openid", "profile", "email"})
+	viper.SetDefault("auth.oidc.username_claim", "preferred_username")
+	viper.SetDefault("auth.oidc.role_claim", "")
+	viper.SetDefault("auth.oidc.admin_values", []string{})
+	viper.SetDefault("auth.oidc.allowed_values", []string{})
+	viper.SetDefault("auth.oidc.auto_create", true)
+	viper.SetDefault("auth.oidc.link_existing", false)
+	viper.SetDefault("auth.oidc.password_login", true)
+	viper.SetDefault("auth.oidc.allowed_redirects", []string{})
+
 	// 数据库配置
 	viper.SetDefault("database.type", "sqlite")
 	viper.SetDefault("database.log_level", 0)
@@ -100,6 +208,49 @@ func initDefaultConfig() {
 	viper.SetDefault("geoip.type", "ip2region")
 	viper.SetDefault("geoip.ip2region.path", "data/ip2region.xdb")
 	viper.SetDefault("geoip.ip2region.mode", "vector")
+
+	// 兜底页面配置
+	viper.SetDefault("fallback.root_url", "")
+	viper.SetDefault("fallback.not_found", "auto")
+	viper.SetDefault("fallback.not_found_url", "")
+	viper.SetDefault("fallback.code_param", "code")
+	viper.SetDefault("fallback.template_dir", "")
+	viper.SetDefault("fallback.site_name", "")
+
+	// 应用深度链接配置
+	viper.SetDefault("deeplink.schemes", []string{})
+	viper.SetDefault("deeplink.timeout", 1500)
+
+	// URL 规范化配置
+	viper.SetDefault("normalize.strip_tracking", false)
+	viper.SetDefault("normalize.tracking_params", []string{})
+	viper.SetDefault("normalize.dedupe", false)
+
+	// 目标地址策略配置
+	viper.SetDefault("policy.enabled", false)
+	viper.SetDefault("policy.allowed_domains", []string{})
+	viper.SetDefault("policy.blocked_domains", []string{})
+	viper.SetDefault("policy.block_ip_literal", false)
+	viper.SetDefault("policy.block_private", true)
+	viper.SetDefault("policy.resolve_dns", true)
+	viper.SetDefault("policy.dns_timeout", 3)
+
+	// 跳转链路检测配置
+	viper.SetDefault("chain.own_domains", []string{})
+	viper.SetDefault("chain.external_domains", []string{})
+	viper.SetDefault("chain.collapse", false)
+	viper.SetDefault("chain.max_hops", 10)
+	viper.SetDefault("chain.timeout", 5)
+
+	// 目标地址健康检测配置
+	viper.SetDefault("health.enabled", false)
+	viper.SetDefault("health.interval", 3600)
+	viper.SetDefault("health.timeout", 10)
+	viper.SetDefault("health.concurrency", 8)
+	viper.SetDefault("health.host_delay", 1000)
+	viper.SetDefault("health.method", "HEAD")
+	viper.SetDefault("health.user_agent", "ShortenerHealthChecker/1.0")
+	viper.SetDefault("health.batch_size", 200)
 }
 
 func initAPIKeyConfig() {
@@ -108,10 +259,7 @@ func initAPIKeyConfig() {
 		apiKey = viper.GetString("server.api_key")
 	}
 
-	if apiKey == "" {
-		apiKey = utils.GenerateCode(32)
-	}
-
+	// 为空时不导入，API Key 通过 /api/keys 管理
 	shared.GlobalAPIKey = apiKey
 }
 
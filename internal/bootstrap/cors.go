@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initCORS 初始化跨域资源共享配置
+func initCORS() {
+	var corsCfg types.CfgCORS
+	if err := viper.UnmarshalKey("server.cors", &corsCfg); err != nil {
+		panic("cors config unmarshal failed: " + err.Error())
+	}
+
+	if !corsCfg.Enabled {
+		return
+	}
+
+	if len(corsCfg.AllowOrigins) == 0 {
+		panic("cors config invalid: allow_origins is required")
+	}
+	for _, origin := range corsCfg.AllowOrigins {
+		if origin != "*" && strings.Count(origin, "*") > 1 {
+			panic("cors config invalid: allow_origins: " + origin)
+		}
+	}
+	// 允许任意来源携带凭据等同于任意网站都能以用户身份调用接口
+	if corsCfg.AllowCredentials && slices.Contains(corsCfg.AllowOrigins, "*") {
+		panic(`cors config invalid: allow_origins "*" cannot be used with allow_credentials`)
+	}
+	for i, method := range corsCfg.AllowMethods {
+		corsCfg.AllowMethods[i] = strings.ToUpper(method)
+	}
+	for i, header := range corsCfg.AllowHeaders {
+		corsCfg.AllowHeaders[i] = http.CanonicalHeaderKey(header)
+	}
+
+	shared.GlobalCORS = &corsCfg
+}
@@ -2,7 +2,9 @@ package bootstrap
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
@@ -43,8 +45,13 @@ func initDB() {
 	// if level < 0 || level > 4 {
 	// 	level = 1
 	// }
+	// SQL 日志经由 slog 输出，查询带有请求上下文时附加请求ID
 	gormCfg := &gorm.Config{
-		Logger: gormLogger.Default.LogMode(gormLogger.LogLevel(level)),
+		Logger: gormLogger.NewSlogLogger(slog.Default(), gormLogger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormLogger.LogLevel(level),
+			IgnoreRecordNotFoundError: true,
+		}),
 	}
 	shared.GlobalDB, err = gorm.Open(dialector, gormCfg)
 	if err != nil {
@@ -146,7 +153,7 @@ func connectMysql() gorm.Dialector {
 // migrate 数据库迁移 schema
 func migrate() {
 	// log.Println("migrate")
-	err := shared.GlobalDB.AutoMigrate(&model.Url{}, &model.History{})
+	err := shared.GlobalDB.AutoMigrate(&model.Url{}, &model.History{}, &model.Setting{}, &model.User{}, &model.ApiKey{}, &model.Session{}, &model.AuditLog{}, &model.Workspace{}, &model.WorkspaceMember{}, &model.WorkspaceUsage{})
 	if err != nil {
 		panic("failed to migrate database: " + err.Error())
 	}
@@ -0,0 +1,38 @@
+package bootstrap
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// unsafeSchemes 可在页面中执行脚本或嵌入内容的协议，不允许作为应用深度链接
+var unsafeSchemes = []string{"javascript", "data", "vbscript"}
+
+// initDeepLink 初始化应用深度链接配置
+func initDeepLink() {
+	var deepLinkCfg types.CfgDeepLink
+	if err := viper.UnmarshalKey("deeplink", &deepLinkCfg); err != nil {
+		panic("deeplink config unmarshal failed: " + err.Error())
+	}
+
+	schemes := make([]string, 0, len(deepLinkCfg.Schemes))
+	for _, scheme := range deepLinkCfg.Schemes {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if slices.Contains(unsafeSchemes, scheme) {
+			panic("deeplink config invalid: scheme not allowed: " + scheme)
+		}
+		schemes = append(schemes, scheme)
+	}
+	deepLinkCfg.Schemes = schemes
+
+	if deepLinkCfg.Timeout <= 0 {
+		deepLinkCfg.Timeout = 1500
+	}
+
+	shared.GlobalDeepLink = &deepLinkCfg
+}
@@ -0,0 +1,58 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/pkgs/health"
+	"go.xoder.cn/shortener/internal/pkgs/policy"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initHealth 初始化目标地址健康检测
+func initHealth() {
+	var healthCfg types.CfgHealth
+	if err := viper.UnmarshalKey("health", &healthCfg); err != nil {
+		panic("health config unmarshal failed: " + err.Error())
+	}
+
+	if !healthCfg.Enabled {
+		return
+	}
+
+	if healthCfg.Interval <= 0 {
+		healthCfg.Interval = 3600
+	}
+	if healthCfg.Timeout <= 0 {
+		healthCfg.Timeout = 10
+	}
+
+	checker := health.NewChecker(health.Options{
+		Timeout:     time.Duration(healthCfg.Timeout) * time.Second,
+		Concurrency: healthCfg.Concurrency,
+		HostDelay:   time.Duration(healthCfg.HostDelay) * time.Millisecond,
+		Method:      healthCfg.Method,
+		UserAgent:   healthCfg.UserAgent,
+		// 每次调用时读取，配置重载后使用新策略
+		Allow: func(ctx context.Context, rawURL string) error {
+			err := shared.GlobalPolicy.Load().Check(ctx, rawURL)
+			// 无法解析的域名由连接时的地址检查兜底，请求失败计为失效链接
+			if errors.Is(err, policy.ErrUnresolved) {
+				return nil
+			}
+			return err
+		},
+		AllowIP: func(addr netip.Addr) error {
+			return shared.GlobalPolicy.Load().CheckAddr(addr)
+		},
+	})
+
+	healthLogic := logics.NewHealthLogic(checker, healthCfg.BatchSize)
+	go healthLogic.HealthRun(backgroundCtx, time.Duration(healthCfg.Interval)*time.Second)
+}
@@ -0,0 +1,57 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/cache"
+	"go.xoder.cn/shortener/internal/pkgs/hmacsign"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// hmacNoncePrefix 已使用随机数的缓存键前缀
+const hmacNoncePrefix = "nonce:"
+
+// initHMAC 初始化请求签名，启用缓存时随机数保存在缓存中以便多实例共享
+func initHMAC() {
+	var hmacCfg types.CfgHMAC
+	if err := viper.UnmarshalKey("auth.hmac", &hmacCfg); err != nil {
+		panic("hmac config unmarshal failed: " + err.Error())
+	}
+
+	if !hmacCfg.StaticKeys && !hmacCfg.Enabled {
+		panic("auth.hmac.static_keys can not be disabled when auth.hmac is disabled")
+	}
+	shared.GlobalHMAC = &hmacCfg
+
+	if !hmacCfg.Enabled {
+		return
+	}
+
+	if hmacCfg.MaxSkew <= 0 {
+		hmacCfg.MaxSkew = 300
+	}
+	if hmacCfg.MaxBody <= 0 {
+		hmacCfg.MaxBody = 1048576
+	}
+
+	var store hmacsign.NonceStore
+	if shared.GlobalCache.Enabled {
+		store = &nonceCacheStore{cache: shared.GlobalCache}
+	} else {
+		store = hmacsign.NewMemoryNonceStore()
+	}
+	shared.GlobalSigner = hmacsign.NewVerifier(store, hmacNoncePrefix, time.Duration(hmacCfg.MaxSkew)*time.Second)
+}
+
+// nonceCacheStore 基于缓存的随机数存储
+type nonceCacheStore struct {
+	cache *cache.CacheManager
+}
+
+// Claim 记录随机数，已存在时返回 false
+func (t *nonceCacheStore) Claim(key string, ttl time.Duration) (bool, error) {
+	return t.cache.SetNX(t.cache.GetKey(key), "1", ttl)
+}
@@ -0,0 +1,41 @@
+package bootstrap
+
+import (
+	"net"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/middlewares"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initHTTP3 初始化 HTTP/3 配置，与 HTTPS 共用证书及客户端证书校验
+func initHTTP3() {
+	var http3Cfg types.CfgHTTP3
+	if err := viper.UnmarshalKey("server.http3", &http3Cfg); err != nil {
+		panic("http3 config unmarshal failed: " + err.Error())
+	}
+
+	if !http3Cfg.Enabled {
+		return
+	}
+
+	if shared.GlobalTLS == nil {
+		panic("http3 config invalid: server.tls is required")
+	}
+
+	if http3Cfg.Address == "" {
+		http3Cfg.Address = viper.GetString("server.address")
+	}
+	_, port, err := net.SplitHostPort(http3Cfg.Address)
+	if err != nil {
+		panic("http3 config invalid: address: " + err.Error())
+	}
+	if http3Cfg.AltSvcMaxAge <= 0 {
+		http3Cfg.AltSvcMaxAge = 86400
+	}
+
+	shared.GlobalHTTP3 = &http3Cfg
+	shared.GlobalAltSvc = middlewares.AltSvcHeader(port, http3Cfg.AltSvcMaxAge)
+}
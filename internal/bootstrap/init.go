@@ -44,15 +44,87 @@ func init() {
 
 // 初始化
 func bootstrap() {
+	// init logger
+	initLog()
+
 	// init shared config
 	initSharedConfig()
 
 	// init db
 	initDB()
 
+	// init first admin
+	initAdmin()
+
+	// init workspaces
+	initWorkspace()
+
+	// import api key from config
+	initAPIKey()
+
+	// init url normalizer
+	initNormalize()
+
+	// backfill canonical urls of existing links
+	go backfillCanonical(backgroundCtx)
+
 	// init cache
 	initCache()
 
+	// init login sessions
+	initSession()
+
+	// init audit log
+	initAudit()
+
+	// init trusted proxies
+	initTrustedProxies()
+
+	// init login lockout
+	initLockout()
+
+	// init rate limiting
+	initRateLimit()
+
+	// init request signing
+	initHMAC()
+
+	// init https
+	initTLS()
+
+	// init client certificate authentication
+	initMTLS()
+
+	// init http/3
+	initHTTP3()
+
+	// init cors
+	initCORS()
+
+	// init prometheus metrics
+	initMetrics()
+
+	// init jwt
+	initJWT()
+
+	// init oidc single sign-on
+	initOIDC()
+
 	// init geoip
 	initGeoIP()
+
+	// init redirect chain detection
+	initChain()
+
+	// init app deep links
+	initDeepLink()
+
+	// init destination policy
+	initPolicy()
+
+	// init health checker
+	initHealth()
+
+	// init page templates
+	initTemplates()
 }
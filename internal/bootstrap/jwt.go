@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/pkgs/jwtauth"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+// initJWT 初始化 JWT 签发与校验
+func initJWT() {
+	var jwtCfg types.CfgJWT
+	if err := viper.UnmarshalKey("auth.jwt", &jwtCfg); err != nil {
+		panic("jwt config unmarshal failed: " + err.Error())
+	}
+
+	if !jwtCfg.Enabled {
+		return
+	}
+
+	if jwtCfg.Algorithm == "" {
+		jwtCfg.Algorithm = jwtauth.AlgHS256
+	}
+	if jwtCfg.AccessTTL <= 0 {
+		jwtCfg.AccessTTL = 900
+	}
+	// 未配置密钥时随机生成，重启后已签发的访问令牌失效
+	if jwtCfg.Algorithm == jwtauth.AlgHS256 && jwtCfg.Secret == "" {
+		slog.Warn("auth.jwt.secret is empty, using a random secret")
+		jwtCfg.Secret = utils.GenerateSecret(64)
+	}
+
+	manager, err := jwtauth.New(jwtauth.Options{
+		Algorithm:      jwtCfg.Algorithm,
+		Secret:         jwtCfg.Secret,
+		PrivateKeyFile: jwtCfg.PrivateKey,
+		PublicKeyFile:  jwtCfg.PublicKey,
+		Issuer:         jwtCfg.Issuer,
+		Audience:       jwtCfg.Audience,
+		AccessTTL:      time.Duration(jwtCfg.AccessTTL) * time.Second,
+		Leeway:         time.Duration(jwtCfg.Leeway) * time.Second,
+	})
+	if err != nil {
+		panic("jwt init failed: " + err.Error())
+	}
+
+	shared.GlobalJWT = manager
+}
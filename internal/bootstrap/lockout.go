@@ -0,0 +1,99 @@
+package bootstrap
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/cache"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/lockout"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// lockoutCachePrefix 登录失败记录的缓存键前缀
+const lockoutCachePrefix = "lockout:"
+
+// initLockout 初始化登录失败锁定，启用缓存时记录保存在缓存中以便多实例共享
+func initLockout() {
+	var lockoutCfg types.CfgLockout
+	if err := viper.UnmarshalKey("auth.lockout", &lockoutCfg); err != nil {
+		panic("lockout config unmarshal failed: " + err.Error())
+	}
+
+	if !lockoutCfg.Enabled {
+		return
+	}
+
+	var store lockout.Store
+	if shared.GlobalCache.Enabled {
+		store = &lockoutCacheStore{cache: shared.GlobalCache}
+	} else {
+		store = lockout.NewMemoryStore()
+	}
+
+	policy := lockout.Policy{
+		Attempts:   lockoutCfg.Attempts,
+		Backoff:    time.Duration(lockoutCfg.Backoff) * time.Second,
+		MaxLockout: time.Duration(lockoutCfg.MaxLockout) * time.Second,
+		Window:     time.Duration(lockoutCfg.Window) * time.Second,
+	}
+	shared.GlobalLockoutUser = lockout.New(store, lockoutCachePrefix+"user:", policy)
+
+	policy.Attempts = lockoutCfg.IPAttempts
+	shared.GlobalLockoutIP = lockout.New(store, lockoutCachePrefix+"ip:", policy)
+}
+
+// lockoutCacheStore 基于缓存的失败记录存储
+type lockoutCacheStore struct {
+	cache *cache.CacheManager
+}
+
+// Incr 失败次数加一
+func (t *lockoutCacheStore) Incr(key string, ttl time.Duration) (int64, error) {
+	count, err := t.cache.Incr(t.cache.GetKey(key), ttl)
+	if err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		slog.Error("cache lockout counter failed", "err", err)
+	}
+	return count, err
+}
+
+// Decr 失败次数减一
+func (t *lockoutCacheStore) Decr(key string, ttl time.Duration) (int64, error) {
+	count, err := t.cache.Decr(t.cache.GetKey(key), ttl)
+	if err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		slog.Error("cache lockout counter failed", "err", err)
+	}
+	return count, err
+}
+
+// Get 读取值，各缓存实现的键不存在错误不同，读取失败时按 0 计
+func (t *lockoutCacheStore) Get(key string) (int64, error) {
+	data, err := t.cache.Get(t.cache.GetKey(key))
+	if err != nil {
+		return 0, nil
+	}
+	value, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return value, nil
+}
+
+// Set 保存值
+func (t *lockoutCacheStore) Set(key string, value int64, ttl time.Duration) {
+	if err := t.cache.Set(t.cache.GetKey(key), value, ttl); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		slog.Error("cache lockout state failed", "err", err)
+	}
+}
+
+// Delete 删除记录
+func (t *lockoutCacheStore) Delete(key string) {
+	if err := t.cache.Delete(t.cache.GetKey(key)); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		slog.Error("delete lockout state failed", "err", err)
+	}
+}
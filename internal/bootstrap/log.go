@@ -0,0 +1,44 @@
+package bootstrap
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/pkgs/logging"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initLog 初始化日志，标准库 log 的输出同样经由 slog 记录
+func initLog() {
+	logCfg := loadLogConfig()
+
+	logger, err := logging.New(os.Stderr, shared.GlobalLogLevel, logCfg.Format)
+	if err != nil {
+		panic("log config invalid: " + err.Error())
+	}
+	slog.SetDefault(logger)
+	shared.GlobalAccessLog = logCfg.Access
+}
+
+// reloadLog 重新加载日志级别，日志格式与访问日志开关需重启后生效
+func reloadLog() {
+	loadLogConfig()
+}
+
+// loadLogConfig 读取日志配置并设置日志级别
+func loadLogConfig() types.CfgLog {
+	var logCfg types.CfgLog
+	if err := viper.UnmarshalKey("log", &logCfg); err != nil {
+		panic("log config unmarshal failed: " + err.Error())
+	}
+
+	level, err := logging.ParseLevel(logCfg.Level)
+	if err != nil {
+		panic("log config invalid: level: " + err.Error())
+	}
+	shared.GlobalLogLevel.Set(level)
+	return logCfg
+}
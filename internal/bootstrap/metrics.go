@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/pkgs/metrics"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initMetrics 初始化 Prometheus 指标，并注册数据库连接池指标
+func initMetrics() {
+	var metricsCfg types.CfgMetrics
+	if err := viper.UnmarshalKey("metrics", &metricsCfg); err != nil {
+		panic("metrics config unmarshal failed: " + err.Error())
+	}
+
+	if !metricsCfg.Enabled {
+		return
+	}
+
+	if metricsCfg.Path == "" {
+		metricsCfg.Path = "/metrics"
+	}
+	if !strings.HasPrefix(metricsCfg.Path, "/") {
+		panic("metrics config invalid: path must start with /")
+	}
+
+	sqlDB, err := shared.GlobalDB.DB()
+	if err != nil {
+		panic("metrics init failed: " + err.Error())
+	}
+	if err := metrics.RegisterDB(sqlDB, viper.GetString("database.type")); err != nil {
+		panic("metrics init failed: " + err.Error())
+	}
+
+	shared.GlobalMetrics = &metricsCfg
+}
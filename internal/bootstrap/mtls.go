@@ -0,0 +1,82 @@
+package bootstrap
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/pkgs/certauth"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initMTLS 初始化客户端证书认证，吊销的证书在 TLS 握手时即被拒绝
+func initMTLS() {
+	var mtlsCfg types.CfgMTLS
+	if err := viper.UnmarshalKey("auth.mtls", &mtlsCfg); err != nil {
+		panic("mtls config unmarshal failed: " + err.Error())
+	}
+
+	if !mtlsCfg.Enabled {
+		return
+	}
+
+	if shared.GlobalTLS == nil || shared.GlobalTLS.ClientCAs == nil {
+		panic("mtls config invalid: server.tls with client_ca is required")
+	}
+
+	identities := make([]certauth.Identity, 0, len(mtlsCfg.Identities))
+	for _, item := range mtlsCfg.Identities {
+		for _, scope := range item.Scopes {
+			if !slices.Contains(access.Scopes(), scope) {
+				panic("mtls config invalid: unknown scope " + scope + " in identity " + item.Name)
+			}
+		}
+		if item.Scopes == nil {
+			item.Scopes = []string{}
+		}
+		identities = append(identities, certauth.Identity{
+			Name:     item.Name,
+			Subject:  item.Subject,
+			SAN:      item.SAN,
+			SPKI:     item.SPKI,
+			Username: item.Username,
+			Scopes:   item.Scopes,
+		})
+	}
+
+	authority, err := certauth.New(identities, tlsClientCAs, mtlsCfg.CRLFiles, mtlsCfg.DenyFingerprints)
+	if err != nil {
+		panic("mtls init failed: " + err.Error())
+	}
+	shared.GlobalCertAuth = authority
+
+	shared.GlobalTLS.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.VerifiedChains) > 0 && authority.Revoked(state.VerifiedChains[0][0]) {
+			return errors.New("client certificate revoked")
+		}
+		return nil
+	}
+
+	if len(mtlsCfg.CRLFiles) > 0 && mtlsCfg.CRLReload > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Duration(mtlsCfg.CRLReload) * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-backgroundCtx.Done():
+					return
+				case <-ticker.C:
+				}
+				if err := authority.Reload(); err != nil {
+					slog.Error("reload crl failed", "err", err)
+				}
+			}
+		}()
+	}
+}
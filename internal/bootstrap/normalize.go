@@ -0,0 +1,62 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/pkgs/urlnorm"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initNormalize 初始化 URL 规范化
+func initNormalize() {
+	var normalizeCfg types.CfgNormalize
+	if err := viper.UnmarshalKey("normalize", &normalizeCfg); err != nil {
+		panic("normalize config unmarshal failed: " + err.Error())
+	}
+
+	shared.GlobalURLNorm.Store(urlnorm.New(urlnorm.Options{
+		StripTracking:  normalizeCfg.StripTracking,
+		TrackingParams: normalizeCfg.TrackingParams,
+	}))
+}
+
+// backfillCanonical 为缺少规范化地址的短链接补全数据，启动时在后台执行一次
+func backfillCanonical(ctx context.Context) {
+	db := shared.GlobalDB.WithContext(ctx)
+	normalizer := shared.GlobalURLNorm.Load()
+
+	var batch []model.Url
+	err := db.Model(&model.Url{}).
+		Where("canonical_hash = ?", "").
+		FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+			for _, item := range batch {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				canonical, err := normalizer.Normalize(item.OriginalURL)
+				if err != nil {
+					// 历史数据中无法规范化的地址保留原样
+					canonical = item.OriginalURL
+				}
+
+				if err := db.Model(&model.Url{}).Where("id = ?", item.ID).UpdateColumns(map[string]any{
+					"canonical_url":  canonical,
+					"canonical_hash": urlnorm.Hash(canonical),
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Error("backfill canonical url failed", "err", err)
+	}
+}
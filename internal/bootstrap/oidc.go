@@ -0,0 +1,42 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/pkgs/sso"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initOIDC 初始化 OIDC 单点登录
+func initOIDC() {
+	var oidcCfg types.CfgOIDC
+	if err := viper.UnmarshalKey("auth.oidc", &oidcCfg); err != nil {
+		panic("oidc config unmarshal failed: " + err.Error())
+	}
+
+	if !oidcCfg.Enabled {
+		return
+	}
+
+	if oidcCfg.Issuer == "" || oidcCfg.ClientID == "" {
+		panic("oidc config invalid: issuer and client_id are required")
+	}
+	if oidcCfg.RedirectURL == "" {
+		oidcCfg.RedirectURL = strings.TrimSuffix(viper.GetString("server.site_url"), "/") + "/api/account/oidc/callback"
+	}
+	if oidcCfg.UsernameClaim == "" {
+		oidcCfg.UsernameClaim = "preferred_username"
+	}
+
+	shared.GlobalOIDC = &oidcCfg
+	shared.GlobalSSO = sso.New(sso.Options{
+		Issuer:       oidcCfg.Issuer,
+		ClientID:     oidcCfg.ClientID,
+		ClientSecret: oidcCfg.ClientSecret,
+		RedirectURL:  oidcCfg.RedirectURL,
+		Scopes:       oidcCfg.Scopes,
+	})
+}
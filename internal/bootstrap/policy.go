@@ -0,0 +1,110 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/pkgs/policy"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// policyFingerprintKey 保存当前生效策略指纹的设置项
+const policyFingerprintKey = "policy.fingerprint"
+
+// policyRecheck 正在执行的策略重新检查，配置重载时取消上一次检查，新的检查在其退出后开始
+var policyRecheck struct {
+	sync.Mutex
+	cancel      context.CancelFunc // 为 nil 表示没有正在执行的检查
+	done        chan struct{}
+	fingerprint string // 正在检查的策略指纹
+}
+
+// initPolicy 初始化目标地址策略
+func initPolicy() {
+	var policyCfg types.CfgPolicy
+	if err := viper.UnmarshalKey("policy", &policyCfg); err != nil {
+		panic("policy config unmarshal failed: " + err.Error())
+	}
+
+	shared.GlobalPolicy.Store(policy.New(policy.Options{
+		Enabled:        policyCfg.Enabled,
+		AllowedDomains: policyCfg.AllowedDomains,
+		BlockedDomains: policyCfg.BlockedDomains,
+		BlockIPLiteral: policyCfg.BlockIPLiteral,
+		BlockPrivate:   policyCfg.BlockPrivate,
+		ResolveDNS:     policyCfg.ResolveDNS,
+		DNSTimeout:     time.Duration(policyCfg.DNSTimeout) * time.Second,
+	}))
+
+	// 策略变更后重新检查已有短链接
+	fingerprint := policyFingerprint(&policyCfg)
+	settingLogic := logics.NewSettingLogic()
+	stored, err := settingLogic.SettingGet(policyFingerprintKey)
+	if err != nil {
+		panic("load policy fingerprint failed: " + err.Error())
+	}
+
+	policyRecheck.Lock()
+	defer policyRecheck.Unlock()
+
+	// 上一次检查被取消时部分短链接已按其策略更新，即使指纹未变也需重新检查
+	if stored == fingerprint && policyRecheck.cancel == nil {
+		return
+	}
+	// 相同策略的检查已在执行
+	if policyRecheck.cancel != nil && policyRecheck.fingerprint == fingerprint {
+		return
+	}
+	if policyRecheck.cancel != nil {
+		policyRecheck.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(backgroundCtx)
+	prev, done := policyRecheck.done, make(chan struct{})
+	policyRecheck.cancel, policyRecheck.done, policyRecheck.fingerprint = cancel, done, fingerprint
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		if prev != nil {
+			<-prev
+		}
+
+		blocked, restored, err := logics.NewShortenLogic().ShortenRecheckPolicy(ctx)
+
+		policyRecheck.Lock()
+		if policyRecheck.done == done {
+			policyRecheck.cancel = nil
+		}
+		policyRecheck.Unlock()
+
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				slog.Error("policy recheck failed", "err", err)
+			}
+			return
+		}
+		slog.Info("policy recheck finished", "blocked", blocked, "restored", restored)
+
+		if err := settingLogic.SettingSet(policyFingerprintKey, fingerprint); err != nil {
+			slog.Error("save policy fingerprint failed", "err", err)
+		}
+	}()
+}
+
+// policyFingerprint 计算策略配置指纹
+func policyFingerprint(policyCfg *types.CfgPolicy) string {
+	data, _ := sonic.Marshal(policyCfg)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,30 @@
+package bootstrap
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/shared"
+)
+
+// initTrustedProxies 初始化可信反向代理，仅信任来自这些地址的 X-Forwarded-For 与 X-Real-IP
+func initTrustedProxies() {
+	proxies := viper.GetStringSlice("server.trusted_proxies")
+
+	trusted := make([]string, 0, len(proxies))
+	for _, proxy := range proxies {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				panic("server config invalid: trusted_proxies: " + proxy)
+			}
+		}
+		trusted = append(trusted, proxy)
+	}
+
+	shared.GlobalProxies = trusted
+}
@@ -0,0 +1,80 @@
+package bootstrap
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/cache"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/ratelimit"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// rateLimitCachePrefix 限流计数的缓存键前缀
+const rateLimitCachePrefix = "ratelimit:"
+
+// initRateLimit 初始化请求限流，启用缓存时计数保存在缓存中以便多实例共享
+func initRateLimit() {
+	var rateLimitCfg types.CfgRateLimit
+	if err := viper.UnmarshalKey("ratelimit", &rateLimitCfg); err != nil {
+		panic("ratelimit config unmarshal failed: " + err.Error())
+	}
+
+	if !rateLimitCfg.Enabled {
+		return
+	}
+
+	var store ratelimit.Store
+	if shared.GlobalCache.Enabled {
+		store = &rateLimitCacheStore{cache: shared.GlobalCache}
+	} else {
+		store = ratelimit.NewMemoryStore()
+	}
+
+	shared.GlobalRateRedirect = newLimiter(store, "redirect:", rateLimitCfg.Redirect)
+	shared.GlobalRateLogin = newLimiter(store, "login:", rateLimitCfg.Login)
+	shared.GlobalRateAPI = newLimiter(store, "api:", rateLimitCfg.API)
+}
+
+// newLimiter 创建限流器，未设置请求数时返回 nil 表示不限制
+func newLimiter(store ratelimit.Store, prefix string, policyCfg types.CfgRateLimitPolicy) *ratelimit.Limiter {
+	if policyCfg.Limit <= 0 {
+		return nil
+	}
+	return ratelimit.New(store, rateLimitCachePrefix+prefix, ratelimit.Policy{
+		Limit:  policyCfg.Limit,
+		Window: time.Duration(policyCfg.Window) * time.Second,
+	})
+}
+
+// rateLimitCacheStore 基于缓存的计数存储
+type rateLimitCacheStore struct {
+	cache *cache.CacheManager
+}
+
+// Incr 计数加一
+func (t *rateLimitCacheStore) Incr(key string, ttl time.Duration) (int64, error) {
+	count, err := t.cache.Incr(t.cache.GetKey(key), ttl)
+	if err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		slog.Error("cache rate limit counter failed", "err", err)
+	}
+	return count, err
+}
+
+// Get 读取计数，各缓存实现的键不存在错误不同，读取失败时按 0 计
+func (t *rateLimitCacheStore) Get(key string) (int64, error) {
+	data, err := t.cache.Get(t.cache.GetKey(key))
+	if err != nil {
+		return 0, nil
+	}
+	count, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return count, nil
+}
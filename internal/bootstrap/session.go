@@ -0,0 +1,34 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// initSession 初始化登录会话配置并定期清理过期会话
+func initSession() {
+	var sessionCfg types.CfgSession
+	if err := viper.UnmarshalKey("session", &sessionCfg); err != nil {
+		panic("session config unmarshal failed: " + err.Error())
+	}
+
+	if sessionCfg.TTL <= 0 {
+		sessionCfg.TTL = 86400
+	}
+	if sessionCfg.RememberTTL < sessionCfg.TTL {
+		sessionCfg.RememberTTL = sessionCfg.TTL
+	}
+	if sessionCfg.PurgeInterval <= 0 {
+		sessionCfg.PurgeInterval = 3600
+	}
+
+	shared.GlobalSession = &sessionCfg
+
+	sessionLogic := logics.NewSessionLogic()
+	go sessionLogic.SessionPurgeRun(backgroundCtx, time.Duration(sessionCfg.PurgeInterval)*time.Second)
+}
@@ -0,0 +1,79 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/shared"
+)
+
+// backgroundCtx 后台任务的上下文，关闭服务时取消
+var backgroundCtx, backgroundCancel = context.WithCancel(context.Background())
+
+// Shutdown 停止后台任务，等待访问记录写入完成后依次关闭数据库、缓存与IP地址库
+// 须在 HTTP 服务关闭后调用，此时不再产生新的访问记录
+func Shutdown(ctx context.Context) error {
+	backgroundCancel()
+
+	var errs []error
+	if err := logics.HistoryFlush(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("flush history: %w", err))
+	}
+
+	if sqlDB, err := shared.GlobalDB.DB(); err != nil {
+		errs = append(errs, fmt.Errorf("close database: %w", err))
+	} else if err := sqlDB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close database: %w", err))
+	}
+
+	if err := shared.GlobalCache.Close(); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		errs = append(errs, fmt.Errorf("close cache: %w", err))
+	}
+
+	if shared.GlobalGeoIP != nil {
+		if err := shared.GlobalGeoIP.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close geoip: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reload 重新读取配置文件，应用 URL 规范化、目标地址策略与页面模板配置，并重新加载服务器证书与证书吊销列表
+// 其他配置需重启服务后生效；加载失败时返回错误，服务继续运行
+func Reload() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+
+	reloadLog()
+	initNormalize()
+	initPolicy()
+	initTemplates()
+
+	if shared.GlobalTLSCert != nil {
+		if err := shared.GlobalTLSCert.Reload(); err != nil {
+			return err
+		}
+	}
+	if shared.GlobalCertAuth != nil {
+		if err := shared.GlobalCertAuth.Reload(); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("config reloaded", "file", viper.ConfigFileUsed())
+	return nil
+}
@@ -0,0 +1,14 @@
+package bootstrap
+
+import (
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/templates"
+)
+
+// initTemplates 加载自定义页面模板
+func initTemplates() {
+	if err := templates.Load(viper.GetString("fallback.template_dir")); err != nil {
+		panic("templates load failed: " + err.Error())
+	}
+}
@@ -0,0 +1,163 @@
+package bootstrap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"go.xoder.cn/shortener/internal/pkgs/certwatch"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// tlsClientCAs 客户端证书的 CA，用于校验吊销列表签名
+var tlsClientCAs []*x509.Certificate
+
+// initTLS 初始化 HTTPS 配置
+func initTLS() {
+	var tlsCfg types.CfgTLS
+	if err := viper.UnmarshalKey("server.tls", &tlsCfg); err != nil {
+		panic("tls config unmarshal failed: " + err.Error())
+	}
+
+	if !tlsCfg.Enabled {
+		return
+	}
+
+	watcher, err := certwatch.New(tlsCfg.CertFile, tlsCfg.KeyFile)
+	if err != nil {
+		panic("load tls certificate failed: " + err.Error())
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tlsVersion(tlsCfg.MinVersion),
+		CipherSuites:   tlsCipherSuites(tlsCfg.CipherSuites),
+		GetCertificate: watcher.GetCertificate,
+	}
+
+	if tlsCfg.ClientCA != "" && tlsCfg.ClientAuth != "none" {
+		tlsClientCAs = loadCertificates(tlsCfg.ClientCA)
+		pool := x509.NewCertPool()
+		for _, ca := range tlsClientCAs {
+			pool.AddCert(ca)
+		}
+		tlsConfig.ClientCAs = pool
+
+		switch tlsCfg.ClientAuth {
+		case "require":
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		case "optional", "":
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		default:
+			panic("tls config invalid: client_auth must be none, optional or require")
+		}
+	}
+
+	shared.GlobalTLS = tlsConfig
+	shared.GlobalTLSCert = watcher
+	shared.GlobalHSTS = hstsHeader(&tlsCfg)
+
+	if tlsCfg.ReloadInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Duration(tlsCfg.ReloadInterval) * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-backgroundCtx.Done():
+					return
+				case <-ticker.C:
+				}
+				reloaded, err := watcher.ReloadIfChanged()
+				if err != nil {
+					slog.Error("reload tls certificate failed", "err", err)
+				} else if reloaded {
+					slog.Info("tls certificate reloaded", "not_after", watcher.NotAfter().Local().Format(time.DateTime))
+				}
+			}
+		}()
+	}
+}
+
+// tlsVersion 解析最低 TLS 版本
+func tlsVersion(version string) uint16 {
+	switch version {
+	case "1.2", "":
+		return tls.VersionTLS12
+	case "1.3":
+		return tls.VersionTLS13
+	default:
+		panic("tls config invalid: min_version must be 1.2 or 1.3")
+	}
+}
+
+// tlsCipherSuites 按名称解析密码套件，仅允许 Go 认为安全的套件
+func tlsCipherSuites(names []string) []uint16 {
+	if len(names) == 0 {
+		return nil
+	}
+
+	suites := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := suites[name]
+		if !ok {
+			panic("tls config invalid: unknown or insecure cipher suite " + name)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// hstsHeader Strict-Transport-Security 响应头，未启用时为空
+func hstsHeader(tlsCfg *types.CfgTLS) string {
+	if tlsCfg.HSTSMaxAge <= 0 {
+		return ""
+	}
+
+	header := "max-age=" + strconv.Itoa(tlsCfg.HSTSMaxAge)
+	if tlsCfg.HSTSIncludeSubdomains {
+		header += "; includeSubDomains"
+	}
+	if tlsCfg.HSTSPreload {
+		header += "; preload"
+	}
+	return header
+}
+
+// loadCertificates 读取 PEM 文件中的全部证书
+func loadCertificates(file string) []*x509.Certificate {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		panic("read certificate file failed: " + err.Error())
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			panic("parse certificate failed: " + file + ": " + err.Error())
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		panic("no certificate found in " + file)
+	}
+	return certs
+}
@@ -0,0 +1,43 @@
+package bootstrap
+
+import (
+	"log/slog"
+	"time"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+// initAdmin 首次启动时根据配置或环境变量创建管理员
+func initAdmin() {
+	var count int64
+	if err := shared.GlobalDB.Model(&model.User{}).Count(&count).Error; err != nil {
+		panic("count users failed: " + err.Error())
+	}
+
+	// 已存在用户时不再使用配置中的账号
+	if count > 0 {
+		shared.GlobalUser = nil
+		return
+	}
+
+	hash, err := utils.HashPassword(shared.GlobalUser.Password)
+	if err != nil {
+		panic("hash admin password failed: " + err.Error())
+	}
+
+	nowTime := time.Now().Local()
+	admin := model.User{
+		Username:  shared.GlobalUser.Username,
+		Password:  hash,
+		Role:      access.RoleAdmin,
+		CreatedAt: nowTime,
+		UpdatedAt: nowTime,
+	}
+	if err := shared.GlobalDB.Create(&admin).Error; err != nil {
+		panic("create admin failed: " + err.Error())
+	}
+	slog.Info("created admin user", "username", admin.Username)
+}
@@ -0,0 +1,88 @@
+package bootstrap
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// workspaceMigratedKey 已将现有用户加入默认工作区的设置项
+const workspaceMigratedKey = "workspace.migrated"
+
+// initWorkspace 确保默认工作区存在，并将升级前的数据归入默认工作区
+// 现有用户仅在首次升级时加入默认工作区，之后由管理员维护成员
+func initWorkspace() {
+	var workspaceCfg types.CfgWorkspace
+	if err := viper.UnmarshalKey("workspace", &workspaceCfg); err != nil {
+		panic("workspace config unmarshal failed: " + err.Error())
+	}
+
+	var workspace model.Workspace
+	err := shared.GlobalDB.Where("slug = ?", model.WorkspaceDefaultSlug).First(&workspace).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		nowTime := time.Now().Local()
+		workspace = model.Workspace{
+			Name:      "Default",
+			Slug:      model.WorkspaceDefaultSlug,
+			CreatedAt: nowTime,
+			UpdatedAt: nowTime,
+		}
+		err = shared.GlobalDB.Create(&workspace).Error
+	}
+	if err != nil {
+		panic("init default workspace failed: " + err.Error())
+	}
+	workspaceCfg.DefaultID = workspace.ID
+	shared.GlobalWorkspace = &workspaceCfg
+
+	// 未归属工作区的数据归入默认工作区
+	for _, item := range []any{&model.Url{}, &model.History{}, &model.ApiKey{}} {
+		if err := shared.GlobalDB.Model(item).Where("workspace_id = ?", 0).UpdateColumn("workspace_id", workspace.ID).Error; err != nil {
+			panic("migrate data to default workspace failed: " + err.Error())
+		}
+	}
+
+	settingLogic := logics.NewSettingLogic()
+	migrated, err := settingLogic.SettingGet(workspaceMigratedKey)
+	if err != nil {
+		panic("load workspace migration failed: " + err.Error())
+	}
+	if migrated != "" {
+		return
+	}
+
+	var users []model.User
+	if err := shared.GlobalDB.Select("id").Find(&users).Error; err != nil {
+		panic("load users failed: " + err.Error())
+	}
+	if len(users) > 0 {
+		nowTime := time.Now().Local()
+		members := make([]model.WorkspaceMember, 0, len(users))
+		for _, user := range users {
+			members = append(members, model.WorkspaceMember{
+				WorkspaceID: workspace.ID,
+				UserID:      user.ID,
+				Role:        access.WorkspaceRoleMember,
+				CreatedAt:   nowTime,
+			})
+		}
+		if err := shared.GlobalDB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(members, 200).Error; err != nil {
+			panic("join users to default workspace failed: " + err.Error())
+		}
+		slog.Info("joined existing users to default workspace", "users", len(users))
+	}
+
+	if err := settingLogic.SettingSet(workspaceMigratedKey, "1"); err != nil {
+		panic("save workspace migration failed: " + err.Error())
+	}
+}
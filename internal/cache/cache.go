@@ -1,19 +1,28 @@
 package cache
 
 import (
+	"errors"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+	"github.com/valkey-io/valkey-go"
+
 	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/metrics"
 )
 
 // Cache 缓存
 type Cache interface {
 	Ping() error
 	Set(key string, value any, ttl ...time.Duration) error
+	SetNX(key string, value string, ttl time.Duration) (bool, error)
+	Incr(key string, ttl time.Duration) (int64, error)
+	Decr(key string, ttl time.Duration) (int64, error)
 	Get(key string) (string, error)
 	Delete(key string) error
 	ClearPrefix(prefix string) error
 	BatchSet(values map[string]string, ttl ...time.Duration) error
+	Close() error
 }
 
 // CacheManager 缓存管理器
@@ -28,12 +37,22 @@ func NewCacheManager(enabled bool, cache Cache, prefix string) *CacheManager {
 	return &CacheManager{Enabled: enabled, Cache: cache, Prefix: prefix}
 }
 
-// Get 获取缓存
+// Get 获取缓存，并统计命中、未命中与读取失败次数
 func (c *CacheManager) Get(key string) (string, error) {
 	if !c.Enabled {
 		return "", ecodes.ErrCacheDisabled
 	}
-	return c.Cache.Get(key)
+
+	data, err := c.Cache.Get(key)
+	switch {
+	case err == nil:
+		metrics.CacheReads.WithLabelValues(metrics.CacheHit).Inc()
+	case IsNotFound(err):
+		metrics.CacheReads.WithLabelValues(metrics.CacheMiss).Inc()
+	default:
+		metrics.CacheReads.WithLabelValues(metrics.CacheError).Inc()
+	}
+	return data, err
 }
 
 // Set 设置缓存
@@ -44,6 +63,30 @@ func (c *CacheManager) Set(key string, value any, ttl ...time.Duration) error {
 	return c.Cache.Set(key, value, ttl...)
 }
 
+// SetNX 键不存在时设置缓存，返回是否设置成功
+func (c *CacheManager) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	if !c.Enabled {
+		return false, ecodes.ErrCacheDisabled
+	}
+	return c.Cache.SetNX(key, value, ttl)
+}
+
+// Incr 计数加一并返回加一后的值，同时将过期时间设为 ttl
+func (c *CacheManager) Incr(key string, ttl time.Duration) (int64, error) {
+	if !c.Enabled {
+		return 0, ecodes.ErrCacheDisabled
+	}
+	return c.Cache.Incr(key, ttl)
+}
+
+// Decr 计数减一并返回减一后的值，同时将过期时间设为 ttl
+func (c *CacheManager) Decr(key string, ttl time.Duration) (int64, error) {
+	if !c.Enabled {
+		return 0, ecodes.ErrCacheDisabled
+	}
+	return c.Cache.Decr(key, ttl)
+}
+
 // Delete 删除缓存
 func (c *CacheManager) Delete(key string) error {
 	if !c.Enabled {
@@ -76,6 +119,14 @@ func (c *CacheManager) Ping() error {
 	return c.Cache.Ping()
 }
 
+// Close 关闭缓存连接
+func (c *CacheManager) Close() error {
+	if !c.Enabled {
+		return ecodes.ErrCacheDisabled
+	}
+	return c.Cache.Close()
+}
+
 // GetKey 获取缓存key
 func (c *CacheManager) GetKey(key string) string {
 	if !c.Enabled {
@@ -83,3 +134,8 @@ func (c *CacheManager) GetKey(key string) string {
 	}
 	return c.Prefix + key
 }
+
+// IsNotFound 判断读取缓存的错误是否为键不存在
+func IsNotFound(err error) bool {
+	return errors.Is(err, redis.Nil) || valkey.IsValkeyNil(err) || errors.Is(err, errBaseNotFound)
+}
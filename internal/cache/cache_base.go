@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -8,6 +9,9 @@ import (
 	"go.xoder.cn/shortener/internal/ecodes"
 )
 
+// errBaseNotFound 缓存键不存在
+var errBaseNotFound = ecodes.GetGeneralError(ecodes.ErrCodeCacheKeyNotFound)
+
 // 缓存项结构
 type baseCacheItem struct {
 	Value      any
@@ -74,13 +78,61 @@ func (t *BaseCache) Set(key string, value any, ttl ...time.Duration) error {
 	return nil
 }
 
+func (t *BaseCache) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if item, exists := t.items[key]; exists && !item.Expired() {
+		return false, nil
+	}
+	var expiration int64
+	if ttl > 0 {
+		expiration = time.Now().Add(ttl).UnixNano()
+	}
+	t.items[key] = baseCacheItem{
+		Value:      value,
+		Expiration: expiration,
+	}
+	return true, nil
+}
+
+func (t *BaseCache) Incr(key string, ttl time.Duration) (int64, error) {
+	return t.incrBy(key, 1, ttl)
+}
+
+func (t *BaseCache) Decr(key string, ttl time.Duration) (int64, error) {
+	return t.incrBy(key, -1, ttl)
+}
+
+// incrBy 计数增加 delta 并将过期时间设为 ttl
+func (t *BaseCache) incrBy(key string, delta int64, ttl time.Duration) (int64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var count int64
+	if item, exists := t.items[key]; exists && !item.Expired() {
+		count, _ = strconv.ParseInt(item.Value.(string), 10, 64)
+	}
+	count += delta
+
+	var expiration int64
+	if ttl > 0 {
+		expiration = time.Now().Add(ttl).UnixNano()
+	}
+	t.items[key] = baseCacheItem{
+		Value:      strconv.FormatInt(count, 10),
+		Expiration: expiration,
+	}
+	return count, nil
+}
+
 func (t *BaseCache) Get(key string) (string, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
 	item, exists := t.items[key]
 	if !exists || item.Expired() {
-		return "", ecodes.GetGeneralError(ecodes.ErrCodeCacheKeyNotFound)
+		return "", errBaseNotFound
 	}
 	return item.Value.(string), nil
 }
@@ -121,3 +173,7 @@ func (t *BaseCache) BatchSet(values map[string]string, ttl ...time.Duration) err
 	}
 	return nil
 }
+
+func (t *BaseCache) Close() error {
+	return nil
+}
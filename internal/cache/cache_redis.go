@@ -32,6 +32,11 @@ func (t *RedisCache) Ping() error {
 	return t.client.Ping(context.Background()).Err()
 }
 
+// Close 关闭缓存连接
+func (t *RedisCache) Close() error {
+	return t.client.Close()
+}
+
 // Set 设置缓存
 func (t *RedisCache) Set(key string, value any, ttl ...time.Duration) error {
 	jsonBytes, err := sonic.Marshal(value)
@@ -41,11 +46,40 @@ func (t *RedisCache) Set(key string, value any, ttl ...time.Duration) error {
 
 	expire := 0
 	if len(ttl) > 0 {
-		expire = int(ttl[0])
+		expire = int(ttl[0] / time.Second)
 	}
 	return t.client.Set(context.Background(), key, string(jsonBytes), time.Duration(expire)*time.Second).Err()
 }
 
+// SetNX 键不存在时设置缓存，值原样保存
+func (t *RedisCache) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return t.client.SetNX(context.Background(), key, value, ttl).Result()
+}
+
+// Incr 计数加一并返回加一后的值，同时将过期时间设为 ttl
+func (t *RedisCache) Incr(key string, ttl time.Duration) (int64, error) {
+	return t.incrBy(key, 1, ttl)
+}
+
+// Decr 计数减一并返回减一后的值，同时将过期时间设为 ttl
+func (t *RedisCache) Decr(key string, ttl time.Duration) (int64, error) {
+	return t.incrBy(key, -1, ttl)
+}
+
+// incrBy 计数增加 delta 并将过期时间设为 ttl
+func (t *RedisCache) incrBy(key string, delta int64, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := t.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(context.Background(), key, delta)
+		pipe.Expire(context.Background(), key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
 // Get 获取缓存
 func (t *RedisCache) Get(key string) (string, error) {
 	data, err := t.client.Get(context.Background(), key).Result()
@@ -91,7 +125,7 @@ func (t *RedisCache) BatchSet(values map[string]string, ttl ...time.Duration) er
 
 	expire := 0
 	if len(ttl) > 0 {
-		expire = int(ttl[0])
+		expire = int(ttl[0] / time.Second)
 	}
 
 	for key, value := range values {
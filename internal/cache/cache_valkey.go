@@ -38,6 +38,12 @@ func (t *ValkeyCache) Ping() error {
 	return t.client.Do(context.Background(), t.client.B().Ping().Build()).Error()
 }
 
+// Close 关闭缓存连接
+func (t *ValkeyCache) Close() error {
+	t.client.Close()
+	return nil
+}
+
 // Set 设置缓存
 func (t *ValkeyCache) Set(key string, value any, ttl ...time.Duration) error {
 	jsonBytes, err := sonic.Marshal(value)
@@ -47,7 +53,7 @@ func (t *ValkeyCache) Set(key string, value any, ttl ...time.Duration) error {
 
 	expire := 0
 	if len(ttl) > 0 {
-		expire = int(ttl[0])
+		expire = int(ttl[0] / time.Second)
 	}
 	ctx := context.Background()
 	builder := t.client.B().Set().Key(key).Value(string(jsonBytes))
@@ -57,6 +63,46 @@ func (t *ValkeyCache) Set(key string, value any, ttl ...time.Duration) error {
 	return t.client.Do(ctx, builder.Build()).Error()
 }
 
+// SetNX 键不存在时设置缓存，值原样保存
+func (t *ValkeyCache) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	cmd := t.client.B().Set().Key(key).Value(value).Nx()
+	var err error
+	if expire := int64(ttl / time.Second); expire > 0 {
+		err = t.client.Do(context.Background(), cmd.ExSeconds(expire).Build()).Error()
+	} else {
+		err = t.client.Do(context.Background(), cmd.Build()).Error()
+	}
+	if valkey.IsValkeyNil(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Incr 计数加一并返回加一后的值，同时将过期时间设为 ttl
+func (t *ValkeyCache) Incr(key string, ttl time.Duration) (int64, error) {
+	return t.incrBy(key, 1, ttl)
+}
+
+// Decr 计数减一并返回减一后的值，同时将过期时间设为 ttl
+func (t *ValkeyCache) Decr(key string, ttl time.Duration) (int64, error) {
+	return t.incrBy(key, -1, ttl)
+}
+
+// incrBy 计数增加 delta 并将过期时间设为 ttl
+func (t *ValkeyCache) incrBy(key string, delta int64, ttl time.Duration) (int64, error) {
+	resps := t.client.DoMulti(context.Background(),
+		t.client.B().Multi().Build(),
+		t.client.B().Incrby().Key(key).Increment(delta).Build(),
+		t.client.B().Pexpire().Key(key).Milliseconds(ttl.Milliseconds()).Build(),
+		t.client.B().Exec().Build(),
+	)
+	results, err := resps[3].ToArray()
+	if err != nil {
+		return 0, err
+	}
+	return results[0].AsInt64()
+}
+
 // Get 获取缓存
 func (t *ValkeyCache) Get(key string) (string, error) {
 	resp, err := t.client.Do(context.Background(), t.client.B().Get().Key(key).Build()).ToString()
@@ -110,7 +156,7 @@ func (t *ValkeyCache) BatchSet(values map[string]string, ttl ...time.Duration) e
 
 	expire := 0
 	if len(ttl) > 0 {
-		expire = int(ttl[0])
+		expire = int(ttl[0] / time.Second)
 	}
 
 	cmds := make(valkey.Commands, 0, len(values))
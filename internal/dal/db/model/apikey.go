@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ApiKey API Key 表
+type ApiKey struct {
+	ID          int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                 // 主键ID
+	WorkspaceID int64      `gorm:"column:workspace_id;not null;default:0;index" json:"workspace_id"`             // 所属工作区ID，密钥仅可访问该工作区
+	UserID      int64      `gorm:"column:user_id;not null;index" json:"user_id"`                                 // 所属用户ID
+	Name        string     `gorm:"column:name;type:varchar(64);not null" json:"name"`                            // 名称
+	Prefix      string     `gorm:"column:prefix;type:varchar(16);not null" json:"prefix"`                        // 密钥前缀，用于识别
+	KeyHash     string     `gorm:"column:key_hash;type:char(64);uniqueIndex;not null" json:"-"`                  // 密钥的 SHA-256
+	Secret      string     `gorm:"column:secret;type:varchar(64);not null;default:''" json:"-"`                  // 请求签名密钥（HMAC-SHA256），为空表示不支持签名
+	Scopes      string     `gorm:"column:scopes;type:varchar(255);not null;default:''" json:"scopes"`            // 权限范围，以空格分隔
+	ExpiresAt   *time.Time `gorm:"column:expires_at;type:datetime;precision:6" json:"expires_at"`                // 过期时间，为空表示永不过期
+	LastUsedAt  *time.Time `gorm:"column:last_used_at;type:datetime;precision:6" json:"last_used_at"`            // 最近使用时间
+	UpdatedAt   time.Time  `gorm:"column:updated_at;type:datetime;precision:6;not null" json:"updated_at"`       // 更新时间
+	CreatedAt   time.Time  `gorm:"column:created_at;type:datetime;precision:6;not null;index" json:"created_at"` // 创建时间
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// 审计操作者类型
+const (
+	AuditActorUser      = "user"      // 登录会话或 JWT
+	AuditActorApiKey    = "api_key"   // API Key
+	AuditActorCert      = "cert"      // 客户端证书
+	AuditActorAnonymous = "anonymous" // 未登录，如登录失败
+)
+
+// 审计操作
+const (
+	AuditShortenCreate      = "shorten.create"
+	AuditShortenUpdate      = "shorten.update"
+	AuditShortenDelete      = "shorten.delete"
+	AuditShortenDeleteBatch = "shorten.delete_batch"
+	AuditHistoryDeleteBatch = "history.delete_batch"
+
+	AuditLogin         = "account.login"
+	AuditLoginFailed   = "account.login_failed"
+	AuditLockout       = "account.lockout"
+	AuditLogout        = "account.logout"
+	AuditSessionRevoke = "account.session_revoke"
+	AuditTotpEnable    = "account.totp_enable"
+	AuditTotpDisable   = "account.totp_disable"
+	AuditTotpRecovery  = "account.totp_recovery_renew"
+
+	AuditApiKeyCreate = "api_key.create"
+	AuditApiKeyUpdate = "api_key.update"
+	AuditApiKeyRotate = "api_key.rotate"
+	AuditApiKeyDelete = "api_key.delete"
+
+	AuditUserCreate    = "user.create"
+	AuditUserUpdate    = "user.update"
+	AuditUserDelete    = "user.delete"
+	AuditUserTotpReset = "user.totp_reset"
+
+	AuditWorkspaceCreate       = "workspace.create"
+	AuditWorkspaceUpdate       = "workspace.update"
+	AuditWorkspaceDelete       = "workspace.delete"
+	AuditWorkspaceMemberAdd    = "workspace.member_add"
+	AuditWorkspaceMemberUpdate = "workspace.member_update"
+	AuditWorkspaceMemberRemove = "workspace.member_remove"
+)
+
+// AuditLog 审计日志表，只追加不修改，仅按保留期限清理
+type AuditLog struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                  // 主键ID
+	ActorType   string    `gorm:"column:actor_type;type:varchar(16);not null" json:"actor_type"`                 // 操作者类型
+	ActorID     int64     `gorm:"column:actor_id;not null;default:0;index" json:"actor_id"`                      // 操作者用户ID，0 表示未知
+	ActorName   string    `gorm:"column:actor_name;type:varchar(64);not null;default:''" json:"actor_name"`      // 操作者用户名
+	ApiKeyID    int64     `gorm:"column:api_key_id;not null;default:0" json:"api_key_id"`                        // 通过 API Key 操作时的密钥ID
+	WorkspaceID int64     `gorm:"column:workspace_id;not null;default:0;index" json:"workspace_id"`              // 操作时所在的工作区ID，0 表示不属于任何工作区
+	Action      string    `gorm:"column:action;type:varchar(64);not null;index" json:"action"`                   // 操作
+	TargetType  string    `gorm:"column:target_type;type:varchar(32);not null;default:''" json:"target_type"`    // 操作对象类型
+	TargetID    string    `gorm:"column:target_id;type:varchar(255);not null;default:'';index" json:"target_id"` // 操作对象ID，批量操作时以逗号分隔
+	IP          string    `gorm:"column:ip;type:varchar(64);not null;default:''" json:"ip"`                      // 请求 IP
+	UserAgent   string    `gorm:"column:user_agent;type:varchar(255);not null;default:''" json:"user_agent"`     // 请求 User-Agent
+	Before      string    `gorm:"column:before_data;type:text" json:"before"`                                    // 操作前有变化的字段（JSON）
+	After       string    `gorm:"column:after_data;type:text" json:"after"`                                      // 操作后有变化的字段（JSON）
+	CreatedAt   time.Time `gorm:"column:created_at;type:datetime;precision:6;not null;index" json:"created_at"`  // 操作时间
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// 会话类型
+const (
+	SessionKindLogin   = "login"   // 登录令牌，直接用于访问接口
+	SessionKindRefresh = "refresh" // 刷新令牌，仅用于换取 JWT 访问令牌
+	SessionKindMFA     = "mfa"     // 两步验证挑战，仅用于完成登录，使用一次后失效
+)
+
+// Session 登录会话表
+type Session struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                 // 主键ID
+	UserID     int64     `gorm:"column:user_id;not null;index" json:"user_id"`                                 // 所属用户ID
+	TokenHash  string    `gorm:"column:token_hash;type:char(64);uniqueIndex;not null" json:"-"`                // 令牌的 SHA-256
+	Kind       string    `gorm:"column:kind;type:varchar(16);not null;default:'login'" json:"kind"`            // 会话类型
+	Scopes     string    `gorm:"column:scopes;type:varchar(255);not null;default:''" json:"scopes"`            // 刷新令牌签发的访问令牌的权限范围，以空格分隔；两步验证挑战中不为空表示登录后签发 JWT
+	Remember   bool      `gorm:"column:remember;not null;default:false" json:"remember"`                       // 是否记住登录
+	IP         string    `gorm:"column:ip;type:varchar(64);not null;default:''" json:"ip"`                     // 登录 IP
+	UserAgent  string    `gorm:"column:user_agent;type:varchar(255);not null;default:''" json:"user_agent"`    // 登录 User-Agent
+	ExpiresAt  time.Time `gorm:"column:expires_at;type:datetime;precision:6;not null;index" json:"expires_at"` // 过期时间，访问时顺延
+	LastSeenAt time.Time `gorm:"column:last_seen_at;type:datetime;precision:6;not null" json:"last_seen_at"`   // 最近访问时间
+	CreatedAt  time.Time `gorm:"column:created_at;type:datetime;precision:6;not null" json:"created_at"`       // 创建时间
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// Setting 系统设置表（键值对）
+type Setting struct {
+	Name      string    `gorm:"column:name;type:varchar(64);primaryKey" json:"name"`                    // 键
+	Value     string    `gorm:"column:value;type:text" json:"value"`                                    // 值
+	UpdatedAt time.Time `gorm:"column:updated_at;type:datetime;precision:6;not null" json:"updated_at"` // 更新时间
+}
@@ -4,16 +4,35 @@ import (
 	"time"
 )
 
+// 短网址状态
+const (
+	UrlStatusNormal   int8 = 0 // 正常
+	UrlStatusDisabled int8 = 1 // 已禁用
+	UrlStatusExpired  int8 = 2 // 已过期
+	UrlStatusBlocked  int8 = 3 // 目标地址被策略禁止
+)
+
+// HealthCodeBlocked 目标地址或其重定向地址被策略禁止，未完成检测
+const HealthCodeBlocked = -1
+
 // Url 短网址表
 type Url struct {
-	ID          int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                 // 主键ID
-	ShortCode   string    `gorm:"column:short_code;type:varchar(16);uniqueIndex;not null" json:"short_code"`    // 短码
-	OriginalURL string    `gorm:"column:original_url;type:varchar(2048);not null" json:"original_url"`          // 原始URL
-	Describe    string    `gorm:"column:describe;type:varchar(255)" json:"describe"`                            // 描述
-	Status      int8      `gorm:"column:status;type:smallint;default:0;index;not null" json:"status"`           // 状态
-	UpdatedAt   time.Time `gorm:"column:updated_at;type:datetime;precision:6;not null;index" json:"updated_at"` // 更新时间
-	CreatedAt   time.Time `gorm:"column:created_at;type:datetime;precision:6;not null;index" json:"created_at"` // 创建时间
-	Histories   []History `gorm:"foreignKey:UrlID;constraint:OnDelete:CASCADE"`
+	ID              int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                        // 主键ID
+	WorkspaceID     int64      `gorm:"column:workspace_id;not null;default:0;index" json:"workspace_id"`                    // 所属工作区ID
+	UserID          int64      `gorm:"column:user_id;not null;default:0;index" json:"user_id"`                              // 所属用户ID，0 表示无所属用户
+	ShortCode       string     `gorm:"column:short_code;type:varchar(16);uniqueIndex;not null" json:"short_code"`           // 短码
+	OriginalURL     string     `gorm:"column:original_url;type:varchar(2048);not null" json:"original_url"`                 // 原始URL
+	CanonicalURL    string     `gorm:"column:canonical_url;type:varchar(2048);not null;default:''" json:"canonical_url"`    // 规范化后的URL，用于搜索与去重
+	CanonicalHash   string     `gorm:"column:canonical_hash;type:char(64);not null;default:'';index" json:"canonical_hash"` // 规范化URL的 SHA-256
+	FallbackURL     string     `gorm:"column:fallback_url;type:varchar(2048);not null;default:''" json:"fallback_url"`      // 应用深度链接的网页回退地址
+	Describe        string     `gorm:"column:describe;type:varchar(255)" json:"describe"`                                   // 描述
+	Status          int8       `gorm:"column:status;type:smallint;default:0;index;not null" json:"status"`                  // 状态
+	HealthCode      int        `gorm:"column:health_code;type:smallint;default:0;index;not null" json:"health_code"`        // 最近一次检测的状态码（0 表示请求失败，-1 表示被策略禁止）
+	HealthLatency   int64      `gorm:"column:health_latency;default:0;not null" json:"health_latency"`                      // 最近一次检测的耗时（毫秒）
+	HealthCheckedAt *time.Time `gorm:"column:health_checked_at;type:datetime;precision:6;index" json:"health_checked_at"`   // 最近一次检测时间
+	UpdatedAt       time.Time  `gorm:"column:updated_at;type:datetime;precision:6;not null;index" json:"updated_at"`        // 更新时间
+	CreatedAt       time.Time  `gorm:"column:created_at;type:datetime;precision:6;not null;index" json:"created_at"`        // 创建时间
+	Histories       []History  `gorm:"foreignKey:UrlID;constraint:OnDelete:CASCADE"`
 }
 
 // // 按需添加以下索引
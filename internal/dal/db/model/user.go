@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// User 用户表
+type User struct {
+	ID           int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                 // 主键ID
+	Username     string    `gorm:"column:username;type:varchar(64);uniqueIndex;not null" json:"username"`        // 用户名
+	Password     string    `gorm:"column:password;type:varchar(255);not null" json:"-"`                          // 密码哈希（bcrypt）
+	Role         string    `gorm:"column:role;type:varchar(16);not null;default:'user'" json:"role"`             // 角色
+	TotpSecret   string    `gorm:"column:totp_secret;type:varchar(64);not null;default:''" json:"-"`             // TOTP 密钥（Base32），未启用时为待确认的密钥
+	TotpEnabled  bool      `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`               // 是否启用两步验证
+	TotpLastStep int64     `gorm:"column:totp_last_step;not null;default:0" json:"-"`                            // 最近使用的验证码时间步，防止重放
+	TotpRecovery string    `gorm:"column:totp_recovery;type:text" json:"-"`                                      // 未使用恢复码的 SHA-256，以空格分隔
+	OidcSub      *string   `gorm:"column:oidc_sub;type:varchar(255);uniqueIndex" json:"-"`                       // 关联的 OIDC 用户标识（sub），为空表示未关联
+	UpdatedAt    time.Time `gorm:"column:updated_at;type:datetime;precision:6;not null" json:"updated_at"`       // 更新时间
+	CreatedAt    time.Time `gorm:"column:created_at;type:datetime;precision:6;not null;index" json:"created_at"` // 创建时间
+}
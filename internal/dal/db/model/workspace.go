@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// WorkspaceDefaultSlug 默认工作区的标识，升级前的数据归入默认工作区
+const WorkspaceDefaultSlug = "default"
+
+// Workspace 工作区表
+type Workspace struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                 // 主键ID
+	Name       string    `gorm:"column:name;type:varchar(64);not null" json:"name"`                            // 名称
+	Slug       string    `gorm:"column:slug;type:varchar(64);uniqueIndex;not null" json:"slug"`                // 标识，创建后不可修改
+	Domain     string    `gorm:"column:domain;type:varchar(255);not null;default:'';index" json:"domain"`      // 短链接域名（主机[:端口]），为空表示使用站点地址
+	LinkQuota  int64     `gorm:"column:link_quota;not null;default:0" json:"link_quota"`                       // 短链接数量上限，0 表示不限制
+	ClickQuota int64     `gorm:"column:click_quota;not null;default:0" json:"click_quota"`                     // 每月访问次数上限，0 表示不限制
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:datetime;precision:6;not null" json:"updated_at"`       // 更新时间
+	CreatedAt  time.Time `gorm:"column:created_at;type:datetime;precision:6;not null;index" json:"created_at"` // 创建时间
+}
+
+// WorkspaceMember 工作区成员表
+type WorkspaceMember struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`                                      // 主键ID
+	WorkspaceID int64     `gorm:"column:workspace_id;not null;uniqueIndex:idx_workspace_member" json:"workspace_id"` // 工作区ID
+	UserID      int64     `gorm:"column:user_id;not null;uniqueIndex:idx_workspace_member;index" json:"user_id"`     // 用户ID
+	Role        string    `gorm:"column:role;type:varchar(16);not null;default:'member'" json:"role"`                // 成员角色
+	CreatedAt   time.Time `gorm:"column:created_at;type:datetime;precision:6;not null" json:"created_at"`            // 加入时间
+}
+
+// WorkspaceUsage 工作区每月用量表
+type WorkspaceUsage struct {
+	WorkspaceID int64  `gorm:"column:workspace_id;primaryKey;autoIncrement:false" json:"workspace_id"` // 工作区ID
+	Period      string `gorm:"column:period;type:varchar(7);primaryKey" json:"period"`                 // 月份，格式为 2006-01
+	Clicks      int64  `gorm:"column:clicks;not null;default:0" json:"clicks"`                         // 访问次数
+}
@@ -8,6 +8,7 @@ package ecodes
 10000-10099	用户通用错误	10001 用户不存在
 10100-10199	注册相关错误	10101 用户名已存在
 10200-10299	登录相关错误	10201 用户名或密码错误
+						  10203	两步验证码错误
 10300-10399	权限相关错误	10301 无访问权限
 						  10302	身份验证失败
 						  10303	未授权
@@ -31,6 +32,23 @@ package ecodes
 13000-13099	支付通用错误	13001	支付渠道不可用
 13100-13199	支付处理错误	13101	支付金额不符
 13200-13299	退款相关错误	13201	退款失败
+
+短链接模块 (14xxx)
+错误码范围	类别	示例代码	说明
+14000-14099	短链接通用错误	14001	短链接已禁用
+						  14002	短链接已过期
+14100-14199	目标地址错误	14101	目标地址被策略禁止
+						  14102	目标地址形成循环跳转
+						  14103	跳转链路过长
+
+工作区模块 (15xxx)
+错误码范围	类别	示例代码	说明
+15000-15099	工作区通用错误	15001	工作区不存在
+						  15002	未加入任何工作区
+						  15003	不是工作区成员
+						  15004	默认工作区不可删除
+15100-15199	配额相关错误	15101	短链接数量已达上限
+						  15102	本月访问次数已达上限
 */
 
 const (
@@ -39,6 +57,7 @@ const (
 	ErrCodeUserExists        = 10101
 	ErrCodeUserLoginFailed   = 10201
 	ErrCodeUserPasswordError = 10202
+	ErrCodeUserTotpError     = 10203
 
 	ErrCodeUserPermissionDenied = 10301
 	ErrCodeUserAuthFailed       = 10302
@@ -60,4 +79,19 @@ const (
 	ErrCodePaymentChannelNotAvailable = 13001
 	ErrCodePaymentAmountMismatch      = 13101
 	ErrCodeRefundFailed               = 13201
+
+	// 短链接模块
+	ErrCodeShortenDisabled      = 14001
+	ErrCodeShortenExpired       = 14002
+	ErrCodeDestinationRejected  = 14101
+	ErrCodeRedirectLoop         = 14102
+	ErrCodeRedirectChainTooLong = 14103
+
+	// 工作区模块
+	ErrCodeWorkspaceNotFound  = 15001
+	ErrCodeWorkspaceRequired  = 15002
+	ErrCodeWorkspaceForbidden = 15003
+	ErrCodeWorkspaceDefault   = 15004
+	ErrCodeQuotaLinks         = 15101
+	ErrCodeQuotaClicks        = 15102
 )
@@ -25,6 +25,7 @@ var errCodeMessages = map[int]string{
 	ErrCodeUserExists:           "用户已存在",
 	ErrCodeUserLoginFailed:      "用户登录失败",
 	ErrCodeUserPasswordError:    "用户名或密码错误",
+	ErrCodeUserTotpError:        "两步验证码错误",
 	ErrCodeUserPermissionDenied: "用户权限不足",
 	ErrCodeUserAuthFailed:       "身份验证失败",
 	ErrCodeUserPhoneExists:      "手机号已存在",
@@ -42,6 +43,19 @@ var errCodeMessages = map[int]string{
 	ErrCodePaymentAmountMismatch:      "支付金额不符",
 	ErrCodeRefundFailed:               "退款失败",
 
+	ErrCodeShortenDisabled:      "短链接已禁用",
+	ErrCodeShortenExpired:       "短链接已过期",
+	ErrCodeDestinationRejected:  "目标地址被禁止",
+	ErrCodeRedirectLoop:         "目标地址形成循环跳转",
+	ErrCodeRedirectChainTooLong: "跳转链路过长",
+
+	ErrCodeWorkspaceNotFound:  "工作区不存在",
+	ErrCodeWorkspaceRequired:  "未加入任何工作区",
+	ErrCodeWorkspaceForbidden: "不是工作区成员",
+	ErrCodeWorkspaceDefault:   "默认工作区不可删除",
+	ErrCodeQuotaLinks:         "短链接数量已达上限",
+	ErrCodeQuotaClicks:        "本月访问次数已达上限",
+
 	ErrCodeInvalidParam:     "参数错误",
 	ErrCodeBadRequest:       "请求失败",
 	ErrCodeUnauthorized:     "未授权",
@@ -6,10 +6,13 @@ import (
 
 // Handler is the handler struct
 type Handler struct {
-	AccountHandler *v1.AccountHandler
-	UserHandler    *v1.UserHandler
-	ShortenHandler *v1.ShortenHandler
-	HistoryHandler *v1.HistoryHandler
+	AccountHandler   *v1.AccountHandler
+	UserHandler      *v1.UserHandler
+	ShortenHandler   *v1.ShortenHandler
+	HistoryHandler   *v1.HistoryHandler
+	ApiKeyHandler    *v1.ApiKeyHandler
+	AuditHandler     *v1.AuditHandler
+	WorkspaceHandler *v1.WorkspaceHandler
 }
 
 // Handle expose the handler to outside
@@ -17,9 +20,12 @@ var Handle *Handler
 
 func init() {
 	Handle = &Handler{
-		AccountHandler: v1.NewAccountHandler(),
-		UserHandler:    v1.NewUserHandler(),
-		ShortenHandler: v1.NewShortenHandler(),
-		HistoryHandler: v1.NewHistoryHandler(),
+		AccountHandler:   v1.NewAccountHandler(),
+		UserHandler:      v1.NewUserHandler(),
+		ShortenHandler:   v1.NewShortenHandler(),
+		HistoryHandler:   v1.NewHistoryHandler(),
+		ApiKeyHandler:    v1.NewApiKeyHandler(),
+		AuditHandler:     v1.NewAuditHandler(),
+		WorkspaceHandler: v1.NewWorkspaceHandler(),
 	}
 }
@@ -1,33 +1,66 @@
 package v1
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/bytedance/sonic"
 	"github.com/gin-gonic/gin"
 
+	"go.xoder.cn/shortener/internal/dal/db/model"
 	"go.xoder.cn/shortener/internal/ecodes"
 	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+const (
+	oidcCookieName   = "shortener_oidc" // 保存授权上下文的 Cookie
+	oidcCookieMaxAge = 600              // 授权上下文有效期（秒）
 )
 
 // AccountHandler 账号处理器
 type AccountHandler struct {
 	handler
-	logic *logics.AccountLogic
+	logic   *logics.AccountLogic
+	session *logics.SessionLogic
+	oidc    *logics.OidcLogic
+	totp    *logics.TotpLogic
+	lockout *logics.LockoutLogic
 }
 
 // NewAccountHandler 创建账号处理器
 func NewAccountHandler() *AccountHandler {
 	t := &AccountHandler{}
+	t.audit = logics.NewAuditLogic()
+	t.logic = logics.NewAccountLogic()
+	t.session = logics.NewSessionLogic()
+	t.oidc = logics.NewOidcLogic()
+	t.totp = logics.NewTotpLogic()
+	t.lockout = logics.NewLockoutLogic()
 	return t
 }
 
-// Login 账号登录
+// Login 账号登录，auto 为 true 时记住登录
+// token_type 为 jwt 时签发访问令牌与刷新令牌，否则创建登录会话
+// 开启两步验证的用户返回挑战，需调用 LoginTotp 完成登录
 func (t *AccountHandler) Login(c *gin.Context) {
+	oidc := t.oidc.WithContext(c.Request.Context())
+	logic := t.logic.WithContext(c.Request.Context())
+	lockout := t.lockout.WithContext(c.Request.Context())
+
 	var reqJson struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
-		Auto     bool   `json:"auto,omitempty"`
+		Username  string   `json:"username" binding:"required"`
+		Password  string   `json:"password" binding:"required"`
+		Auto      bool     `json:"auto,omitempty"`
+		TokenType string   `json:"token_type,omitempty" binding:"omitempty,oneof=session jwt"`
+		Scopes    []string `json:"scopes,omitempty"` // 仅 jwt 可用，为空表示全部权限范围
 	}
 
 	if err := c.ShouldBindJSON(&reqJson); err != nil {
@@ -35,21 +68,484 @@ func (t *AccountHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := t.logic.Login(reqJson.Username, reqJson.Password)
+	// 仅允许单点登录
+	if !oidc.OidcPasswordLogin() {
+		c.JSON(http.StatusForbidden, t.JsonRespErr(ecodes.ErrCodeUserPermissionDenied))
+		return
+	}
+
+	var scopes []string
+	if reqJson.TokenType == "jwt" {
+		var errCode int
+		if errCode, scopes = logic.TokenScopes(reqJson.Scopes); errCode != ecodes.ErrCodeSuccess {
+			t.tokenError(c, errCode)
+			return
+		}
+	}
+
+	// 校验前先计为失败，锁定期间或超过允许次数时不校验密码
+	if wait := lockout.LockoutAttempt(reqJson.Username, c.ClientIP()); wait > 0 {
+		t.tooManyRequests(c, wait)
+		return
+	}
+
+	errCode, user := logic.Authenticate(reqJson.Username, reqJson.Password)
+	if errCode != ecodes.ErrCodeUserPasswordError {
+		lockout.LockoutRelease(reqJson.Username, c.ClientIP())
+	}
+	if errCode != ecodes.ErrCodeSuccess {
+		if errCode == ecodes.ErrCodeUserPasswordError {
+			t.Audit(c, types.AuditEvent{
+				Actor:      types.Principal{Username: reqJson.Username},
+				Action:     model.AuditLoginFailed,
+				TargetType: "user",
+				TargetID:   reqJson.Username,
+				After:      gin.H{"method": "password"},
+			})
+		}
+		t.tokenError(c, errCode)
+		return
+	}
+
+	// 开启两步验证时，失败记录在完成验证后才清除
+	if user.TotpEnabled {
+		errCode, data := logic.Challenge(user, reqJson.Auto, scopes, c.ClientIP(), c.Request.UserAgent())
+		if errCode != ecodes.ErrCodeSuccess {
+			t.tokenError(c, errCode)
+			return
+		}
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	t.loginResult(c, user, reqJson.Auto, scopes, "password")
+}
+
+// LoginTotp 使用登录返回的挑战与验证码（或恢复码）完成两步验证登录
+func (t *AccountHandler) LoginTotp(c *gin.Context) {
+	lockout := t.lockout.WithContext(c.Request.Context())
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqJson struct {
+		Challenge string `json:"challenge" binding:"required"`
+		Code      string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	// 挑战校验前无法确定用户名，先按 IP 计为失败
+	if wait := lockout.LockoutAttempt("", c.ClientIP()); wait > 0 {
+		t.tooManyRequests(c, wait)
+		return
+	}
+
+	errCode, user, session := logic.Verify(reqJson.Challenge, reqJson.Code)
+	if errCode != ecodes.ErrCodeUserTotpError {
+		lockout.LockoutRelease("", c.ClientIP())
+	}
+	if errCode != ecodes.ErrCodeSuccess {
+		if errCode == ecodes.ErrCodeUserTotpError {
+			lockout.LockoutFail(user.Username, c.ClientIP())
+			t.Audit(c, types.AuditEvent{
+				Actor:      types.Principal{Username: user.Username},
+				Action:     model.AuditLoginFailed,
+				TargetType: "user",
+				TargetID:   strconv.FormatInt(user.ID, 10),
+				After:      gin.H{"method": "totp"},
+			})
+		}
+		t.tokenError(c, errCode)
+		return
+	}
+
+	t.loginResult(c, user, session.Remember, strings.Fields(session.Scopes), "totp")
+}
+
+// Refresh 使用刷新令牌换取新的 JWT 访问令牌
+func (t *AccountHandler) Refresh(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqJson struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.Refresh(reqJson.RefreshToken)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.tokenError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// Logout 账号登出，注销当前登录会话或刷新令牌
+func (t *AccountHandler) Logout(c *gin.Context) {
+	session := t.session.WithContext(c.Request.Context())
+
+	if user := t.Principal(c); user.SessionID != 0 {
+		if session.SessionRevoke(user, user.SessionID) == ecodes.ErrCodeSuccess {
+			t.Audit(c, types.AuditEvent{Action: model.AuditLogout, TargetType: "session", TargetID: strconv.FormatInt(user.SessionID, 10)})
+		}
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// OidcLogin 跳转到身份提供方进行授权，redirect 为登录成功后跳转的前端地址
+func (t *AccountHandler) OidcLogin(c *gin.Context) {
+	oidc := t.oidc.WithContext(c.Request.Context())
+	if !oidc.OidcEnabled() {
+		c.JSON(http.StatusNotFound, t.JsonRespErr(ecodes.ErrCodeNotFound))
+		return
+	}
+
+	var reqQuery struct {
+		Redirect string `form:"redirect,omitempty"`
+		Auto     bool   `form:"auto,omitempty"`
+	}
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqQuery.Redirect != "" && !oidc.OidcRedirectAllowed(reqQuery.Redirect) {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, authURL, state := oidc.OidcStart(c.Request.Context(), reqQuery.Redirect, reqQuery.Auto)
+	if errCode != ecodes.ErrCodeSuccess {
+		c.JSON(http.StatusBadGateway, t.JsonRespErr(errCode))
+		return
+	}
+
+	value, err := sonic.Marshal(state)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeUserPasswordError))
+		c.JSON(http.StatusInternalServerError, t.JsonRespErr(ecodes.ErrCodeSystemInternalError))
+		return
+	}
+	t.setOidcCookie(c, base64.RawURLEncoding.EncodeToString(value), oidcCookieMaxAge)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OidcCallback 身份提供方授权回调，校验 state 后创建登录会话
+// 授权时指定了跳转地址则将令牌放在 URL 片段中跳转，否则直接返回令牌
+func (t *AccountHandler) OidcCallback(c *gin.Context) {
+	oidc := t.oidc.WithContext(c.Request.Context())
+	if !oidc.OidcEnabled() {
+		c.JSON(http.StatusNotFound, t.JsonRespErr(ecodes.ErrCodeNotFound))
+		return
+	}
+
+	var state logics.OidcState
+	cookie, err := c.Cookie(oidcCookieName)
+	if err == nil {
+		var value []byte
+		if value, err = base64.RawURLEncoding.DecodeString(cookie); err == nil {
+			err = sonic.Unmarshal(value, &state)
+		}
+	}
+	t.setOidcCookie(c, "", -1)
+
+	// state 须与发起授权时一致，防止跨站请求伪造
+	reqState := c.Query("state")
+	if err != nil || state.State == "" || subtle.ConstantTimeCompare([]byte(reqState), []byte(state.State)) != 1 {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	// Cookie 中的跳转地址可能已被篡改或配置已变更，令牌发出前重新检查
+	if state.Redirect != "" && !oidc.OidcRedirectAllowed(state.Redirect) {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode := ecodes.ErrCodeUserAuthFailed
+	var token string
+	var expiresAt time.Time
+	if code := c.Query("code"); code != "" && c.Query("error") == "" {
+		errCode, token, expiresAt = oidc.OidcCallback(c.Request.Context(), code, state, c.ClientIP(), c.Request.UserAgent())
+	}
+
+	if state.Redirect != "" {
+		fragment := url.Values{}
+		if errCode != ecodes.ErrCodeSuccess {
+			fragment.Set("errcode", strconv.Itoa(errCode))
+		} else {
+			fragment.Set("token", token)
+			fragment.Set("expires_at", utils.TimeToStr(expiresAt))
+		}
+		c.Redirect(http.StatusFound, state.Redirect+"#"+fragment.Encode())
+		return
+	}
+
+	if errCode != ecodes.ErrCodeSuccess {
+		t.oidcError(c, errCode)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"token":      token,
+		"expires_at": utils.TimeToStr(expiresAt),
 	})
 }
 
-// Logout 账号登出
-func (t *AccountHandler) Logout(c *gin.Context) {
-	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
-	_ = t.logic.Remove(token)
+// SessionList 获取当前用户的登录会话列表
+func (t *AccountHandler) SessionList(c *gin.Context) {
+	session := t.session.WithContext(c.Request.Context())
+
+	var reqQuery types.ReqQuery
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqQuery.Order == "" {
+		reqQuery.Order = "DESC"
+	}
+	if reqQuery.SortBy == "" {
+		reqQuery.SortBy = "last_seen_at"
+	}
+	if !slices.Contains([]string{"id", "expires_at", "last_seen_at", "created_at"}, reqQuery.SortBy) {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data, pageInfo := session.SessionAll(t.Principal(c), reqQuery)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.sessionError(c, errCode)
+		return
+	}
+
+	result := types.ResSuccess[[]types.ResSession]{
+		Data: data,
+		Meta: pageInfo,
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SessionDelete 注销当前用户的指定登录会话
+func (t *AccountHandler) SessionDelete(c *gin.Context) {
+	session := t.session.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode := session.SessionRevoke(t.Principal(c), reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.sessionError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditSessionRevoke, TargetType: "session", TargetID: strconv.FormatInt(reqUri.ID, 10)})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SessionDeleteOthers 注销当前用户除当前会话外的所有登录会话
+func (t *AccountHandler) SessionDeleteOthers(c *gin.Context) {
+	session := t.session.WithContext(c.Request.Context())
+	errCode := session.SessionRevokeOthers(t.Principal(c))
+	if errCode != ecodes.ErrCodeSuccess {
+		t.sessionError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditSessionRevoke, TargetType: "session", TargetID: "others"})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// TotpStatus 获取当前用户的两步验证状态
+func (t *AccountHandler) TotpStatus(c *gin.Context) {
+	totp := t.totp.WithContext(c.Request.Context())
+	errCode, data := totp.TotpStatus(t.Principal(c))
+	if errCode != ecodes.ErrCodeSuccess {
+		t.totpError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// TotpSetup 生成两步验证密钥，需调用 TotpEnable 确认后生效
+func (t *AccountHandler) TotpSetup(c *gin.Context) {
+	totp := t.totp.WithContext(c.Request.Context())
+	errCode, data := totp.TotpSetup(t.Principal(c))
+	if errCode != ecodes.ErrCodeSuccess {
+		t.totpError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// TotpEnable 使用验证码确认密钥并启用两步验证，返回恢复码
+func (t *AccountHandler) TotpEnable(c *gin.Context) {
+	totp := t.totp.WithContext(c.Request.Context())
+
+	var reqJson types.ReqTotpCode
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := totp.TotpEnable(t.Principal(c), reqJson.Code)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.totpError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditTotpEnable, TargetType: "user", TargetID: strconv.FormatInt(t.Principal(c).UserID, 10)})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// TotpDisable 使用验证码或恢复码关闭两步验证
+func (t *AccountHandler) TotpDisable(c *gin.Context) {
+	totp := t.totp.WithContext(c.Request.Context())
+
+	var reqJson types.ReqTotpCode
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode := totp.TotpDisable(t.Principal(c), reqJson.Code)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.totpError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditTotpDisable, TargetType: "user", TargetID: strconv.FormatInt(t.Principal(c).UserID, 10)})
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// TotpRecoveryCodes 使用验证码重新生成恢复码
+func (t *AccountHandler) TotpRecoveryCodes(c *gin.Context) {
+	totp := t.totp.WithContext(c.Request.Context())
+
+	var reqJson types.ReqTotpCode
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := totp.TotpRecoveryRenew(t.Principal(c), reqJson.Code)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.totpError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditTotpRecovery, TargetType: "user", TargetID: strconv.FormatInt(t.Principal(c).UserID, 10)})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// loginResult 按权限范围签发 JWT 或创建登录会话并响应，method 为审计日志中的登录方式
+func (t *AccountHandler) loginResult(c *gin.Context, user model.User, remember bool, scopes []string, method string) {
+	lockout := t.lockout.WithContext(c.Request.Context())
+	logic := t.logic.WithContext(c.Request.Context())
+	lockout.LockoutReset(user.Username)
+
+	event := types.AuditEvent{
+		Actor:      types.Principal{UserID: user.ID, Username: user.Username, Role: user.Role},
+		Action:     model.AuditLogin,
+		TargetType: "user",
+		TargetID:   strconv.FormatInt(user.ID, 10),
+		After:      gin.H{"method": method, "token_type": "session"},
+	}
+
+	if len(scopes) > 0 {
+		errCode, data := logic.LoginToken(user, remember, scopes, c.ClientIP(), c.Request.UserAgent())
+		if errCode != ecodes.ErrCodeSuccess {
+			t.tokenError(c, errCode)
+			return
+		}
+		event.After = gin.H{"method": method, "token_type": "jwt", "scopes": scopes}
+		t.Audit(c, event)
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	errCode, data := logic.Login(user, remember, c.ClientIP(), c.Request.UserAgent())
+	if errCode != ecodes.ErrCodeSuccess {
+		t.tokenError(c, errCode)
+		return
+	}
+	t.Audit(c, event)
+	c.JSON(http.StatusOK, data)
+}
+
+// tokenError 登录相关错误响应
+func (t *AccountHandler) tokenError(c *gin.Context, errCode int) {
+	errInfo := t.JsonRespErr(errCode)
+	if errCode == ecodes.ErrCodeUnauthorized || errCode == ecodes.ErrCodeUserTotpError {
+		c.JSON(http.StatusUnauthorized, errInfo)
+	} else if errCode == ecodes.ErrCodeBadRequest || errCode == ecodes.ErrCodeInvalidParam || errCode == ecodes.ErrCodeUserPasswordError {
+		c.JSON(http.StatusBadRequest, errInfo)
+	} else {
+		c.JSON(http.StatusInternalServerError, errInfo)
+	}
+}
+
+// tooManyRequests 登录失败次数过多，wait 为剩余的锁定时长
+func (t *AccountHandler) tooManyRequests(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+	c.JSON(http.StatusTooManyRequests, t.JsonRespErr(ecodes.ErrCodeTooManyRequests))
+}
+
+// oidcError OIDC 登录相关错误响应
+func (t *AccountHandler) oidcError(c *gin.Context, errCode int) {
+	errInfo := t.JsonRespErr(errCode)
+	if errCode == ecodes.ErrCodeUserAuthFailed {
+		c.JSON(http.StatusUnauthorized, errInfo)
+	} else if errCode == ecodes.ErrCodeUserPermissionDenied || errCode == ecodes.ErrCodeUserNotFound {
+		c.JSON(http.StatusForbidden, errInfo)
+	} else if errCode == ecodes.ErrCodeUserExists {
+		c.JSON(http.StatusConflict, errInfo)
+	} else {
+		c.JSON(http.StatusInternalServerError, errInfo)
+	}
+}
+
+// setOidcCookie 保存授权上下文的 Cookie，仅回调地址可读取
+func (t *AccountHandler) setOidcCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookieName, value, maxAge, "/api/account/oidc", "", c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https", true)
+}
+
+// totpError 两步验证相关错误响应
+func (t *AccountHandler) totpError(c *gin.Context, errCode int) {
+	errInfo := t.JsonRespErr(errCode)
+	if errCode == ecodes.ErrCodeUserTotpError || errCode == ecodes.ErrCodeBadRequest {
+		c.JSON(http.StatusBadRequest, errInfo)
+	} else if errCode == ecodes.ErrCodeConflict {
+		c.JSON(http.StatusConflict, errInfo)
+	} else if errCode == ecodes.ErrCodeUserNotFound {
+		c.JSON(http.StatusNotFound, errInfo)
+	} else {
+		c.JSON(http.StatusInternalServerError, errInfo)
+	}
+}
+
+// sessionError 登录会话相关错误响应
+func (t *AccountHandler) sessionError(c *gin.Context, errCode int) {
+	errInfo := t.JsonRespErr(errCode)
+	if errCode == ecodes.ErrCodeNotFound {
+		c.JSON(http.StatusNotFound, errInfo)
+	} else {
+		c.JSON(http.StatusInternalServerError, errInfo)
+	}
+}
@@ -0,0 +1,593 @@
+package v1
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/pkgs/sso"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+const (
+	testClientID     = "shortener"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://short.test/api/account/oidc/callback"
+)
+
+// mockGrant 模拟身份提供方签发的授权码
+type mockGrant struct {
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+// mockIdP 本地模拟的 OIDC 身份提供方
+type mockIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu         sync.Mutex
+	claims     map[string]any // 下次授权时签发的声明
+	nonce      string         // 不为空时替换 ID Token 中的 nonce
+	grants     map[string]mockGrant
+	tokenCalls int
+	verified   int // PKCE 校验通过的次数
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &mockIdP{key: key, grants: make(map[string]mockGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (p *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/authorize",
+		"token_endpoint":                        p.URL + "/token",
+		"jwks_uri":                              p.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize 直接同意授权，并跳转回客户端的回调地址
+func (p *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != testClientID ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := rand.Text()
+	p.mu.Lock()
+	p.grants[code] = mockGrant{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+		claims:    p.claims,
+	}
+	p.mu.Unlock()
+
+	callback := query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, callback, http.StatusFound)
+}
+
+// token 校验授权码与 PKCE code_verifier 后签发 ID Token
+func (p *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tokenCalls++
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != testClientID || clientSecret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	grant, ok := p.grants[r.PostFormValue("code")]
+	delete(p.grants, r.PostFormValue("code"))
+	if r.PostFormValue("grant_type") != "authorization_code" || !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "pkce verification failed"})
+		return
+	}
+	p.verified++
+
+	nonce := grant.nonce
+	if p.nonce != "" {
+		nonce = p.nonce
+	}
+	claims := jwt.MapClaims{
+		"iss":   p.URL,
+		"aud":   testClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// oidcFlow 一次完整的授权流程，可在回调前修改 state 或授权上下文 Cookie
+type oidcFlow struct {
+	router  *gin.Engine
+	authURL string
+	cookie  *http.Cookie
+	code    string
+	state   string
+}
+
+// newOidcRouter 使用模拟身份提供方的配置创建路由
+func newOidcRouter(idp *mockIdP, cfg types.CfgOIDC) *gin.Engine {
+	cfg.Enabled = true
+	cfg.Issuer = idp.URL
+	cfg.ClientID = testClientID
+	cfg.ClientSecret = testClientSecret
+	cfg.RedirectURL = testRedirectURL
+	cfg.UsernameClaim = "preferred_username"
+	cfg.Scopes = []string{"profile", "email"}
+
+	shared.GlobalOIDC = &cfg
+	shared.GlobalSSO = sso.New(sso.Options{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	})
+
+	handler := NewAccountHandler()
+	router := gin.New()
+	router.GET("/api/account/oidc/login", handler.OidcLogin)
+	router.GET("/api/account/oidc/callback", handler.OidcCallback)
+	return router
+}
+
+// startOidcFlow 发起登录并由模拟身份提供方同意授权
+func startOidcFlow(t *testing.T, router *gin.Engine, idp *mockIdP, claims map[string]any) *oidcFlow {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/account/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: got status %d, body %s", w.Code, w.Body.String())
+	}
+
+	flow := &oidcFlow{router: router, authURL: w.Header().Get("Location")}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == oidcCookieName {
+			flow.cookie = cookie
+		}
+	}
+	if flow.cookie == nil {
+		t.Fatal("login: oidc cookie not set")
+	}
+
+	idp.mu.Lock()
+	idp.claims = claims
+	idp.mu.Unlock()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(flow.authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: got status %d", resp.StatusCode)
+	}
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow.code = callback.Query().Get("code")
+	flow.state = callback.Query().Get("state")
+	return flow
+}
+
+// callback 携带授权码与授权上下文 Cookie 请求回调地址
+func (f *oidcFlow) callback() *httptest.ResponseRecorder {
+	query := url.Values{"code": {f.code}, "state": {f.state}}
+	req := httptest.NewRequest(http.MethodGet, "/api/account/oidc/callback?"+query.Encode(), nil)
+	if f.cookie != nil {
+		req.AddCookie(f.cookie)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+// stateCookie 解析授权上下文 Cookie
+func (f *oidcFlow) stateCookie(t *testing.T) logics.OidcState {
+	t.Helper()
+
+	var state logics.OidcState
+	value, err := base64.RawURLEncoding.DecodeString(f.cookie.Value)
+	if err == nil {
+		err = sonic.Unmarshal(value, &state)
+	}
+	if err != nil {
+		t.Fatalf("decode oidc cookie: %v", err)
+	}
+	return state
+}
+
+// setStateCookie 替换授权上下文 Cookie
+func (f *oidcFlow) setStateCookie(t *testing.T, state logics.OidcState) {
+	t.Helper()
+
+	value, err := sonic.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.cookie = &http.Cookie{Name: oidcCookieName, Value: base64.RawURLEncoding.EncodeToString(value)}
+}
+
+func findUser(t *testing.T, username string) (model.User, bool) {
+	t.Helper()
+
+	var user model.User
+	err := shared.GlobalDB.Where("username = ?", username).Limit(1).Find(&user).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, user.ID != 0
+}
+
+func TestOidcLoginPKCE(t *testing.T) {
+	idp := newMockIdP(t)
+	router := newOidcRouter(idp, types.CfgOIDC{AutoCreate: true})
+
+	flow := startOidcFlow(t, router, idp, map[string]any{"sub": "sub-pkce", "preferred_username": "pkce"})
+	state := flow.stateCookie(t)
+
+	authURL, err := url.Parse(flow.authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	sum := sha256.Sum256([]byte(state.Verifier))
+	if query.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Error("code_challenge is not the S256 of the stored verifier")
+	}
+	if strings.Contains(flow.authURL, state.Verifier) {
+		t.Error("code_verifier leaked in the authorization url")
+	}
+	if query.Get("state") != state.State || query.Get("nonce") != state.Nonce {
+		t.Error("state or nonce in the authorization url differs from the cookie")
+	}
+	if scope := query.Get("scope"); !strings.Contains(scope, "openid") {
+		t.Errorf("scope %q does not include openid", scope)
+	}
+
+	w := flow.callback()
+	if w.Code != http.StatusOK {
+		t.Fatalf("callback: got status %d, body %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Token == "" {
+		t.Fatalf("callback: no token in %s", w.Body.String())
+	}
+	if idp.verified != 1 {
+		t.Errorf("identity provider verified PKCE %d times, want 1", idp.verified)
+	}
+
+	user, ok := findUser(t, "pkce")
+	if !ok || user.OidcSub == nil || *user.OidcSub != "sub-pkce" {
+		t.Fatalf("user not created with oidc sub: %+v", user)
+	}
+}
+
+func TestOidcLoginWrongVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	router := newOidcRouter(idp, types.CfgOIDC{AutoCreate: true})
+
+	flow := startOidcFlow(t, router, idp, map[string]any{"sub": "sub-verifier", "preferred_username": "verifier"})
+	state := flow.stateCookie(t)
+	state.Verifier = strings.Repeat("x", len(state.Verifier))
+	flow.setStateCookie(t, state)
+
+	w := flow.callback()
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("callback: got status %d, want 401", w.Code)
+	}
+	// 客户端认证方式自动探测失败时 oauth2 会重试一次
+	if idp.tokenCalls == 0 || idp.verified != 0 {
+		t.Errorf("token calls %d, verified %d, want exchange attempted and rejected", idp.tokenCalls, idp.verified)
+	}
+	if _, ok := findUser(t, "verifier"); ok {
+		t.Error("user created with a wrong code_verifier")
+	}
+}
+
+func TestOidcCallbackBadState(t *testing.T) {
+	idp := newMockIdP(t)
+	router := newOidcRouter(idp, types.CfgOIDC{AutoCreate: true})
+
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, flow *oidcFlow)
+	}{
+		{"state mismatch", func(t *testing.T, flow *oidcFlow) {
+			flow.state = "forged"
+		}},
+		{"empty state", func(t *testing.T, flow *oidcFlow) {
+			flow.state = ""
+		}},
+		{"missing cookie", func(t *testing.T, flow *oidcFlow) {
+			flow.cookie = nil
+		}},
+		{"malformed cookie", func(t *testing.T, flow *oidcFlow) {
+			flow.cookie = &http.Cookie{Name: oidcCookieName, Value: "%%%"}
+		}},
+		{"cookie from another flow", func(t *testing.T, flow *oidcFlow) {
+			state := flow.stateCookie(t)
+			state.State = "other"
+			flow.setStateCookie(t, state)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := startOidcFlow(t, router, idp, map[string]any{"sub": "sub-state", "preferred_username": "state"})
+			tt.tamper(t, flow)
+
+			calls := idp.tokenCalls
+			w := flow.callback()
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("callback: got status %d, want 400", w.Code)
+			}
+			if idp.tokenCalls != calls {
+				t.Error("authorization code exchanged despite a bad state")
+			}
+		})
+	}
+
+	if _, ok := findUser(t, "state"); ok {
+		t.Error("user created despite a bad state")
+	}
+}
+
+func TestOidcLoginNonceMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.nonce = "replayed"
+	router := newOidcRouter(idp, types.CfgOIDC{AutoCreate: true})
+
+	flow := startOidcFlow(t, router, idp, map[string]any{"sub": "sub-nonce", "preferred_username": "nonce"})
+	w := flow.callback()
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("callback: got status %d, want 401", w.Code)
+	}
+	if _, ok := findUser(t, "nonce"); ok {
+		t.Error("user created despite a nonce mismatch")
+	}
+}
+
+func TestOidcLoginRoleMapping(t *testing.T) {
+	idp := newMockIdP(t)
+	router := newOidcRouter(idp, types.CfgOIDC{
+		AutoCreate:    true,
+		RoleClaim:     "groups",
+		AdminValues:   []string{"shortener-admins"},
+		AllowedValues: []string{"staff"},
+	})
+
+	tests := []struct {
+		name     string
+		username string
+		groups   any
+		status   int
+		role     string
+	}{
+		{"admin group", "mapped-admin", []any{"staff", "shortener-admins"}, http.StatusOK, access.RoleAdmin},
+		{"admin single value", "mapped-admin2", "shortener-admins", http.StatusOK, access.RoleAdmin},
+		{"allowed group", "mapped-user", []any{"staff"}, http.StatusOK, access.RoleUser},
+		{"not allowed", "mapped-guest", []any{"guests"}, http.StatusForbidden, ""},
+		{"no role claim", "mapped-none", nil, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]any{"sub": "sub-" + tt.username, "preferred_username": tt.username}
+			if tt.groups != nil {
+				claims["groups"] = tt.groups
+			}
+
+			w := startOidcFlow(t, router, idp, claims).callback()
+			if w.Code != tt.status {
+				t.Fatalf("callback: got status %d, want %d, body %s", w.Code, tt.status, w.Body.String())
+			}
+
+			user, ok := findUser(t, tt.username)
+			if tt.role == "" {
+				if ok {
+					t.Errorf("user created with role %q, want none", user.Role)
+				}
+				return
+			}
+			if !ok || user.Role != tt.role {
+				t.Errorf("got user %v role %q, want %q", ok, user.Role, tt.role)
+			}
+		})
+	}
+}
+
+func TestOidcLoginRoleSync(t *testing.T) {
+	idp := newMockIdP(t)
+	router := newOidcRouter(idp, types.CfgOIDC{
+		AutoCreate:  true,
+		RoleClaim:   "groups",
+		AdminValues: []string{"shortener-admins"},
+	})
+
+	// 另一位管理员，避免同步时因最后一个管理员而保留角色
+	other := model.User{Username: "sync-other-admin", Password: "x", Role: access.RoleAdmin, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := shared.GlobalDB.Where("username = ?", other.Username).FirstOrCreate(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]any{"sub": "sub-sync", "preferred_username": "sync", "groups": []any{"shortener-admins"}}
+	if w := startOidcFlow(t, router, idp, claims).callback(); w.Code != http.StatusOK {
+		t.Fatalf("first login: got status %d", w.Code)
+	}
+	if user, _ := findUser(t, "sync"); user.Role != access.RoleAdmin {
+		t.Fatalf("first login: got role %q, want admin", user.Role)
+	}
+
+	claims["groups"] = []any{"staff"}
+	if w := startOidcFlow(t, router, idp, claims).callback(); w.Code != http.StatusOK {
+		t.Fatalf("second login: got status %d", w.Code)
+	}
+	if user, _ := findUser(t, "sync"); user.Role != access.RoleUser {
+		t.Errorf("second login: got role %q, want user", user.Role)
+	}
+}
+
+func TestOidcCallbackErrorRedirect(t *testing.T) {
+	idp := newMockIdP(t)
+	router := newOidcRouter(idp, types.CfgOIDC{AutoCreate: true, AllowedRedirects: []string{"https://app.test/"}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/account/oidc/login?redirect=https://evil.test/", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("login with disallowed redirect: got status %d, want 400", w.Code)
+	}
+
+	idp.nonce = "replayed"
+	flow := startOidcFlow(t, router, idp, map[string]any{"sub": "sub-redirect", "preferred_username": "redirect"})
+	state := flow.stateCookie(t)
+	state.Redirect = "https://app.test/done"
+	flow.setStateCookie(t, state)
+
+	w = flow.callback()
+	location := w.Header().Get("Location")
+	if w.Code != http.StatusFound || !strings.HasPrefix(location, "https://app.test/done#") {
+		t.Fatalf("callback: got status %d, location %q", w.Code, location)
+	}
+	fragment, _ := url.ParseQuery(location[strings.Index(location, "#")+1:])
+	if fragment.Get("token") != "" || fragment.Get("errcode") != "10302" {
+		t.Errorf("callback fragment %q, want errcode %d without token", fragment.Encode(), ecodes.ErrCodeUserAuthFailed)
+	}
+
+	// 篡改 Cookie 中的跳转地址
+	flow = startOidcFlow(t, router, idp, map[string]any{"sub": "sub-redirect", "preferred_username": "redirect"})
+	state = flow.stateCookie(t)
+	state.Redirect = "https://app.test.evil.test/"
+	flow.setStateCookie(t, state)
+
+	w = flow.callback()
+	if w.Code != http.StatusBadRequest || w.Header().Get("Location") != "" {
+		t.Errorf("callback with tampered redirect: got status %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestOidcRedirectAllowed(t *testing.T) {
+	idp := newMockIdP(t)
+	newOidcRouter(idp, types.CfgOIDC{AllowedRedirects: []string{"https://app.test", "https://admin.test:8443/console/"}})
+	oidc := logics.NewOidcLogic()
+
+	cases := map[string]bool{
+		"https://app.test":                         true,
+		"https://app.test/":                        true,
+		"https://APP.test/done?x=1":                true,
+		"https://admin.test:8443/console":          true,
+		"https://admin.test:8443/console/users":    true,
+		"https://admin.test:8443/consoles":         false,
+		"https://admin.test:8443/console/../admin": false,
+		"https://admin.test/console/":              false,
+		"https://app.test.evil.test/":              false,
+		"https://app.test@evil.test/":              false,
+		"http://app.test/":                         false,
+		"https://app.test/done#x":                  false,
+		"//app.test/":                              false,
+		"javascript:alert(1)":                      false,
+	}
+	for redirect, want := range cases {
+		if got := oidc.OidcRedirectAllowed(redirect); got != want {
+			t.Errorf("%s: got %v, want %v", redirect, got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package v1
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// ApiKeyHandler API Key 处理器
+type ApiKeyHandler struct {
+	handler
+	logic *logics.ApiKeyLogic
+}
+
+// NewApiKeyHandler 创建 API Key 处理器
+func NewApiKeyHandler() *ApiKeyHandler {
+	t := &ApiKeyHandler{}
+	t.audit = logics.NewAuditLogic()
+	t.logic = logics.NewApiKeyLogic()
+	return t
+}
+
+// ApiKeyAdd 创建 API Key，密钥仅在响应中返回一次
+func (t *ApiKeyHandler) ApiKeyAdd(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqJson struct {
+		Name      string   `json:"name" binding:"required,max=64"`
+		Scopes    []string `json:"scopes" binding:"required,min=1"`
+		ExpiresAt string   `json:"expires_at,omitempty"`
+		UserID    int64    `json:"user_id,omitempty" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	expiresAt, ok := parseExpiresAt(reqJson.ExpiresAt)
+	if !ok {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.ApiKeyAdd(t.Principal(c), reqJson.UserID, reqJson.Name, reqJson.Scopes, expiresAt)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.apiKeyError(c, errCode)
+		return
+	}
+
+	// 审计日志中不记录密钥
+	after := data
+	after.Key = ""
+	after.Secret = ""
+	t.Audit(c, types.AuditEvent{Action: model.AuditApiKeyCreate, TargetType: "api_key", TargetID: strconv.FormatInt(data.ID, 10), After: after})
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// ApiKeyUpdate 更新 API Key
+func (t *ApiKeyHandler) ApiKeyUpdate(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	var reqJson struct {
+		Name      string   `json:"name,omitempty" binding:"omitempty,max=64"`
+		Scopes    []string `json:"scopes,omitempty" binding:"omitempty,min=1"`
+		ExpiresAt *string  `json:"expires_at,omitempty"` // 未提供时不修改，空字符串表示取消过期时间
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	var expiresAt *time.Time
+	clearExpires := false
+	if reqJson.ExpiresAt != nil {
+		if *reqJson.ExpiresAt == "" {
+			clearExpires = true
+		} else {
+			var ok bool
+			if expiresAt, ok = parseExpiresAt(*reqJson.ExpiresAt); !ok {
+				c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+				return
+			}
+		}
+	}
+
+	_, before := logic.ApiKeyFind(t.Principal(c), reqUri.ID)
+	errCode, data := logic.ApiKeyUpdate(t.Principal(c), reqUri.ID, reqJson.Name, reqJson.Scopes, expiresAt, clearExpires)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.apiKeyError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditApiKeyUpdate, TargetType: "api_key", TargetID: strconv.FormatInt(reqUri.ID, 10), Before: before, After: data})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// ApiKeyRotate 轮换 API Key，新密钥仅在响应中返回一次
+func (t *ApiKeyHandler) ApiKeyRotate(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	_, before := logic.ApiKeyFind(t.Principal(c), reqUri.ID)
+	errCode, data := logic.ApiKeyRotate(t.Principal(c), reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.apiKeyError(c, errCode)
+		return
+	}
+
+	after := data
+	after.Key = ""
+	after.Secret = ""
+	t.Audit(c, types.AuditEvent{Action: model.AuditApiKeyRotate, TargetType: "api_key", TargetID: strconv.FormatInt(reqUri.ID, 10), Before: before, After: after})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// ApiKeyDelete 删除 API Key
+func (t *ApiKeyHandler) ApiKeyDelete(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	_, before := logic.ApiKeyFind(t.Principal(c), reqUri.ID)
+	errCode := logic.ApiKeyDelete(t.Principal(c), reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.apiKeyError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditApiKeyDelete, TargetType: "api_key", TargetID: strconv.FormatInt(reqUri.ID, 10), Before: before})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ApiKeyFind 获取 API Key
+func (t *ApiKeyHandler) ApiKeyFind(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.ApiKeyFind(t.Principal(c), reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.apiKeyError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// ApiKeyList 获取 API Key 列表
+func (t *ApiKeyHandler) ApiKeyList(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqQuery types.ReqQueryApiKey
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqQuery.Order == "" {
+		reqQuery.Order = "DESC"
+	}
+	if reqQuery.SortBy == "" {
+		reqQuery.SortBy = "created_at"
+	}
+	if !slices.Contains([]string{"id", "name", "expires_at", "last_used_at", "created_at", "updated_at"}, reqQuery.SortBy) {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data, pageInfo := logic.ApiKeyAll(t.Principal(c), reqQuery)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.apiKeyError(c, errCode)
+		return
+	}
+
+	result := types.ResSuccess[[]types.ResApiKey]{
+		Data: data,
+		Meta: pageInfo,
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// apiKeyError API Key 相关错误响应
+func (t *ApiKeyHandler) apiKeyError(c *gin.Context, errCode int) {
+	errInfo := t.JsonRespErr(errCode)
+	if errCode == ecodes.ErrCodeNotFound || errCode == ecodes.ErrCodeUserNotFound {
+		c.JSON(http.StatusNotFound, errInfo)
+	} else if errCode == ecodes.ErrCodeInvalidParam {
+		c.JSON(http.StatusBadRequest, errInfo)
+	} else if errCode == ecodes.ErrCodeUserPermissionDenied || errCode == ecodes.ErrCodeWorkspaceForbidden {
+		c.JSON(http.StatusForbidden, errInfo)
+	} else {
+		c.JSON(http.StatusInternalServerError, errInfo)
+	}
+}
+
+// parseExpiresAt 解析过期时间（本地时间 2006-01-02 15:04:05），为空表示不设置
+func parseExpiresAt(value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	expiresAt, err := time.ParseInLocation(time.DateTime, value, time.Local)
+	if err != nil || !expiresAt.After(time.Now()) {
+		return nil, false
+	}
+	return &expiresAt, true
+}
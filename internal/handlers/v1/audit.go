@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct {
+	handler
+	logic *logics.AuditLogic
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler() *AuditHandler {
+	t := &AuditHandler{}
+	t.logic = logics.NewAuditLogic()
+	return t
+}
+
+// AuditList 获取审计日志列表，仅管理员可用
+func (t *AuditHandler) AuditList(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqQuery types.ReqQueryAudit
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqQuery.Order == "" {
+		reqQuery.Order = "DESC"
+	}
+	if reqQuery.SortBy == "" {
+		reqQuery.SortBy = "id"
+	}
+	if !slices.Contains([]string{"id", "created_at"}, reqQuery.SortBy) {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	start, ok := parseAuditTime(reqQuery.Start)
+	if !ok {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	end, ok := parseAuditTime(reqQuery.End)
+	if !ok {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data, pageInfo := logic.AuditAll(reqQuery, start, end)
+	if errCode != ecodes.ErrCodeSuccess {
+		c.JSON(http.StatusInternalServerError, t.JsonRespErr(errCode))
+		return
+	}
+
+	result := types.ResSuccess[[]types.ResAudit]{
+		Data: data,
+		Meta: pageInfo,
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseAuditTime 解析查询时间（本地时间 2006-01-02 15:04:05），为空时返回零值
+func parseAuditTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	result, err := time.ParseInLocation(time.DateTime, value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return result, true
+}
@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/templates"
+)
+
+// 短码不存在时的处理方式
+const (
+	fallbackAuto     = "auto"     // 按 Accept 返回 html 或 json
+	fallbackJSON     = "json"     // 返回 json
+	fallbackHTML     = "html"     // 返回错误页
+	fallbackRedirect = "redirect" // 跳转到兜底地址
+)
+
+// errorPages 各错误码对应的错误页
+var errorPages = map[int]struct {
+	kind    string
+	title   string
+	message string
+}{
+	ecodes.ErrCodeNotFound:        {"not_found", "链接不存在", "您访问的短链接不存在"},
+	ecodes.ErrCodeShortenDisabled: {"disabled", "链接已禁用", "您访问的短链接已被禁用"},
+	ecodes.ErrCodeShortenExpired:  {"expired", "链接已过期", "您访问的短链接已过期"},
+	ecodes.ErrCodeQuotaClicks:     {"quota", "链接暂不可用", "该短链接本月访问次数已达上限"},
+}
+
+// ShortenRoot 访问根路径
+func (t *ShortenHandler) ShortenRoot(c *gin.Context) {
+	if t.fallback.RootURL != "" {
+		c.Redirect(http.StatusFound, t.fallback.RootURL)
+		return
+	}
+	t.notFound(c, "")
+}
+
+// ShortenNoRoute 未匹配的路由
+func (t *ShortenHandler) ShortenNoRoute(c *gin.Context) {
+	// 接口路径始终返回 json
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		c.JSON(http.StatusNotFound, t.JsonRespErr(ecodes.ErrCodeNotFound))
+		return
+	}
+	t.notFound(c, "")
+}
+
+// notFound 短码不存在
+func (t *ShortenHandler) notFound(c *gin.Context, code string) {
+	if t.fallback.NotFound == fallbackRedirect && t.fallback.NotFoundURL != "" {
+		c.Redirect(http.StatusFound, t.notFoundURL(code))
+		return
+	}
+	t.errorPage(c, http.StatusNotFound, ecodes.ErrCodeNotFound, code)
+}
+
+// notFoundURL 兜底跳转地址，附加访问的短码
+func (t *ShortenHandler) notFoundURL(code string) string {
+	u, err := url.Parse(t.fallback.NotFoundURL)
+	if err != nil || code == "" {
+		return t.fallback.NotFoundURL
+	}
+	query := u.Query()
+	query.Set(t.fallback.CodeParam, code)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// errorPage 按配置与 Accept 返回错误页或 json
+func (t *ShortenHandler) errorPage(c *gin.Context, status int, errCode int, code string) {
+	if !t.wantsHTML(c) {
+		c.JSON(status, t.JsonRespErr(errCode))
+		return
+	}
+
+	page := errorPages[errCode]
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	_ = templates.RenderError(c.Writer, page.kind, templates.ErrorPage{
+		StatusCode: status,
+		Title:      page.title,
+		Message:    page.message,
+		Code:       code,
+		SiteName:   t.fallback.SiteName,
+		HomeURL:    t.fallback.RootURL,
+	})
+}
+
+// wantsHTML 是否返回 html 错误页
+func (t *ShortenHandler) wantsHTML(c *gin.Context) bool {
+	switch t.fallback.NotFound {
+	case fallbackJSON:
+		return false
+	case fallbackHTML:
+		return true
+	default:
+		// 未声明偏好（如 */*）时保持 json，兼容已有的接口调用方
+		return c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+	}
+}
@@ -1,12 +1,18 @@
 package v1
 
 import (
+	"github.com/gin-gonic/gin"
+
 	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/middlewares"
 	"go.xoder.cn/shortener/internal/types"
 	"go.xoder.cn/shortener/internal/utils"
 )
 
-type handler struct{}
+type handler struct {
+	audit *logics.AuditLogic
+}
 
 // JsonRespErr 返回错误响应
 func (t *handler) JsonRespErr(errCode int) types.ResErr {
@@ -20,3 +26,16 @@ func (t *handler) JsonRespErr(errCode int) types.ResErr {
 func (t *handler) IsURL(url string) bool {
 	return utils.IsURL(url)
 }
+
+// Audit 记录审计事件，操作者为空时使用当前请求的身份
+func (t *handler) Audit(c *gin.Context, event types.AuditEvent) {
+	if event.Actor.UserID == 0 && event.Actor.Username == "" {
+		event.Actor = t.Principal(c)
+	}
+	t.audit.WithContext(c.Request.Context()).AuditRecord(event, c.ClientIP(), c.Request.UserAgent())
+}
+
+// Principal 获取当前请求的身份
+func (t *handler) Principal(c *gin.Context) types.Principal {
+	return middlewares.CurrentPrincipal(c)
+}
@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	_ "modernc.org/sqlite"
+
+	"go.xoder.cn/shortener/internal/cache"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// TestMain 使用内存 sqlite 数据库并关闭缓存
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite", DSN: "file:v1test?mode=memory&cache=shared"}, &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(&model.Url{}, &model.History{}, &model.User{}, &model.Session{}, &model.AuditLog{}, &model.Workspace{}, &model.WorkspaceMember{}, &model.WorkspaceUsage{}); err != nil {
+		panic(err)
+	}
+
+	shared.GlobalDB = db
+	shared.GlobalCache = cache.NewCacheManager(false, nil, "")
+	shared.GlobalSession = &types.CfgSession{TTL: 3600, RememberTTL: 86400}
+
+	os.Exit(m.Run())
+}
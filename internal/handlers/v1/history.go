@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"go.xoder.cn/shortener/internal/dal/db/model"
 	"go.xoder.cn/shortener/internal/ecodes"
 	"go.xoder.cn/shortener/internal/logics"
 	"go.xoder.cn/shortener/internal/types"
@@ -20,12 +21,15 @@ type HistoryHandler struct {
 // NewHistoryHandler 创建短链接处理器
 func NewHistoryHandler() *HistoryHandler {
 	t := &HistoryHandler{}
+	t.audit = logics.NewAuditLogic()
 	t.logic = logics.NewHistoryLogic()
 	return t
 }
 
 // HistoryDeleteAll 删除所有历史记录
 func (t *HistoryHandler) HistoryDeleteAll(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqQuery struct {
 		IDs string `form:"ids" binding:"required"`
 	}
@@ -36,18 +40,22 @@ func (t *HistoryHandler) HistoryDeleteAll(c *gin.Context) {
 
 	// log.Printf("reqQuery.IDs: %s", reqQuery.IDs)
 	ids := strings.Split(reqQuery.IDs, ",")
-	errCode := t.logic.HistoryDeleteAll(ids)
+	errCode := logic.HistoryDeleteAll(t.Principal(c), ids)
 	if errCode != ecodes.ErrCodeSuccess {
 		errInfo := t.JsonRespErr(errCode)
 		c.JSON(http.StatusInternalServerError, errInfo)
 		return
 	}
 
+	t.Audit(c, types.AuditEvent{Action: model.AuditHistoryDeleteBatch, TargetType: "history", TargetID: reqQuery.IDs})
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
 // HistoryList 获取历史记录列表
 func (t *HistoryHandler) HistoryList(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqQuery types.ReqQueryHistory
 	if err := c.ShouldBindQuery(&reqQuery); err != nil {
 		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
@@ -60,7 +68,7 @@ func (t *HistoryHandler) HistoryList(c *gin.Context) {
 		reqQuery.SortBy = "created_at"
 	}
 
-	errCode, data, pageInfo := t.logic.HistoryAll(reqQuery)
+	errCode, data, pageInfo := logic.HistoryAll(t.Principal(c), reqQuery)
 	if errCode != ecodes.ErrCodeSuccess {
 		errInfo := t.JsonRespErr(errCode)
 		if errCode == ecodes.ErrCodeDatabaseError {
@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/middlewares"
+	"go.xoder.cn/shortener/internal/shared"
+)
+
+// selfSignedCert 生成 127.0.0.1 的自签名证书及信任该证书的证书池
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func noRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+func TestShortenRedirectHTTP3(t *testing.T) {
+	link := model.Url{
+		ShortCode:   "h3test",
+		OriginalURL: "https://example.com/h3",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := shared.GlobalDB.Where("short_code = ?", link.ShortCode).FirstOrCreate(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cert, pool := selfSignedCert(t)
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(udpConn.LocalAddr().String())
+
+	// 与 routers.NewRouter 及 main 中的 HTTP/3 服务配置一致
+	router := gin.New()
+	router.Use(middlewares.AltSvc(middlewares.AltSvcHeader(port, 86400)))
+	router.GET("/:code", NewShortenHandler().ShortenRedirect)
+
+	quicServer := &http3.Server{
+		Handler:   router.Handler(),
+		TLSConfig: http3.ConfigureTLSConfig(tlsConfig),
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- quicServer.Serve(udpConn) }()
+	t.Cleanup(func() {
+		_ = quicServer.Close()
+		if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("http/3 server: %v", err)
+		}
+	})
+
+	tcpServer := httptest.NewUnstartedServer(router.Handler())
+	tcpServer.TLS = tlsConfig
+	tcpServer.StartTLS()
+	t.Cleanup(tcpServer.Close)
+
+	// HTTPS 响应通告 HTTP/3 端口
+	tcpClient := &http.Client{
+		Transport:     &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		CheckRedirect: noRedirect,
+	}
+	resp, err := tcpClient.Get(tcpServer.URL + "/" + link.ShortCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != link.OriginalURL {
+		t.Fatalf("https: got status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	altSvc := resp.Header.Get("Alt-Svc")
+	match := regexp.MustCompile(`^h3=":(\d+)"; ma=86400$`).FindStringSubmatch(altSvc)
+	if match == nil || match[1] != port {
+		t.Fatalf("https: got Alt-Svc %q, want h3 on port %s", altSvc, port)
+	}
+
+	// 按 Alt-Svc 通告的端口通过 HTTP/3 访问
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	t.Cleanup(func() { _ = transport.Close() })
+	h3Client := &http.Client{Transport: transport, CheckRedirect: noRedirect, Timeout: 5 * time.Second}
+
+	h3URL := "https://127.0.0.1:" + match[1] + "/"
+	resp, err = h3Client.Get(h3URL + link.ShortCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.ProtoMajor != 3 {
+		t.Errorf("http/3: got protocol %s", resp.Proto)
+	}
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != link.OriginalURL {
+		t.Errorf("http/3: got status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if got := resp.Header.Get("Alt-Svc"); got != "" {
+		t.Errorf("http/3: got Alt-Svc %q, want none", got)
+	}
+
+	resp, err = h3Client.Get(h3URL + "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("http/3 unknown code: got status %d, want 404", resp.StatusCode)
+	}
+}
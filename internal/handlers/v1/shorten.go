@@ -1,14 +1,20 @@
 package v1
 
 import (
+	"html/template"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 
+	"go.xoder.cn/shortener/internal/dal/db/model"
 	"go.xoder.cn/shortener/internal/ecodes"
 	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/pkgs/metrics"
 	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/templates"
 	"go.xoder.cn/shortener/internal/types"
 	"go.xoder.cn/shortener/internal/utils"
 )
@@ -16,57 +22,135 @@ import (
 // ShortenHandler 短链接处理器
 type ShortenHandler struct {
 	handler
-	logic *logics.ShortenLogic
+	logic         *logics.ShortenLogic
+	history       *logics.HistoryLogic
+	bridgeTimeout int
+	fallback      types.CfgFallback
 }
 
 // NewShortenHandler 创建短链接处理器
 func NewShortenHandler() *ShortenHandler {
 	t := &ShortenHandler{}
+	t.audit = logics.NewAuditLogic()
 	t.logic = logics.NewShortenLogic()
+	t.history = logics.NewHistoryLogic()
+	t.bridgeTimeout = 1500
+	if shared.GlobalDeepLink != nil {
+		t.bridgeTimeout = shared.GlobalDeepLink.Timeout
+	}
+	_ = viper.UnmarshalKey("fallback", &t.fallback)
+	if t.fallback.CodeParam == "" {
+		t.fallback.CodeParam = "code"
+	}
 	return t
 }
 
 // ShortenRedirect 短链接跳转
 func (t *ShortenHandler) ShortenRedirect(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+	history := t.history.WithContext(c.Request.Context())
+
 	var reqUri types.ReqCode
 	if err := c.ShouldBindUri(&reqUri); err != nil {
 		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
 		return
 	}
 
-	errCode, data := t.logic.ShortenFind(reqUri.Code)
+	errCode, data := logic.ShortenResolve(c.Request.Host, reqUri.Code)
 	if errCode != ecodes.ErrCodeSuccess {
-		errInfo := t.JsonRespErr(errCode)
 		if errCode == ecodes.ErrCodeNotFound {
-			c.JSON(http.StatusNotFound, errInfo)
+			metrics.Redirects.WithLabelValues(metrics.RedirectMiss).Inc()
+			t.notFound(c, reqUri.Code)
+		} else if errCode == ecodes.ErrCodeQuotaClicks {
+			metrics.Redirects.WithLabelValues(metrics.RedirectQuota).Inc()
+			t.errorPage(c, http.StatusTooManyRequests, errCode, reqUri.Code)
 		} else {
-			c.JSON(http.StatusInternalServerError, errInfo)
+			metrics.Redirects.WithLabelValues(metrics.RedirectError).Inc()
+			c.JSON(http.StatusInternalServerError, t.JsonRespErr(errCode))
 		}
 		return
 	}
 
-	// 异步记录访问历史
-	record := logics.NewHistoryLogic()
-	go func() {
-		_ = record.HistoryAdd(
-			types.HistoryParams{
-				URLID:     data.ID,
-				ShortCode: data.Code,
-				IPAddress: c.ClientIP(),
-				UserAgent: c.Request.UserAgent(),
-				Referer:   c.Request.Referer(),
-			},
-		)
-	}()
+	// 已禁用、已过期或被策略禁止的短链接展示对应的错误页
+	switch data.Status {
+	case model.UrlStatusExpired:
+		metrics.Redirects.WithLabelValues(metrics.RedirectExpired).Inc()
+		t.errorPage(c, http.StatusGone, ecodes.ErrCodeShortenExpired, reqUri.Code)
+		return
+	case model.UrlStatusDisabled, model.UrlStatusBlocked:
+		metrics.Redirects.WithLabelValues(metrics.RedirectDisabled).Inc()
+		t.errorPage(c, http.StatusForbidden, ecodes.ErrCodeShortenDisabled, reqUri.Code)
+		return
+	}
+	metrics.Redirects.WithLabelValues(metrics.RedirectHit).Inc()
+
+	// 异步记录访问历史，请求参数须在返回前读取
+	history.HistoryAddAsync(
+		types.HistoryParams{
+			WorkspaceID: data.WorkspaceID,
+			URLID:       data.ID,
+			ShortCode:   data.Code,
+			IPAddress:   c.ClientIP(),
+			UserAgent:   c.Request.UserAgent(),
+			Referer:     c.Request.Referer(),
+		},
+	)
+
+	// 应用深度链接
+	if data.FallbackURL != "" && !t.IsURL(data.OriginalURL) {
+		t.deepLinkRedirect(c, data)
+		return
+	}
 
 	c.Redirect(http.StatusFound, data.OriginalURL)
 }
 
+// deepLinkRedirect 应用深度链接跳转
+// 桌面端直接跳转网页，Android 上的 intent 链接交由浏览器处理，其余移动端展示唤起应用的中转页
+func (t *ShortenHandler) deepLinkRedirect(c *gin.Context, data types.ResShorten) {
+	ua := strings.ToLower(c.Request.UserAgent())
+	isAndroid := strings.Contains(ua, "android")
+	isIOS := strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "ipod")
+
+	switch {
+	case !isAndroid && !isIOS:
+		c.Redirect(http.StatusFound, data.FallbackURL)
+	case isAndroid && strings.HasPrefix(strings.ToLower(data.OriginalURL), "intent:"):
+		c.Redirect(http.StatusFound, intentWithFallback(data.OriginalURL, data.FallbackURL))
+	default:
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		_ = templates.Render(c.Writer, "bridge.html", templates.BridgePage{
+			AppURL:      template.URL(data.OriginalURL), // 协议已按配置校验，配置不允许 javascript 等协议
+			FallbackURL: data.FallbackURL,
+			Timeout:     t.bridgeTimeout,
+		})
+	}
+}
+
+// intentWithFallback 将 Android intent 链接的 S.browser_fallback_url 设为已按策略检查的网页回退地址
+func intentWithFallback(intentURL string, fallbackURL string) string {
+	// 链接中原有的回退地址未经检查，一律替换
+	rest, _, err := utils.SplitIntentFallback(intentURL)
+	if err != nil {
+		return fallbackURL
+	}
+	end := strings.LastIndex(rest, ";end")
+	if end < 0 || !strings.Contains(rest, "#Intent;") {
+		return rest
+	}
+	return rest[:end] + ";S.browser_fallback_url=" + url.QueryEscape(fallbackURL) + rest[end:]
+}
+
 // ShortenAdd 添加短链接
 func (t *ShortenHandler) ShortenAdd(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqJson struct {
 		Code        string `json:"code,omitempty"`
 		OriginalURL string `json:"original_url" binding:"required,url"`
+		FallbackURL string `json:"fallback_url,omitempty" binding:"omitempty,url"`
 		Describe    string `json:"describe,omitempty"`
 	}
 
@@ -75,13 +159,19 @@ func (t *ShortenHandler) ShortenAdd(c *gin.Context) {
 		return
 	}
 
-	if reqJson.OriginalURL != "" && !t.IsURL(reqJson.OriginalURL) {
+	// 非 http(s) 的应用深度链接必须提供网页回退地址
+	if reqJson.OriginalURL != "" && !t.IsURL(reqJson.OriginalURL) && !t.IsURL(reqJson.FallbackURL) {
 		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
 		return
 	}
 
 	// 生成短码
 	if reqJson.Code == "" {
+		// 开启去重时复用已有的短链接
+		if errCode, data := logic.ShortenDedupe(t.Principal(c), reqJson.OriginalURL); errCode == ecodes.ErrCodeSuccess {
+			c.JSON(http.StatusOK, data)
+			return
+		}
 		reqJson.Code = utils.GenerateCode(shared.GlobalShorten.Length)
 	}
 
@@ -90,30 +180,43 @@ func (t *ShortenHandler) ShortenAdd(c *gin.Context) {
 		return
 	}
 
-	errCode, data := t.logic.ShortenAdd(reqJson.Code, reqJson.OriginalURL, reqJson.Describe)
+	errCode, data := logic.ShortenAdd(t.Principal(c), reqJson.Code, reqJson.OriginalURL, reqJson.FallbackURL, reqJson.Describe)
 	if errCode != 0 {
 		errInfo := t.JsonRespErr(errCode)
 		if errCode == ecodes.ErrCodeConflict {
 			c.JSON(http.StatusConflict, errInfo)
+		} else if errCode == ecodes.ErrCodeInvalidParam {
+			c.JSON(http.StatusBadRequest, errInfo)
+		} else if errCode == ecodes.ErrCodeDestinationRejected {
+			c.JSON(http.StatusForbidden, errInfo)
+		} else if errCode == ecodes.ErrCodeRedirectLoop || errCode == ecodes.ErrCodeRedirectChainTooLong {
+			c.JSON(http.StatusUnprocessableEntity, errInfo)
+		} else if errCode == ecodes.ErrCodeQuotaLinks {
+			c.JSON(http.StatusForbidden, errInfo)
 		} else {
 			c.JSON(http.StatusInternalServerError, errInfo)
 		}
 		return
 	}
 
+	t.Audit(c, types.AuditEvent{Action: model.AuditShortenCreate, TargetType: "shorten", TargetID: data.Code, After: data})
+
 	c.Header("Location", c.Request.RequestURI+"/"+data.Code)
 	c.JSON(http.StatusCreated, data)
 }
 
 // ShortenDelete 删除短链接
 func (t *ShortenHandler) ShortenDelete(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqUri types.ReqCode
 	if err := c.ShouldBindUri(&reqUri); err != nil {
 		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
 		return
 	}
 
-	errCode := t.logic.ShortenDelete(reqUri.Code)
+	_, before := logic.ShortenFind(t.Principal(c), reqUri.Code)
+	errCode := logic.ShortenDelete(t.Principal(c), reqUri.Code)
 	if errCode != ecodes.ErrCodeSuccess {
 		errInfo := t.JsonRespErr(errCode)
 		if errCode == ecodes.ErrCodeNotFound {
@@ -124,11 +227,15 @@ func (t *ShortenHandler) ShortenDelete(c *gin.Context) {
 		return
 	}
 
+	t.Audit(c, types.AuditEvent{Action: model.AuditShortenDelete, TargetType: "shorten", TargetID: reqUri.Code, Before: before})
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
 // ShortenDeleteAll 删除所有短链接
 func (t *ShortenHandler) ShortenDeleteAll(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqQuery struct {
 		IDs string `form:"ids" binding:"required"`
 	}
@@ -139,18 +246,22 @@ func (t *ShortenHandler) ShortenDeleteAll(c *gin.Context) {
 
 	// log.Printf("reqQuery.IDs: %s", reqQuery.IDs)
 	ids := strings.Split(reqQuery.IDs, ",")
-	errCode := t.logic.ShortenDeleteAll(ids)
+	errCode := logic.ShortenDeleteAll(t.Principal(c), ids)
 	if errCode != ecodes.ErrCodeSuccess {
 		errInfo := t.JsonRespErr(errCode)
 		c.JSON(http.StatusInternalServerError, errInfo)
 		return
 	}
 
+	t.Audit(c, types.AuditEvent{Action: model.AuditShortenDeleteBatch, TargetType: "shorten", TargetID: reqQuery.IDs})
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
 // ShortenUpdate 更新短链接
 func (t *ShortenHandler) ShortenUpdate(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqUri types.ReqCode
 	if err := c.ShouldBindUri(&reqUri); err != nil {
 		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
@@ -159,6 +270,7 @@ func (t *ShortenHandler) ShortenUpdate(c *gin.Context) {
 
 	var reqJson struct {
 		OriginalURL string `json:"original_url,omitempty" binding:"omitempty,url"`
+		FallbackURL string `json:"fallback_url,omitempty" binding:"omitempty,url"`
 		Describe    string `json:"describe,omitempty"`
 	}
 	if err := c.ShouldBindJSON(&reqJson); err != nil {
@@ -166,34 +278,45 @@ func (t *ShortenHandler) ShortenUpdate(c *gin.Context) {
 		return
 	}
 
-	if reqJson.OriginalURL != "" && !t.IsURL(reqJson.OriginalURL) {
+	if reqJson.FallbackURL != "" && !t.IsURL(reqJson.FallbackURL) {
 		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
 		return
 	}
 
-	errCode, data := t.logic.ShortenUpdate(reqUri.Code, reqJson.OriginalURL, reqJson.Describe)
+	_, before := logic.ShortenFind(t.Principal(c), reqUri.Code)
+	errCode, data := logic.ShortenUpdate(t.Principal(c), reqUri.Code, reqJson.OriginalURL, reqJson.FallbackURL, reqJson.Describe)
 	if errCode != ecodes.ErrCodeSuccess {
 		errInfo := t.JsonRespErr(errCode)
 		if errCode == ecodes.ErrCodeNotFound {
 			c.JSON(http.StatusNotFound, errInfo)
+		} else if errCode == ecodes.ErrCodeInvalidParam {
+			c.JSON(http.StatusBadRequest, errInfo)
+		} else if errCode == ecodes.ErrCodeDestinationRejected {
+			c.JSON(http.StatusForbidden, errInfo)
+		} else if errCode == ecodes.ErrCodeRedirectLoop || errCode == ecodes.ErrCodeRedirectChainTooLong {
+			c.JSON(http.StatusUnprocessableEntity, errInfo)
 		} else {
 			c.JSON(http.StatusInternalServerError, errInfo)
 		}
 		return
 	}
 
+	t.Audit(c, types.AuditEvent{Action: model.AuditShortenUpdate, TargetType: "shorten", TargetID: reqUri.Code, Before: before, After: data})
+
 	c.JSON(http.StatusOK, data)
 }
 
 // ShortenFind 获取短链接
 func (t *ShortenHandler) ShortenFind(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqUri types.ReqCode
 	if err := c.ShouldBindUri(&reqUri); err != nil {
 		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
 		return
 	}
 
-	errCode, data := t.logic.ShortenFind(reqUri.Code)
+	errCode, data := logic.ShortenFind(t.Principal(c), reqUri.Code)
 	if errCode != ecodes.ErrCodeSuccess {
 		errInfo := t.JsonRespErr(errCode)
 		if errCode == ecodes.ErrCodeNotFound {
@@ -209,6 +332,8 @@ func (t *ShortenHandler) ShortenFind(c *gin.Context) {
 
 // ShortenList 获取短链接列表
 func (t *ShortenHandler) ShortenList(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
 	var reqQuery types.ReqQueryShorten
 	if err := c.ShouldBindQuery(&reqQuery); err != nil {
 		// log.Printf("err: %v", err)
@@ -222,7 +347,7 @@ func (t *ShortenHandler) ShortenList(c *gin.Context) {
 		reqQuery.SortBy = "created_at"
 	}
 
-	errCode, data, pageInfo := t.logic.ShortenAll(reqQuery)
+	errCode, data, pageInfo := logic.ShortenAll(t.Principal(c), reqQuery)
 	if errCode != ecodes.ErrCodeSuccess {
 		errInfo := t.JsonRespErr(errCode)
 		if errCode == ecodes.ErrCodeDatabaseError {
@@ -240,3 +365,17 @@ func (t *ShortenHandler) ShortenList(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// ShortenLoops 扫描形成循环跳转的短链接
+func (t *ShortenHandler) ShortenLoops(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+	errCode, data := logic.ShortenScanLoops(c.Request.Context())
+	if errCode != ecodes.ErrCodeSuccess {
+		c.JSON(http.StatusInternalServerError, t.JsonRespErr(errCode))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
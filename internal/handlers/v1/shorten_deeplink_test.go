@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/middlewares"
+	"go.xoder.cn/shortener/internal/pkgs/policy"
+	"go.xoder.cn/shortener/internal/pkgs/urlnorm"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// useDeepLinkPolicy 允许 intent 与 myapp 深度链接并禁止 evil.test，测试结束后恢复
+func useDeepLinkPolicy(t *testing.T) {
+	t.Helper()
+
+	oldPolicy, oldNorm, oldDeepLink := shared.GlobalPolicy.Load(), shared.GlobalURLNorm.Load(), shared.GlobalDeepLink
+	t.Cleanup(func() {
+		shared.GlobalPolicy.Store(oldPolicy)
+		shared.GlobalURLNorm.Store(oldNorm)
+		shared.GlobalDeepLink = oldDeepLink
+	})
+
+	shared.GlobalPolicy.Store(policy.New(policy.Options{Enabled: true, BlockedDomains: []string{".evil.test"}}))
+	shared.GlobalURLNorm.Store(urlnorm.New(urlnorm.Options{}))
+	shared.GlobalDeepLink = &types.CfgDeepLink{Schemes: []string{"intent", "myapp"}, Timeout: 1500}
+}
+
+func TestShortenAddDeepLinkPolicy(t *testing.T) {
+	useDeepLinkPolicy(t)
+	handler := NewShortenHandler()
+
+	workspace := model.Workspace{Name: "Deep links", Slug: "deeplink-test", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := shared.GlobalDB.Where("slug = ?", workspace.Slug).FirstOrCreate(&workspace).Error; err != nil {
+		t.Fatal(err)
+	}
+	principal := types.Principal{WorkspaceID: workspace.ID, Role: access.RoleAdmin}
+
+	cases := []struct {
+		name        string
+		code        string
+		originalURL string
+		wantStatus  int
+		wantStored  string
+	}{
+		{
+			name:        "intent host blocked",
+			code:        "dl1",
+			originalURL: "intent://evil.test/path#Intent;scheme=myapp;package=com.example;end",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "embedded fallback blocked",
+			code:        "dl2",
+			originalURL: "intent://open#Intent;scheme=myapp;S.browser_fallback_url=https%3A%2F%2Fwww.evil.test%2F;end",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "embedded fallback not http",
+			code:        "dl3",
+			originalURL: "intent://open#Intent;scheme=myapp;S.browser_fallback_url=javascript%3Aalert(1);end",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "embedded fallback removed",
+			code:        "dl4",
+			originalURL: "intent://open#Intent;scheme=myapp;S.browser_fallback_url=https%3A%2F%2Fexample.com%2F;package=com.example;end",
+			wantStatus:  http.StatusCreated,
+			wantStored:  "intent://open#Intent;scheme=myapp;package=com.example;end",
+		},
+	}
+
+	for _, c := range cases {
+		body, _ := sonic.Marshal(map[string]string{
+			"code":         c.code,
+			"original_url": c.originalURL,
+			"fallback_url": "https://example.com/app",
+		})
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/api/shortens", strings.NewReader(string(body)))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		ctx.Set(middlewares.PrincipalKey, principal)
+
+		handler.ShortenAdd(ctx)
+
+		if w.Code != c.wantStatus {
+			t.Errorf("%s: got status %d, want %d: %s", c.name, w.Code, c.wantStatus, w.Body.String())
+			continue
+		}
+		if c.wantStored == "" {
+			continue
+		}
+		var data types.ResShorten
+		if err := sonic.Unmarshal(w.Body.Bytes(), &data); err != nil {
+			t.Fatal(err)
+		}
+		if data.OriginalURL != c.wantStored {
+			t.Errorf("%s: got stored url %q, want %q", c.name, data.OriginalURL, c.wantStored)
+		}
+	}
+}
+
+func TestIntentWithFallback(t *testing.T) {
+	const fallback = "https://example.com/app"
+	cases := map[string]string{
+		"intent://open#Intent;scheme=myapp;end":                                                "intent://open#Intent;scheme=myapp;S.browser_fallback_url=https%3A%2F%2Fexample.com%2Fapp;end",
+		"intent://open#Intent;S.browser_fallback_url=https%3A%2F%2Fevil.test;scheme=myapp;end": "intent://open#Intent;scheme=myapp;S.browser_fallback_url=https%3A%2F%2Fexample.com%2Fapp;end",
+		"intent://open#Intent;S.browser_fallback_url=a;S.browser_fallback_url=b;end":           fallback,
+	}
+	for intentURL, want := range cases {
+		if got := intentWithFallback(intentURL, fallback); got != want {
+			t.Errorf("%s: got %q, want %q", intentURL, got, want)
+		}
+	}
+}
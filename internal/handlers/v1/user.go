@@ -2,26 +2,250 @@ package v1
 
 import (
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
-	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/types"
 )
 
+// userAuditChange 用户变更的审计数据，密码仅记录是否修改
+type userAuditChange struct {
+	types.ResUser
+	PasswordChanged bool `json:"password_changed,omitempty"`
+}
+
 // UserHandler 用户处理器
 type UserHandler struct {
 	handler
+	logic *logics.UserLogic
 }
 
 // NewUserHandler 创建用户处理器
 func NewUserHandler() *UserHandler {
 	t := &UserHandler{}
+	t.audit = logics.NewAuditLogic()
+	t.logic = logics.NewUserLogic()
 	return t
 }
 
 // Current 获取当前登录用户信息
 func (t *UserHandler) Current(c *gin.Context) {
+	user := t.Principal(c)
 	c.JSON(http.StatusOK, gin.H{
-		"name": shared.GlobalUser.Username,
+		"id":   user.UserID,
+		"name": user.Username,
+		"role": user.Role,
+	})
+}
+
+// CurrentUpdate 修改当前登录用户的密码
+func (t *UserHandler) CurrentUpdate(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqJson struct {
+		Password string `json:"password" binding:"required,min=6,max=72"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.UserUpdate(t.Principal(c).UserID, reqJson.Password, "")
+	if errCode != ecodes.ErrCodeSuccess {
+		t.userError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditUserUpdate, TargetType: "user", TargetID: strconv.FormatInt(data.ID, 10), After: userAuditChange{ResUser: data, PasswordChanged: true}})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// UserAdd 添加用户
+func (t *UserHandler) UserAdd(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqJson struct {
+		Username string `json:"username" binding:"required,min=2,max=64"`
+		Password string `json:"password" binding:"required,min=6,max=72"`
+		Role     string `json:"role,omitempty" binding:"omitempty,oneof=admin user"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqJson.Role == "" {
+		reqJson.Role = access.RoleUser
+	}
+
+	errCode, data := logic.UserAdd(reqJson.Username, reqJson.Password, reqJson.Role)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.userError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditUserCreate, TargetType: "user", TargetID: strconv.FormatInt(data.ID, 10), After: data})
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// UserUpdate 更新用户
+func (t *UserHandler) UserUpdate(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	var reqJson struct {
+		Password string `json:"password,omitempty" binding:"omitempty,min=6,max=72"`
+		Role     string `json:"role,omitempty" binding:"omitempty,oneof=admin user"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	_, before := logic.UserFind(reqUri.ID)
+	errCode, data := logic.UserUpdate(reqUri.ID, reqJson.Password, reqJson.Role)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.userError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{
+		Action:     model.AuditUserUpdate,
+		TargetType: "user",
+		TargetID:   strconv.FormatInt(reqUri.ID, 10),
+		Before:     before,
+		After:      userAuditChange{ResUser: data, PasswordChanged: reqJson.Password != ""},
 	})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// UserDelete 删除用户
+func (t *UserHandler) UserDelete(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	// 不能删除自己
+	if reqUri.ID == t.Principal(c).UserID {
+		c.JSON(http.StatusForbidden, t.JsonRespErr(ecodes.ErrCodeUserPermissionDenied))
+		return
+	}
+
+	_, before := logic.UserFind(reqUri.ID)
+	errCode := logic.UserDelete(reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.userError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditUserDelete, TargetType: "user", TargetID: strconv.FormatInt(reqUri.ID, 10), Before: before})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// UserTotpReset 重置用户的两步验证
+func (t *UserHandler) UserTotpReset(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode := logic.UserTotpReset(reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.userError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditUserTotpReset, TargetType: "user", TargetID: strconv.FormatInt(reqUri.ID, 10)})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// UserFind 获取用户
+func (t *UserHandler) UserFind(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.UserFind(reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.userError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// UserList 获取用户列表
+func (t *UserHandler) UserList(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqQuery types.ReqQueryUser
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqQuery.Order == "" {
+		reqQuery.Order = "DESC"
+	}
+	if reqQuery.SortBy == "" {
+		reqQuery.SortBy = "created_at"
+	}
+	if !slices.Contains([]string{"id", "username", "role", "created_at", "updated_at"}, reqQuery.SortBy) {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data, pageInfo := logic.UserAll(reqQuery)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.userError(c, errCode)
+		return
+	}
+
+	result := types.ResSuccess[[]types.ResUser]{
+		Data: data,
+		Meta: pageInfo,
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// userError 用户相关错误响应
+func (t *UserHandler) userError(c *gin.Context, errCode int) {
+	errInfo := t.JsonRespErr(errCode)
+	if errCode == ecodes.ErrCodeUserNotFound {
+		c.JSON(http.StatusNotFound, errInfo)
+	} else if errCode == ecodes.ErrCodeUserExists {
+		c.JSON(http.StatusConflict, errInfo)
+	} else if errCode == ecodes.ErrCodeUserPermissionDenied {
+		c.JSON(http.StatusForbidden, errInfo)
+	} else if errCode == ecodes.ErrCodeBadRequest {
+		c.JSON(http.StatusBadRequest, errInfo)
+	} else {
+		c.JSON(http.StatusInternalServerError, errInfo)
+	}
 }
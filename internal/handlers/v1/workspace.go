@@ -0,0 +1,309 @@
+package v1
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// reqWorkspaceMember 工作区成员路径参数
+type reqWorkspaceMember struct {
+	ID     int64 `uri:"id" binding:"required,min=1"`
+	UserID int64 `uri:"user_id" binding:"required,min=1"`
+}
+
+// WorkspaceHandler 工作区处理器
+type WorkspaceHandler struct {
+	handler
+	logic *logics.WorkspaceLogic
+}
+
+// NewWorkspaceHandler 创建工作区处理器
+func NewWorkspaceHandler() *WorkspaceHandler {
+	t := &WorkspaceHandler{}
+	t.audit = logics.NewAuditLogic()
+	t.logic = logics.NewWorkspaceLogic()
+	return t
+}
+
+// WorkspaceAdd 创建工作区
+func (t *WorkspaceHandler) WorkspaceAdd(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqJson struct {
+		Name       string `json:"name" binding:"required,max=64"`
+		Slug       string `json:"slug" binding:"required,max=64"`
+		Domain     string `json:"domain,omitempty" binding:"omitempty,max=255"`
+		LinkQuota  int64  `json:"link_quota,omitempty" binding:"omitempty,min=0"`
+		ClickQuota int64  `json:"click_quota,omitempty" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.WorkspaceAdd(reqJson.Name, reqJson.Slug, reqJson.Domain, reqJson.LinkQuota, reqJson.ClickQuota)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditWorkspaceCreate, TargetType: "workspace", TargetID: strconv.FormatInt(data.ID, 10), After: data})
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// WorkspaceUpdate 更新工作区，domain 为空字符串时取消绑定域名
+func (t *WorkspaceHandler) WorkspaceUpdate(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	var reqJson struct {
+		Name       string  `json:"name,omitempty" binding:"omitempty,max=64"`
+		Domain     *string `json:"domain,omitempty" binding:"omitempty,max=255"`
+		LinkQuota  *int64  `json:"link_quota,omitempty" binding:"omitempty,min=0"`
+		ClickQuota *int64  `json:"click_quota,omitempty" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	_, before := logic.WorkspaceFind(reqUri.ID)
+	errCode, data := logic.WorkspaceUpdate(reqUri.ID, reqJson.Name, reqJson.Domain, reqJson.LinkQuota, reqJson.ClickQuota)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditWorkspaceUpdate, TargetType: "workspace", TargetID: strconv.FormatInt(reqUri.ID, 10), Before: before, After: data})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// WorkspaceDelete 删除工作区及其全部数据
+func (t *WorkspaceHandler) WorkspaceDelete(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	_, before := logic.WorkspaceFind(reqUri.ID)
+	errCode := logic.WorkspaceDelete(reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditWorkspaceDelete, TargetType: "workspace", TargetID: strconv.FormatInt(reqUri.ID, 10), Before: before})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// WorkspaceFind 获取工作区
+func (t *WorkspaceHandler) WorkspaceFind(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.WorkspaceFind(reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// WorkspaceList 获取工作区列表
+func (t *WorkspaceHandler) WorkspaceList(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqQuery types.ReqQueryWorkspace
+	if err := c.ShouldBindQuery(&reqQuery); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqQuery.Order == "" {
+		reqQuery.Order = "ASC"
+	}
+	if reqQuery.SortBy == "" {
+		reqQuery.SortBy = "id"
+	}
+	if !slices.Contains([]string{"id", "name", "slug", "created_at", "updated_at"}, reqQuery.SortBy) {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data, pageInfo := logic.WorkspaceAll(reqQuery)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	result := types.ResSuccess[[]types.ResWorkspace]{
+		Data: data,
+		Meta: pageInfo,
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// WorkspaceMine 获取当前用户加入的工作区
+func (t *WorkspaceHandler) WorkspaceMine(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+	errCode, data := logic.WorkspaceMine(t.Principal(c))
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+// MemberList 获取工作区成员
+func (t *WorkspaceHandler) MemberList(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.WorkspaceMembers(reqUri.ID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+	})
+}
+
+// MemberAdd 添加工作区成员
+func (t *WorkspaceHandler) MemberAdd(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri types.ReqID
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	var reqJson struct {
+		UserID int64  `json:"user_id" binding:"required,min=1"`
+		Role   string `json:"role,omitempty" binding:"omitempty,oneof=admin member"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+	if reqJson.Role == "" {
+		reqJson.Role = access.WorkspaceRoleMember
+	}
+
+	errCode, data := logic.WorkspaceMemberAdd(reqUri.ID, reqJson.UserID, reqJson.Role)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditWorkspaceMemberAdd, TargetType: "workspace", TargetID: strconv.FormatInt(reqUri.ID, 10), After: data})
+
+	c.JSON(http.StatusCreated, data)
+}
+
+// MemberUpdate 修改工作区成员角色
+func (t *WorkspaceHandler) MemberUpdate(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri reqWorkspaceMember
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	var reqJson struct {
+		Role string `json:"role" binding:"required,oneof=admin member"`
+	}
+	if err := c.ShouldBindJSON(&reqJson); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode, data := logic.WorkspaceMemberUpdate(reqUri.ID, reqUri.UserID, reqJson.Role)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{Action: model.AuditWorkspaceMemberUpdate, TargetType: "workspace", TargetID: strconv.FormatInt(reqUri.ID, 10), After: data})
+
+	c.JSON(http.StatusOK, data)
+}
+
+// MemberDelete 移除工作区成员
+func (t *WorkspaceHandler) MemberDelete(c *gin.Context) {
+	logic := t.logic.WithContext(c.Request.Context())
+
+	var reqUri reqWorkspaceMember
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		c.JSON(http.StatusBadRequest, t.JsonRespErr(ecodes.ErrCodeInvalidParam))
+		return
+	}
+
+	errCode := logic.WorkspaceMemberDelete(reqUri.ID, reqUri.UserID)
+	if errCode != ecodes.ErrCodeSuccess {
+		t.workspaceError(c, errCode)
+		return
+	}
+
+	t.Audit(c, types.AuditEvent{
+		Action:     model.AuditWorkspaceMemberRemove,
+		TargetType: "workspace",
+		TargetID:   strconv.FormatInt(reqUri.ID, 10),
+		Before:     types.ResWorkspaceMember{WorkspaceID: reqUri.ID, UserID: reqUri.UserID},
+	})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// workspaceError 工作区相关错误响应
+func (t *WorkspaceHandler) workspaceError(c *gin.Context, errCode int) {
+	errInfo := t.JsonRespErr(errCode)
+	if errCode == ecodes.ErrCodeNotFound || errCode == ecodes.ErrCodeWorkspaceNotFound || errCode == ecodes.ErrCodeUserNotFound {
+		c.JSON(http.StatusNotFound, errInfo)
+	} else if errCode == ecodes.ErrCodeInvalidParam {
+		c.JSON(http.StatusBadRequest, errInfo)
+	} else if errCode == ecodes.ErrCodeConflict {
+		c.JSON(http.StatusConflict, errInfo)
+	} else if errCode == ecodes.ErrCodeWorkspaceDefault {
+		c.JSON(http.StatusForbidden, errInfo)
+	} else {
+		c.JSON(http.StatusInternalServerError, errInfo)
+	}
+}
@@ -1,15 +1,22 @@
 package logics
 
 import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
 
 	"go.xoder.cn/shortener/internal/cache"
+	"go.xoder.cn/shortener/internal/ecodes"
 	"go.xoder.cn/shortener/internal/shared"
 )
 
 // logic 逻辑层
 type logic struct {
+	ctx      context.Context
 	db       *gorm.DB
 	cache    *cache.CacheManager
 	site_url string
@@ -17,6 +24,7 @@ type logic struct {
 
 // init 初始化
 func (t *logic) init() {
+	t.ctx = context.Background()
 	t.db = shared.GlobalDB
 	t.cache = shared.GlobalCache
 	t.site_url = viper.GetString("server.site_url")
@@ -25,6 +33,30 @@ func (t *logic) init() {
 	}
 }
 
+// withContext 返回使用请求上下文的副本，数据库操作及日志附带请求ID
+// 仅沿用上下文中的值，客户端断开时不中断数据库操作，异步写入的访问记录也不受影响
+func (t logic) withContext(ctx context.Context) logic {
+	t.ctx = context.WithoutCancel(ctx)
+	t.db = t.db.WithContext(t.ctx)
+	return t
+}
+
+// logError 记录返回错误码的原因，返回错误码本身
+func (t *logic) logError(errCode int, err error) int {
+	caller := "unknown"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			caller = fn.Name()[strings.LastIndex(fn.Name(), "/")+1:]
+		}
+	}
+	slog.ErrorContext(t.ctx, ecodes.GetErrCodeMessage(errCode),
+		"errcode", errCode,
+		"func", caller,
+		"err", err,
+	)
+	return errCode
+}
+
 // GetSiteURL 获取短链接的完整URL
 func (t *logic) GetSiteURL(code string) string {
 	return t.site_url + "/" + code
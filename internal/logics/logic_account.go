@@ -1,43 +1,185 @@
 package logics
 
 import (
+	"context"
 	"errors"
+	"slices"
+	"time"
 
-	"go.xoder.cn/shortener/internal/shared"
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/types"
 	"go.xoder.cn/shortener/internal/utils"
 )
 
 // AccountLogic 账号处理逻辑
 type AccountLogic struct {
 	logic
+	session *SessionLogic
+	jwt     *JwtLogic
+	totp    *TotpLogic
 }
 
 // NewAccountLogic 账号逻辑实例化
 func NewAccountLogic() *AccountLogic {
 	t := &AccountLogic{}
 	t.init()
+	t.session = NewSessionLogic()
+	t.jwt = NewJwtLogic()
+	t.totp = NewTotpLogic()
 	return t
 }
 
-// Check 检查账号密码
-func (t *AccountLogic) Login(username string, password string) (string, error) {
-	if username != shared.GlobalUser.Username || password != shared.GlobalUser.Password {
-		return "", errors.New("用户名或密码错误")
+// WithContext 返回使用请求上下文的副本
+func (t *AccountLogic) WithContext(ctx context.Context) *AccountLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	if t.session != nil {
+		c.session = t.session.WithContext(ctx)
+	}
+	if t.jwt != nil {
+		c.jwt = t.jwt.WithContext(ctx)
+	}
+	if t.totp != nil {
+		c.totp = t.totp.WithContext(ctx)
+	}
+	return &c
+}
+
+// Authenticate 检查账号密码，开启两步验证的用户还需调用 Challenge 完成验证
+func (t *AccountLogic) Authenticate(username string, password string) (int, model.User) {
+	var user model.User
+	if err := t.db.Where("username = ?", username).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return t.logError(ecodes.ErrCodeDatabaseError, err), user
+		}
+		return ecodes.ErrCodeUserPasswordError, user
+	}
+	if !utils.CheckPassword(user.Password, password) {
+		return ecodes.ErrCodeUserPasswordError, user
+	}
+	return ecodes.ErrCodeSuccess, user
+}
+
+// TokenScopes 检查 JWT 访问令牌的权限范围并去重，为空时返回全部权限范围
+func (t *AccountLogic) TokenScopes(scopes []string) (int, []string) {
+	if !t.jwt.JwtEnabled() {
+		return ecodes.ErrCodeBadRequest, nil
+	}
+
+	checked := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(access.Scopes(), scope) {
+			return ecodes.ErrCodeInvalidParam, nil
+		}
+		if !slices.Contains(checked, scope) {
+			checked = append(checked, scope)
+		}
+	}
+	if len(checked) == 0 {
+		checked = access.Scopes()
+	}
+	return ecodes.ErrCodeSuccess, checked
+}
+
+// Login 创建登录会话，remember 为 true 时使用较长的会话有效期
+func (t *AccountLogic) Login(user model.User, remember bool, ip string, userAgent string) (int, types.ResLogin) {
+	token, session, err := t.session.SessionCreate(user, model.SessionKindLogin, nil, remember, ip, userAgent)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResLogin{}
+	}
+	return ecodes.ErrCodeSuccess, types.ResLogin{
+		Token:     token,
+		ExpiresAt: utils.TimeToStr(session.ExpiresAt),
+	}
+}
+
+// LoginToken 签发 JWT 访问令牌与刷新令牌，scopes 须先经 TokenScopes 检查
+func (t *AccountLogic) LoginToken(user model.User, remember bool, scopes []string, ip string, userAgent string) (int, types.ResToken) {
+	refreshToken, session, err := t.session.SessionCreate(user, model.SessionKindRefresh, scopes, remember, ip, userAgent)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResToken{}
+	}
+
+	return t.tokenResult(types.Principal{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		Scopes:    scopes,
+		SessionID: session.ID,
+	}, refreshToken, session.ExpiresAt)
+}
+
+// Challenge 创建两步验证挑战，scopes 不为空表示验证通过后签发 JWT
+func (t *AccountLogic) Challenge(user model.User, remember bool, scopes []string, ip string, userAgent string) (int, types.ResChallenge) {
+	challenge, session, err := t.session.SessionCreate(user, model.SessionKindMFA, scopes, remember, ip, userAgent)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResChallenge{}
 	}
+	return ecodes.ErrCodeSuccess, types.ResChallenge{
+		MfaRequired: true,
+		Challenge:   challenge,
+		ExpiresAt:   utils.TimeToStr(session.ExpiresAt),
+	}
+}
 
-	userToken := utils.GenerateCode(32)
+// Verify 校验两步验证挑战的验证码或恢复码，挑战无论成功与否仅能使用一次
+// 返回用户及挑战会话，由调用方按挑战中的权限范围完成登录
+func (t *AccountLogic) Verify(challenge string, code string) (int, model.User, model.Session) {
+	var user model.User
+	session, ok, err := t.session.SessionTake(challenge, model.SessionKindMFA)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), user, session
+	} else if !ok {
+		return ecodes.ErrCodeUnauthorized, user, session
+	}
 
-	shared.GlobalUserCache.Range(func(key, value any) bool {
-		shared.GlobalUserCache.Delete(key)
-		return true
-	})
-	shared.GlobalUserCache.Store(userToken, username)
+	if err := t.db.Where("id = ?", session.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeUnauthorized, user, session
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), user, session
+	}
 
-	return userToken, nil
+	if errCode := t.totp.TotpCheck(&user, code); errCode != ecodes.ErrCodeSuccess {
+		return errCode, user, session
+	}
+	return ecodes.ErrCodeSuccess, user, session
 }
 
-// Remove 移除账号
-func (t *AccountLogic) Remove(token string) error {
-	shared.GlobalUserCache.Delete(token)
-	return nil
+// Refresh 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换
+func (t *AccountLogic) Refresh(refreshToken string) (int, types.ResToken) {
+	if !t.jwt.JwtEnabled() {
+		return ecodes.ErrCodeBadRequest, types.ResToken{}
+	}
+
+	newToken, principal, expiresAt, ok, err := t.session.SessionRefresh(refreshToken)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResToken{}
+	} else if !ok {
+		return ecodes.ErrCodeUnauthorized, types.ResToken{}
+	}
+
+	return t.tokenResult(principal, newToken, expiresAt)
+}
+
+// tokenResult 签发访问令牌并构造响应
+func (t *AccountLogic) tokenResult(principal types.Principal, refreshToken string, refreshExpiresAt time.Time) (int, types.ResToken) {
+	accessToken, expiresAt, err := t.jwt.JwtSign(principal)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeSystemInternalError, err), types.ResToken{}
+	}
+
+	return ecodes.ErrCodeSuccess, types.ResToken{
+		TokenType:        "Bearer",
+		AccessToken:      accessToken,
+		ExpiresIn:        int64(time.Until(expiresAt).Round(time.Second) / time.Second),
+		ExpiresAt:        utils.TimeToStr(expiresAt),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: utils.TimeToStr(refreshExpiresAt),
+		Scopes:           principal.Scopes,
+	}
 }
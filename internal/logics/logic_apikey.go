@@ -0,0 +1,398 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+const (
+	apiKeyPrefix    = "sk_" // 密钥前缀，便于识别与密钥扫描
+	apiKeyLength    = 40    // 随机部分长度
+	apiKeyShowChars = 10    // 保存用于识别的前缀长度
+
+	apiKeySecretPrefix = "ss_" // 签名密钥前缀
+	apiKeySecretLength = 40    // 签名密钥随机部分长度
+
+	// apiKeyTouchInterval 最近使用时间的最小更新间隔，避免每次请求都写库
+	apiKeyTouchInterval = time.Minute
+)
+
+// ApiKeyLogic API Key 逻辑层
+type ApiKeyLogic struct {
+	logic
+}
+
+// NewApiKeyLogic 创建 API Key 逻辑层
+func NewApiKeyLogic() *ApiKeyLogic {
+	t := &ApiKeyLogic{}
+	t.init()
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *ApiKeyLogic) WithContext(ctx context.Context) *ApiKeyLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
+// ApiKeyVerify 校验 API Key，返回其对应的身份
+// 密钥不存在、已过期或所属用户已删除时返回 false
+func (t *ApiKeyLogic) ApiKeyVerify(key string) (types.Principal, bool, error) {
+	var apiKey model.ApiKey
+	if err := t.db.Where("key_hash = ?", utils.HashSecret(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.Principal{}, false, nil
+		}
+		return types.Principal{}, false, err
+	}
+
+	principal, ok, err := t.apiKeyPrincipal(apiKey)
+	if err != nil || !ok {
+		return types.Principal{}, false, err
+	}
+
+	t.apiKeyTouch(apiKey)
+	return principal, true, nil
+}
+
+// ApiKeySignVerify 按密钥ID查找签名密钥并交由 verify 校验请求签名，返回其对应的身份
+// 密钥不存在、未设置签名密钥、已过期、所属用户已删除或签名无效时返回 false
+func (t *ApiKeyLogic) ApiKeySignVerify(keyID string, verify func(secret string) (bool, error)) (types.Principal, bool, error) {
+	id, err := strconv.ParseInt(keyID, 10, 64)
+	if err != nil || id <= 0 {
+		return types.Principal{}, false, nil
+	}
+
+	var apiKey model.ApiKey
+	if err := t.db.Where("id = ?", id).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.Principal{}, false, nil
+		}
+		return types.Principal{}, false, err
+	}
+	if apiKey.Secret == "" {
+		return types.Principal{}, false, nil
+	}
+
+	principal, ok, err := t.apiKeyPrincipal(apiKey)
+	if err != nil || !ok {
+		return types.Principal{}, false, err
+	}
+
+	if ok, err := verify(apiKey.Secret); err != nil || !ok {
+		return types.Principal{}, false, err
+	}
+
+	t.apiKeyTouch(apiKey)
+	return principal, true, nil
+}
+
+// ApiKeyAdd 在当前工作区创建 API Key，ownerID 为 0 时归属当前用户
+func (t *ApiKeyLogic) ApiKeyAdd(user types.Principal, ownerID int64, name string, scopes []string, expiresAt *time.Time) (int, types.ResApiKey) {
+	if ownerID == 0 {
+		ownerID = user.UserID
+	}
+	// 仅管理员可为其他用户创建
+	if ownerID != user.UserID && !user.IsAdmin() {
+		return ecodes.ErrCodeUserPermissionDenied, types.ResApiKey{}
+	}
+
+	var owner model.User
+	if err := t.db.Where("id = ?", ownerID).First(&owner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeUserNotFound, types.ResApiKey{}
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResApiKey{}
+	}
+
+	// 为其他用户创建时，该用户须为工作区成员
+	if owner.ID != user.UserID && owner.Role != access.RoleAdmin {
+		var count int64
+		if err := t.db.Model(&model.WorkspaceMember{}).Where("workspace_id = ? AND user_id = ?", user.WorkspaceID, owner.ID).Count(&count).Error; err != nil {
+			return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResApiKey{}
+		}
+		if count == 0 {
+			return ecodes.ErrCodeWorkspaceForbidden, types.ResApiKey{}
+		}
+	}
+
+	scopes, errCode := t.apiKeyCheckScopes(user, owner.Role, scopes)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResApiKey{}
+	}
+
+	key := apiKeyPrefix + utils.GenerateSecret(apiKeyLength)
+	nowTime := time.Now().Local()
+	apiKey := model.ApiKey{
+		WorkspaceID: user.WorkspaceID,
+		UserID:      owner.ID,
+		Name:        name,
+		Prefix:      key[:apiKeyShowChars],
+		KeyHash:     utils.HashSecret(key),
+		Secret:      apiKeySecretPrefix + utils.GenerateSecret(apiKeySecretLength),
+		Scopes:      strings.Join(scopes, " "),
+		ExpiresAt:   expiresAt,
+		CreatedAt:   nowTime,
+		UpdatedAt:   nowTime,
+	}
+	if err := t.db.Create(&apiKey).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResApiKey{}
+	}
+
+	result := apiKeyResult(apiKey)
+	result.Key = key
+	result.Secret = apiKey.Secret
+	return ecodes.ErrCodeSuccess, result
+}
+
+// ApiKeyUpdate 更新 API Key 的名称、权限范围或过期时间，空值表示不修改，clearExpires 为 true 时改为永不过期
+func (t *ApiKeyLogic) ApiKeyUpdate(user types.Principal, id int64, name string, scopes []string, expiresAt *time.Time, clearExpires bool) (int, types.ResApiKey) {
+	errCode, apiKey := t.apiKeyFind(user, id)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResApiKey{}
+	}
+
+	updates := make(map[string]any)
+	if name != "" {
+		updates["name"] = name
+		apiKey.Name = name
+	}
+	if scopes != nil {
+		var owner model.User
+		if err := t.db.Where("id = ?", apiKey.UserID).First(&owner).Error; err != nil {
+			return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResApiKey{}
+		}
+		checked, errCode := t.apiKeyCheckScopes(user, owner.Role, scopes)
+		if errCode != ecodes.ErrCodeSuccess {
+			return errCode, types.ResApiKey{}
+		}
+		apiKey.Scopes = strings.Join(checked, " ")
+		updates["scopes"] = apiKey.Scopes
+	}
+	if clearExpires {
+		updates["expires_at"] = nil
+		apiKey.ExpiresAt = nil
+	} else if expiresAt != nil {
+		updates["expires_at"] = expiresAt
+		apiKey.ExpiresAt = expiresAt
+	}
+	apiKey.UpdatedAt = time.Now().Local()
+	updates["updated_at"] = apiKey.UpdatedAt
+
+	if err := t.db.Model(&apiKey).Updates(updates).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResApiKey{}
+	}
+
+	return ecodes.ErrCodeSuccess, apiKeyResult(apiKey)
+}
+
+// ApiKeyRotate 轮换密钥与签名密钥，旧密钥立即失效，其余属性保持不变
+func (t *ApiKeyLogic) ApiKeyRotate(user types.Principal, id int64) (int, types.ResApiKey) {
+	errCode, apiKey := t.apiKeyFind(user, id)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResApiKey{}
+	}
+
+	key := apiKeyPrefix + utils.GenerateSecret(apiKeyLength)
+	apiKey.Prefix = key[:apiKeyShowChars]
+	apiKey.KeyHash = utils.HashSecret(key)
+	apiKey.Secret = apiKeySecretPrefix + utils.GenerateSecret(apiKeySecretLength)
+	apiKey.LastUsedAt = nil
+	apiKey.UpdatedAt = time.Now().Local()
+
+	err := t.db.Model(&apiKey).Updates(map[string]any{
+		"prefix":       apiKey.Prefix,
+		"key_hash":     apiKey.KeyHash,
+		"secret":       apiKey.Secret,
+		"last_used_at": nil,
+		"updated_at":   apiKey.UpdatedAt,
+	}).Error
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResApiKey{}
+	}
+
+	result := apiKeyResult(apiKey)
+	result.Key = key
+	result.Secret = apiKey.Secret
+	return ecodes.ErrCodeSuccess, result
+}
+
+// ApiKeyDelete 删除 API Key
+func (t *ApiKeyLogic) ApiKeyDelete(user types.Principal, id int64) int {
+	res := t.db.Scopes(ownedBy(user), ofWorkspace(user)).Where("id = ?", id).Delete(&model.ApiKey{})
+	if res.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, res.Error)
+	} else if res.RowsAffected == 0 {
+		return ecodes.ErrCodeNotFound
+	}
+	return ecodes.ErrCodeSuccess
+}
+
+// ApiKeyFind 获取 API Key
+func (t *ApiKeyLogic) ApiKeyFind(user types.Principal, id int64) (int, types.ResApiKey) {
+	errCode, apiKey := t.apiKeyFind(user, id)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResApiKey{}
+	}
+	return ecodes.ErrCodeSuccess, apiKeyResult(apiKey)
+}
+
+// ApiKeyAll 获取 API Key 列表
+func (t *ApiKeyLogic) ApiKeyAll(user types.Principal, reqQuery types.ReqQueryApiKey) (int, []types.ResApiKey, types.ResPage) {
+	results := make([]types.ResApiKey, 0)
+	pageInfo := types.ResPage{}
+
+	query := t.db.Model(&model.ApiKey{}).
+		Scopes(ownedBy(user), ofWorkspace(user)).
+		Order(fmt.Sprintf("%s %s", reqQuery.SortBy, reqQuery.Order))
+
+	// 管理员可按所属用户筛选
+	if reqQuery.UserID != nil && user.IsAdmin() {
+		query = query.Where("user_id = ?", *reqQuery.UserID)
+	}
+
+	// 计算总条数
+	var total int64
+	query = query.Count(&total)
+	if query.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, query.Error), results, pageInfo
+	}
+
+	// 分页查询
+	data := make([]model.ApiKey, 0)
+	resDB := query.Offset(int((reqQuery.Page - 1) * reqQuery.PageSize)).
+		Limit(int(reqQuery.PageSize)).
+		Find(&data)
+	if resDB.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, resDB.Error), results, pageInfo
+	}
+
+	// 页码信息
+	pageInfo.Page = reqQuery.Page
+	pageInfo.PageSize = reqQuery.PageSize
+	pageInfo.CurrentCount = resDB.RowsAffected
+	pageInfo.TotalItems = total
+	pageInfo.TotalPages = total / int64(reqQuery.PageSize)
+	if total%int64(reqQuery.PageSize) != 0 {
+		pageInfo.TotalPages++
+	}
+
+	for _, item := range data {
+		results = append(results, apiKeyResult(item))
+	}
+
+	return ecodes.ErrCodeSuccess, results, pageInfo
+}
+
+// apiKeyFind 获取当前用户在当前工作区中可管理的 API Key
+func (t *ApiKeyLogic) apiKeyFind(user types.Principal, id int64) (int, model.ApiKey) {
+	var apiKey model.ApiKey
+	if err := t.db.Scopes(ownedBy(user), ofWorkspace(user)).Where("id = ?", id).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeNotFound, apiKey
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), apiKey
+	}
+	return ecodes.ErrCodeSuccess, apiKey
+}
+
+// apiKeyPrincipal 构造密钥对应的身份，已过期或所属用户已删除时返回 false
+func (t *ApiKeyLogic) apiKeyPrincipal(apiKey model.ApiKey) (types.Principal, bool, error) {
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now().Local()) {
+		return types.Principal{}, false, nil
+	}
+
+	var user model.User
+	if err := t.db.Where("id = ?", apiKey.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.Principal{}, false, nil
+		}
+		return types.Principal{}, false, err
+	}
+
+	return types.Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Scopes:   apiKeyScopes(apiKey.Scopes),
+		ApiKeyID: apiKey.ID,
+
+		WorkspaceID: apiKey.WorkspaceID,
+	}, true, nil
+}
+
+// apiKeyTouch 更新最近使用时间
+func (t *ApiKeyLogic) apiKeyTouch(apiKey model.ApiKey) {
+	nowTime := time.Now().Local()
+	if apiKey.LastUsedAt != nil && nowTime.Sub(*apiKey.LastUsedAt) < apiKeyTouchInterval {
+		return
+	}
+	if err := t.db.Model(&model.ApiKey{}).Where("id = ?", apiKey.ID).
+		UpdateColumn("last_used_at", nowTime).Error; err != nil {
+		slog.ErrorContext(t.ctx, "update api key last used failed", "err", err)
+	}
+}
+
+// apiKeyCheckScopes 校验并去重权限范围
+// 非管理员用户的密钥不能具有 admin 权限，通过 API Key 创建时不能超出当前密钥的权限
+func (t *ApiKeyLogic) apiKeyCheckScopes(user types.Principal, ownerRole string, scopes []string) ([]string, int) {
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(access.Scopes(), scope) {
+			return nil, ecodes.ErrCodeInvalidParam
+		}
+		if !user.HasScope(scope) {
+			return nil, ecodes.ErrCodeUserPermissionDenied
+		}
+		if scope == access.ScopeAdmin && ownerRole != access.RoleAdmin {
+			return nil, ecodes.ErrCodeUserPermissionDenied
+		}
+		if !slices.Contains(result, scope) {
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ecodes.ErrCodeInvalidParam
+	}
+	return result, ecodes.ErrCodeSuccess
+}
+
+// apiKeyScopes 解析保存的权限范围，结果不为 nil
+func apiKeyScopes(scopes string) []string {
+	result := strings.Fields(scopes)
+	if result == nil {
+		result = []string{}
+	}
+	return result
+}
+
+// apiKeyResult 构造 API Key 响应
+func apiKeyResult(apiKey model.ApiKey) types.ResApiKey {
+	return types.ResApiKey{
+		ID:          apiKey.ID,
+		WorkspaceID: apiKey.WorkspaceID,
+		UserID:      apiKey.UserID,
+		Name:        apiKey.Name,
+		Prefix:      apiKey.Prefix,
+		Scopes:      apiKeyScopes(apiKey.Scopes),
+		ExpiresAt:   timePtrToStr(apiKey.ExpiresAt),
+		LastUsedAt:  timePtrToStr(apiKey.LastUsedAt),
+		CreatedAt:   utils.TimeToStr(apiKey.CreatedAt),
+		UpdatedAt:   utils.TimeToStr(apiKey.UpdatedAt),
+	}
+}
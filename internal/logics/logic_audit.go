@@ -0,0 +1,234 @@
+package logics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+// AuditLogic 审计日志逻辑层
+type AuditLogic struct {
+	logic
+	config *types.CfgAudit
+}
+
+// NewAuditLogic 创建审计日志逻辑层
+func NewAuditLogic() *AuditLogic {
+	t := &AuditLogic{}
+	t.init()
+	t.config = shared.GlobalAudit
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *AuditLogic) WithContext(ctx context.Context) *AuditLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
+// AuditRecord 记录审计事件，写入失败仅记录日志，不影响业务操作
+func (t *AuditLogic) AuditRecord(event types.AuditEvent, ip string, userAgent string) {
+	if t.config == nil {
+		return
+	}
+
+	before, after := auditDiff(event.Before, event.After)
+	entry := model.AuditLog{
+		ActorType:   model.AuditActorUser,
+		ActorID:     event.Actor.UserID,
+		ActorName:   truncateStr(event.Actor.Username, 64),
+		ApiKeyID:    event.Actor.ApiKeyID,
+		WorkspaceID: event.Actor.WorkspaceID,
+		Action:      event.Action,
+		TargetType:  event.TargetType,
+		TargetID:    truncateStr(event.TargetID, 255),
+		IP:          ip,
+		UserAgent:   truncateStr(userAgent, 255),
+		Before:      before,
+		After:       after,
+		CreatedAt:   time.Now().Local(),
+	}
+	if entry.ApiKeyID != 0 {
+		entry.ActorType = model.AuditActorApiKey
+	} else if event.Actor.Client != "" {
+		// 用户名可由 actor_id 获知，记录证书身份名称
+		entry.ActorType = model.AuditActorCert
+		entry.ActorName = truncateStr(event.Actor.Client, 64)
+	} else if entry.ActorID == 0 {
+		entry.ActorType = model.AuditActorAnonymous
+	}
+
+	if err := t.db.Create(&entry).Error; err != nil {
+		slog.ErrorContext(t.ctx, "record audit log failed", "err", err, "action", entry.Action, "target", entry.TargetType+":"+entry.TargetID)
+	}
+}
+
+// AuditAll 获取审计日志列表，start 与 end 为零值时不限制
+func (t *AuditLogic) AuditAll(reqQuery types.ReqQueryAudit, start time.Time, end time.Time) (int, []types.ResAudit, types.ResPage) {
+	results := make([]types.ResAudit, 0)
+	pageInfo := types.ResPage{}
+
+	query := t.db.Model(&model.AuditLog{}).
+		Order(fmt.Sprintf("%s %s", reqQuery.SortBy, reqQuery.Order))
+
+	if reqQuery.ActorID != 0 {
+		query = query.Where("actor_id = ?", reqQuery.ActorID)
+	}
+	if reqQuery.ApiKeyID != 0 {
+		query = query.Where("api_key_id = ?", reqQuery.ApiKeyID)
+	}
+	if reqQuery.WorkspaceID != 0 {
+		query = query.Where("workspace_id = ?", reqQuery.WorkspaceID)
+	}
+	if reqQuery.Action != "" {
+		query = query.Where("action = ?", reqQuery.Action)
+	}
+	if reqQuery.TargetType != "" {
+		query = query.Where("target_type = ?", reqQuery.TargetType)
+	}
+	if reqQuery.TargetID != "" {
+		query = query.Where("target_id = ?", reqQuery.TargetID)
+	}
+	if reqQuery.IP != "" {
+		query = query.Where("ip = ?", reqQuery.IP)
+	}
+	if !start.IsZero() {
+		query = query.Where("created_at >= ?", start)
+	}
+	if !end.IsZero() {
+		query = query.Where("created_at < ?", end)
+	}
+
+	// 计算总条数
+	var total int64
+	query = query.Count(&total)
+	if query.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, query.Error), results, pageInfo
+	}
+
+	// 分页查询
+	data := make([]model.AuditLog, 0)
+	resDB := query.Offset(int((reqQuery.Page - 1) * reqQuery.PageSize)).
+		Limit(int(reqQuery.PageSize)).
+		Find(&data)
+	if resDB.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, resDB.Error), results, pageInfo
+	}
+
+	// 页码信息
+	pageInfo.Page = reqQuery.Page
+	pageInfo.PageSize = reqQuery.PageSize
+	pageInfo.CurrentCount = resDB.RowsAffected
+	pageInfo.TotalItems = total
+	pageInfo.TotalPages = total / int64(reqQuery.PageSize)
+	if total%int64(reqQuery.PageSize) != 0 {
+		pageInfo.TotalPages++
+	}
+
+	for _, item := range data {
+		results = append(results, types.ResAudit{
+			ID:          item.ID,
+			ActorType:   item.ActorType,
+			ActorID:     item.ActorID,
+			ActorName:   item.ActorName,
+			ApiKeyID:    item.ApiKeyID,
+			WorkspaceID: item.WorkspaceID,
+			Action:      item.Action,
+			TargetType:  item.TargetType,
+			TargetID:    item.TargetID,
+			IP:          item.IP,
+			UserAgent:   item.UserAgent,
+			Before:      auditDecode(item.Before),
+			After:       auditDecode(item.After),
+			CreatedAt:   utils.TimeToStr(item.CreatedAt),
+		})
+	}
+
+	return ecodes.ErrCodeSuccess, results, pageInfo
+}
+
+// AuditPurgeRun 按周期清理超过保留天数的日志，直到 ctx 取消
+func (t *AuditLogic) AuditPurgeRun(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Local().AddDate(0, 0, -t.config.RetentionDays)
+		if err := t.db.Where("created_at < ?", before).Delete(&model.AuditLog{}).Error; err != nil {
+			slog.ErrorContext(ctx, "purge audit logs failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// auditDiff 比较操作前后的数据，返回有变化的字段（JSON），无数据时返回空字符串
+func auditDiff(before any, after any) (string, string) {
+	beforeMap := auditFields(before)
+	afterMap := auditFields(after)
+	if beforeMap != nil && afterMap != nil {
+		for key, value := range beforeMap {
+			if other, ok := afterMap[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeMap, key)
+				delete(afterMap, key)
+			}
+		}
+	}
+	return auditEncode(beforeMap), auditEncode(afterMap)
+}
+
+// auditFields 将数据转换为字段映射
+func auditFields(data any) map[string]any {
+	if data == nil {
+		return nil
+	}
+	buf, err := sonic.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := sonic.Unmarshal(buf, &fields); err != nil {
+		// 非对象类型的数据整体记录
+		return map[string]any{"value": data}
+	}
+	return fields
+}
+
+// auditEncode 字段映射转为 JSON
+func auditEncode(fields map[string]any) string {
+	if fields == nil {
+		return ""
+	}
+	buf, err := sonic.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
+// auditDecode 解析记录的 JSON，空字符串返回 nil
+func auditDecode(data string) any {
+	if data == "" {
+		return nil
+	}
+	var value any
+	if err := sonic.Unmarshal([]byte(data), &value); err != nil {
+		return data
+	}
+	return value
+}
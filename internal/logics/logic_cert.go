@@ -0,0 +1,66 @@
+package logics
+
+import (
+	"crypto/x509"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/pkgs/certauth"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// CertLogic 客户端证书认证逻辑层
+type CertLogic struct {
+	logic
+	authority *certauth.Authority
+}
+
+// NewCertLogic 创建客户端证书认证逻辑层
+func NewCertLogic() *CertLogic {
+	t := &CertLogic{}
+	t.init()
+	t.authority = shared.GlobalCertAuth
+	return t
+}
+
+// CertVerify 按客户端证书匹配身份
+// 证书已吊销、未匹配任何规则或映射的用户不存在时返回 false
+func (t *CertLogic) CertVerify(cert *x509.Certificate) (types.Principal, bool, error) {
+	if t.authority == nil || t.authority.Revoked(cert) {
+		return types.Principal{}, false, nil
+	}
+
+	identity, ok := t.authority.Match(cert)
+	if !ok {
+		return types.Principal{}, false, nil
+	}
+
+	var user model.User
+	if err := t.db.Where("username = ?", identity.Username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.Principal{}, false, nil
+		}
+		return types.Principal{}, false, err
+	}
+
+	// 非管理员用户不能具有 admin 权限，与 API Key 一致
+	scopes := make([]string, 0, len(identity.Scopes))
+	for _, scope := range identity.Scopes {
+		if scope == access.ScopeAdmin && user.Role != access.RoleAdmin {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+
+	return types.Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Scopes:   scopes,
+		Client:   identity.Name,
+	}, true, nil
+}
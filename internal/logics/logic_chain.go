@@ -0,0 +1,267 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+var (
+	errRedirectLoop         = errors.New("redirect loop detected")
+	errRedirectChainTooLong = errors.New("redirect chain too long")
+)
+
+// chain 跳转链路检测
+type chain struct {
+	siteHost      string // server.site_url 的主机
+	sitePath      string // server.site_url 的路径前缀，不含末尾的 /
+	ownHosts      []string
+	externalHosts []string
+	collapse      bool
+	maxHops       int
+	client        *http.Client
+}
+
+// initChain 初始化跳转链路检测
+func (t *ShortenLogic) initChain() {
+	chainCfg := types.CfgChain{MaxHops: 10, Timeout: 5}
+	if shared.GlobalChain != nil {
+		chainCfg = *shared.GlobalChain
+	}
+
+	var siteHost, sitePath string
+	if u, err := url.Parse(t.site_url); err == nil && u.Host != "" {
+		siteHost = strings.ToLower(u.Host)
+		sitePath = strings.TrimSuffix(u.Path, "/")
+	}
+
+	ownHosts := make([]string, 0, len(chainCfg.OwnDomains))
+	for _, domain := range chainCfg.OwnDomains {
+		ownHosts = append(ownHosts, strings.ToLower(strings.TrimSpace(domain)))
+	}
+
+	externalHosts := make([]string, 0, len(chainCfg.ExternalDomains))
+	for _, domain := range chainCfg.ExternalDomains {
+		externalHosts = append(externalHosts, strings.ToLower(strings.TrimSpace(domain)))
+	}
+
+	t.chain = chain{
+		siteHost:      siteHost,
+		sitePath:      sitePath,
+		ownHosts:      ownHosts,
+		externalHosts: externalHosts,
+		collapse:      chainCfg.Collapse,
+		maxHops:       chainCfg.MaxHops,
+		client: &http.Client{
+			Timeout: time.Duration(chainCfg.Timeout) * time.Second,
+			// 只读取一跳，由调用方逐跳跟随
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// chainCheck 检查目标地址是否形成循环跳转
+// 链路未折叠时返回原地址，折叠时返回最终目标地址
+func (t *ShortenLogic) chainCheck(ctx context.Context, code string, target string) (int, string) {
+	final, err := t.chainResolve(ctx, code, target)
+	switch {
+	case errors.Is(err, errRedirectLoop):
+		return ecodes.ErrCodeRedirectLoop, ""
+	case errors.Is(err, errRedirectChainTooLong):
+		return ecodes.ErrCodeRedirectChainTooLong, ""
+	case err != nil:
+		return t.logError(ecodes.ErrCodeDatabaseError, err), ""
+	}
+
+	if t.chain.collapse {
+		return ecodes.ErrCodeSuccess, final
+	}
+	return ecodes.ErrCodeSuccess, target
+}
+
+// chainResolve 沿跳转链路解析最终目标地址
+// 本站短链接通过数据库解析，外部短网址服务通过 HTTP 逐跳跟随
+func (t *ShortenLogic) chainResolve(ctx context.Context, code string, target string) (string, error) {
+	visitedCodes := map[string]bool{code: true}
+	visitedURLs := map[string]bool{}
+	current := target
+
+	for range t.chain.maxHops {
+		if visitedURLs[current] {
+			return "", errRedirectLoop
+		}
+		visitedURLs[current] = true
+
+		u, err := url.Parse(current)
+		if err != nil {
+			return current, nil
+		}
+
+		if nextCode, ok := t.chainOwnCode(u); ok {
+			if visitedCodes[nextCode] {
+				return "", errRedirectLoop
+			}
+			visitedCodes[nextCode] = true
+
+			var next model.Url
+			if err := t.db.Where("short_code = ?", nextCode).First(&next).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return current, nil // 指向不存在的本站短码，不构成循环
+				}
+				return "", err
+			}
+			// 与跳转一致，工作区域名仅解析本工作区的短链接
+			if workspaceID, ok := t.workspaceByHost(u.Host); ok && workspaceID != next.WorkspaceID {
+				return current, nil
+			}
+			current = next.OriginalURL
+			continue
+		}
+
+		if t.chainIsExternal(u) {
+			location, ok := t.chainFollow(ctx, current)
+			if !ok {
+				return current, nil
+			}
+			current = location
+			continue
+		}
+
+		return current, nil
+	}
+
+	return "", errRedirectChainTooLong
+}
+
+// chainOwnCode 判断地址是否为本站短链接，是则返回短码
+// 本站域名包括 site_url、chain.own_domains 与工作区绑定的域名，工作区域名可随时变更，每次检测时读取
+func (t *ShortenLogic) chainOwnCode(u *url.URL) (string, bool) {
+	var code string
+	switch {
+	case t.chain.siteHost != "" && hostMatch(u, []string{t.chain.siteHost}):
+		// 短链接为 site_url 加短码，site_url 可以带路径前缀
+		rest, ok := strings.CutPrefix(u.Path, t.chain.sitePath+"/")
+		if !ok {
+			return "", false
+		}
+		code = rest
+	case hostMatch(u, t.chain.ownHosts):
+		code = strings.TrimPrefix(u.Path, "/")
+	case t.chainWorkspaceHost(u):
+		code = strings.TrimPrefix(u.Path, "/")
+	default:
+		return "", false
+	}
+
+	code = strings.TrimSuffix(code, "/")
+	if code == "" || strings.Contains(code, "/") {
+		return "", false
+	}
+	return code, true
+}
+
+// chainWorkspaceHost 判断地址的主机是否为工作区绑定的域名
+func (t *ShortenLogic) chainWorkspaceHost(u *url.URL) bool {
+	_, ok := t.workspaceByHost(u.Host)
+	return ok
+}
+
+// chainIsExternal 判断地址是否属于外部短网址服务
+func (t *ShortenLogic) chainIsExternal(u *url.URL) bool {
+	return hostMatch(u, t.chain.externalHosts)
+}
+
+// chainFollow 请求外部短网址并返回跳转地址
+func (t *ShortenLogic) chainFollow(ctx context.Context, target string) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, t.chain.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return "", false
+	}
+	resp, err := t.chain.client.Do(req)
+	if err != nil {
+		return "", false
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < http.StatusMultipleChoices || resp.StatusCode >= http.StatusBadRequest {
+		return "", false
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return "", false
+	}
+	return location.String(), true
+}
+
+// ShortenScanLoops 扫描所有短链接，返回形成循环或链路过长的短链接
+// ctx 结束时停止扫描，如客户端断开连接
+func (t *ShortenLogic) ShortenScanLoops(ctx context.Context) (int, []types.ResShortenLoop) {
+	results := make([]types.ResShortenLoop, 0)
+
+	var batch []model.Url
+	err := t.db.Model(&model.Url{}).FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+		for _, item := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			// 仅检查指向本站或外部短网址服务的链接
+			u, err := url.Parse(item.OriginalURL)
+			if err != nil {
+				continue
+			}
+			if _, ok := t.chainOwnCode(u); !ok && !t.chainIsExternal(u) {
+				continue
+			}
+
+			errCode, _ := t.chainCheck(ctx, item.ShortCode, item.OriginalURL)
+			if errCode == ecodes.ErrCodeDatabaseError {
+				return errors.New(ecodes.GetErrCodeMessage(errCode))
+			}
+			if errCode != ecodes.ErrCodeSuccess {
+				results = append(results, types.ResShortenLoop{
+					Code:        item.ShortCode,
+					OriginalURL: item.OriginalURL,
+					ErrCode:     errCode,
+					ErrInfo:     ecodes.GetErrCodeMessage(errCode),
+				})
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), results
+	}
+
+	return ecodes.ErrCodeSuccess, results
+}
+
+// hostMatch 主机是否在列表中，列表项不带端口时忽略端口比较
+func hostMatch(u *url.URL, hosts []string) bool {
+	host := strings.ToLower(u.Host)
+	hostname := strings.ToLower(u.Hostname())
+	for _, item := range hosts {
+		if item == "" {
+			continue
+		}
+		if host == item || (!strings.Contains(item, ":") && hostname == item) {
+			return true
+		}
+	}
+	return false
+}
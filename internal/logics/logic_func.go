@@ -1,6 +1,16 @@
 package logics
 
-import "strings"
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
 
 // simplifyDeviceType 将具体设备型号转换为通用类型（mobile/pc/tablet）
 func simplifyDeviceType(device string) string {
@@ -17,3 +27,62 @@ func simplifyDeviceType(device string) string {
 		return "desktop"
 	}
 }
+
+// timePtrToStr 格式化可为空的时间，为空时返回空字符串
+func timePtrToStr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return utils.TimeToStr(*t)
+}
+
+// ownedBy 限定为用户自己的数据（按 user_id），管理员不受限制
+func ownedBy(user types.Principal) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if user.IsAdmin() {
+			return db
+		}
+		return db.Where("user_id = ?", user.UserID)
+	}
+}
+
+// ofWorkspace 限定为当前工作区的数据（按 workspace_id）
+func ofWorkspace(user types.Principal) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("workspace_id = ?", user.WorkspaceID)
+	}
+}
+
+// inWorkspace 限定为当前工作区的数据，普通成员仅限自己的数据
+func inWorkspace(user types.Principal) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("workspace_id = ?", user.WorkspaceID)
+		if user.IsWorkspaceAdmin() {
+			return db
+		}
+		return db.Where("user_id = ?", user.UserID)
+	}
+}
+
+// workspaceHistories 限定为当前工作区的访问记录，普通成员仅限自己短链接的访问记录
+func workspaceHistories(user types.Principal) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("workspace_id = ?", user.WorkspaceID)
+		if user.IsWorkspaceAdmin() {
+			return db
+		}
+		return db.Where("url_id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Model(&model.Url{}).Select("id").Where("workspace_id = ? AND user_id = ?", user.WorkspaceID, user.UserID))
+	}
+}
+
+// truncateStr 截断字符串至最多 n 个字节，不拆分多字节字符
+func truncateStr(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,106 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/health"
+)
+
+// HealthLogic 目标地址健康检测逻辑层
+type HealthLogic struct {
+	logic
+	checker   *health.Checker
+	batchSize int
+}
+
+// NewHealthLogic 创建目标地址健康检测逻辑层
+func NewHealthLogic(checker *health.Checker, batchSize int) *HealthLogic {
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	t := &HealthLogic{
+		checker:   checker,
+		batchSize: batchSize,
+	}
+	t.init()
+	return t
+}
+
+// HealthRun 周期性检测所有短链接，直到 ctx 结束
+func (t *HealthLogic) HealthRun(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.HealthCheckAll(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			slog.ErrorContext(ctx, "health check failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// HealthCheckAll 分批检测所有短链接的目标地址并保存结果
+func (t *HealthLogic) HealthCheckAll(ctx context.Context) error {
+	var batch []model.Url
+	return t.db.Model(&model.Url{}).FindInBatches(&batch, t.batchSize, func(tx *gorm.DB, _ int) error {
+		targets := make([]health.Target, 0, len(batch))
+		for _, item := range batch {
+			// 应用深度链接检测其网页回退地址
+			target := item.OriginalURL
+			if item.FallbackURL != "" {
+				target = item.FallbackURL
+			}
+			targets = append(targets, health.Target{ID: item.ID, URL: target})
+		}
+
+		results := t.checker.Check(ctx, targets)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for i, result := range results {
+			if err := t.saveResult(&batch[i], result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// saveResult 保存检测结果并刷新缓存
+func (t *HealthLogic) saveResult(item *model.Url, result health.Result) error {
+	item.HealthCode = result.StatusCode
+	// 被策略禁止的目标单独记录，不计入失效链接
+	if result.Blocked {
+		item.HealthCode = model.HealthCodeBlocked
+	}
+	item.HealthLatency = result.Latency.Milliseconds()
+	item.HealthCheckedAt = &result.CheckedAt
+
+	// 不修改 updated_at
+	err := t.db.Model(&model.Url{}).Where("id = ?", item.ID).UpdateColumns(map[string]any{
+		"health_code":       item.HealthCode,
+		"health_latency":    item.HealthLatency,
+		"health_checked_at": item.HealthCheckedAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := t.shortenCacheSet(*item); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return err
+	}
+	return nil
+}
@@ -1,21 +1,33 @@
 package logics
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/ua-parser/uap-go/uaparser"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"go.xoder.cn/shortener/internal/dal/db/model"
 	"go.xoder.cn/shortener/internal/ecodes"
 	"go.xoder.cn/shortener/internal/pkgs/geoip"
+	"go.xoder.cn/shortener/internal/pkgs/metrics"
 	"go.xoder.cn/shortener/internal/shared"
 	"go.xoder.cn/shortener/internal/types"
 	"go.xoder.cn/shortener/internal/utils"
 )
 
+// historyPending 尚未写入的访问记录，关闭服务时等待写入完成
+var historyPending sync.WaitGroup
+
+// historyUAParser 用户代理解析器，加载规则的开销较大，全局共用
+var historyUAParser = sync.OnceValue(uaparser.NewFromSaved)
+
 // HistoryLogic 历史记录逻辑层
 type HistoryLogic struct {
 	logic
@@ -31,13 +43,19 @@ func NewHistoryLogic() *HistoryLogic {
 	return t
 }
 
+// WithContext 返回使用请求上下文的副本
+func (t *HistoryLogic) WithContext(ctx context.Context) *HistoryLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
 // HistoryAdd 添加历史记录
 func (t *HistoryLogic) HistoryAdd(params types.HistoryParams) error {
 	nowTime := time.Now().Local()
 
 	// 解析用户代理
-	uaParser := uaparser.NewFromSaved()
-	client := uaParser.Parse(params.UserAgent)
+	client := historyUAParser().Parse(params.UserAgent)
 	deviceType := cases.Title(language.English).String(
 		simplifyDeviceType(client.Device.ToString()),
 	)
@@ -53,7 +71,10 @@ func (t *HistoryLogic) HistoryAdd(params types.HistoryParams) error {
 
 	if t.geoip != nil && t.geoip.Enabled {
 		if ipByte, err := t.geoip.IPStr2Byte(params.IPAddress); err == nil {
-			if ipInfo, err := t.geoip.Search(ipByte); err == nil {
+			start := time.Now()
+			ipInfo, err := t.geoip.Search(ipByte)
+			metrics.GeoIPDuration.Observe(time.Since(start).Seconds())
+			if err == nil {
 				ipData := t.geoip.Parse(ipInfo)
 				geoInfo.Country = ipData.Country
 				geoInfo.Region = ipData.Region
@@ -65,43 +86,90 @@ func (t *HistoryLogic) HistoryAdd(params types.HistoryParams) error {
 	}
 
 	history := model.History{
-		UrlID:      params.URLID,
-		ShortCode:  params.ShortCode,
-		IPAddress:  params.IPAddress,
-		UserAgent:  params.UserAgent,
-		Referer:    params.Referer,
-		Country:    geoInfo.Country,
-		Region:     geoInfo.Region,
-		Province:   geoInfo.Province,
-		City:       geoInfo.City,
-		ISP:        geoInfo.ISP,
-		DeviceType: deviceType,
-		OS:         client.Os.ToString(),
-		Browser:    client.UserAgent.ToString(),
-		AccessedAt: nowTime,
-		CreatedAt:  nowTime,
+		WorkspaceID: params.WorkspaceID,
+		UrlID:       params.URLID,
+		ShortCode:   params.ShortCode,
+		IPAddress:   params.IPAddress,
+		UserAgent:   params.UserAgent,
+		Referer:     params.Referer,
+		Country:     geoInfo.Country,
+		Region:      geoInfo.Region,
+		Province:    geoInfo.Province,
+		City:        geoInfo.City,
+		ISP:         geoInfo.ISP,
+		DeviceType:  deviceType,
+		OS:          client.Os.ToString(),
+		Browser:     client.UserAgent.ToString(),
+		AccessedAt:  nowTime,
+		CreatedAt:   nowTime,
 	}
 	// log.Printf("history: %+v\n", history)
 
-	return t.db.Create(&history).Error
+	if err := t.db.Create(&history).Error; err != nil {
+		return err
+	}
+	return t.historyUsageAdd(params.WorkspaceID, nowTime)
+}
+
+// HistoryAddAsync 异步添加历史记录
+func (t *HistoryLogic) HistoryAddAsync(params types.HistoryParams) {
+	historyPending.Add(1)
+	metrics.HistoryQueue.Inc()
+	go func() {
+		defer historyPending.Done()
+		defer metrics.HistoryQueue.Dec()
+		if err := t.HistoryAdd(params); err != nil {
+			slog.ErrorContext(t.ctx, "add history failed", "err", err)
+		}
+	}()
+}
+
+// HistoryFlush 等待异步添加的历史记录写入完成
+func HistoryFlush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		historyPending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// historyUsageAdd 累加工作区本月访问次数
+func (t *HistoryLogic) historyUsageAdd(workspaceID int64, nowTime time.Time) error {
+	usage := model.WorkspaceUsage{
+		WorkspaceID: workspaceID,
+		Period:      usagePeriod(nowTime),
+		Clicks:      1,
+	}
+	return t.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]any{"clicks": gorm.Expr("workspace_usages.clicks + 1")}),
+	}).Create(&usage).Error
 }
 
 // HistoryDeleteAll 删除所有历史记录
-func (t *HistoryLogic) HistoryDeleteAll(ids []string) int {
-	if res := t.db.Where("id in (?)", ids).Delete(&model.History{}); res.Error != nil {
-		return ecodes.ErrCodeDatabaseError
+func (t *HistoryLogic) HistoryDeleteAll(user types.Principal, ids []string) int {
+	if res := t.db.Scopes(workspaceHistories(user)).Where("id in (?)", ids).Delete(&model.History{}); res.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, res.Error)
 	}
 
 	return ecodes.ErrCodeSuccess
 }
 
 // HistoryAll 获取所有短链接
-func (t *HistoryLogic) HistoryAll(reqQuery types.ReqQueryHistory) (int, []types.ResHistory, types.ResPage) {
+func (t *HistoryLogic) HistoryAll(user types.Principal, reqQuery types.ReqQueryHistory) (int, []types.ResHistory, types.ResPage) {
 	results := make([]types.ResHistory, 0)
 	pageInfo := types.ResPage{}
 
 	// 查询数据库
 	query := t.db.Model(&model.History{}).
+		Scopes(workspaceHistories(user)).
 		Order(fmt.Sprintf("%s %s", reqQuery.SortBy, reqQuery.Order))
 
 	if reqQuery.Code != "" {
@@ -116,7 +184,7 @@ func (t *HistoryLogic) HistoryAll(reqQuery types.ReqQueryHistory) (int, []types.
 	var total int64
 	query = query.Count(&total)
 	if query.Error != nil {
-		return ecodes.ErrCodeDatabaseError, results, pageInfo
+		return t.logError(ecodes.ErrCodeDatabaseError, query.Error), results, pageInfo
 	}
 
 	// 分页查询
@@ -125,7 +193,7 @@ func (t *HistoryLogic) HistoryAll(reqQuery types.ReqQueryHistory) (int, []types.
 		Limit(int(reqQuery.PageSize)).
 		Find(&data)
 	if resDB.Error != nil {
-		return ecodes.ErrCodeDatabaseError, results, pageInfo
+		return t.logError(ecodes.ErrCodeDatabaseError, resDB.Error), results, pageInfo
 	}
 
 	// 页码信息
@@ -0,0 +1,84 @@
+package logics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/pkgs/jwtauth"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// JwtLogic JWT 访问令牌逻辑层
+type JwtLogic struct {
+	logic
+	manager *jwtauth.Manager
+}
+
+// NewJwtLogic 创建 JWT 逻辑层
+func NewJwtLogic() *JwtLogic {
+	t := &JwtLogic{}
+	t.init()
+	t.manager = shared.GlobalJWT
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *JwtLogic) WithContext(ctx context.Context) *JwtLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
+// JwtEnabled 是否可以签发访问令牌
+func (t *JwtLogic) JwtEnabled() bool {
+	return t.manager != nil && t.manager.CanSign()
+}
+
+// JwtSign 为身份签发访问令牌，身份不受权限范围限制时令牌具有全部权限范围
+func (t *JwtLogic) JwtSign(principal types.Principal) (string, time.Time, error) {
+	scopes := principal.Scopes
+	if scopes == nil {
+		scopes = access.Scopes()
+	}
+
+	return t.manager.Sign(jwtauth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: strconv.FormatInt(principal.UserID, 10),
+		},
+		Name:      principal.Username,
+		Role:      principal.Role,
+		Scope:     strings.Join(scopes, " "),
+		SessionID: principal.SessionID,
+	})
+}
+
+// JwtVerify 校验访问令牌，返回其声明的身份
+// 令牌无需查询数据库，签名无效或已过期时返回 false
+func (t *JwtLogic) JwtVerify(token string) (types.Principal, bool, error) {
+	if t.manager == nil {
+		return types.Principal{}, false, nil
+	}
+
+	claims, err := t.manager.Parse(token)
+	if err != nil {
+		return types.Principal{}, false, nil
+	}
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return types.Principal{}, false, nil
+	}
+
+	return types.Principal{
+		UserID:    userID,
+		Username:  claims.Name,
+		Role:      claims.Role,
+		Scopes:    apiKeyScopes(claims.Scope),
+		SessionID: claims.SessionID,
+	}, true, nil
+}
@@ -0,0 +1,114 @@
+package logics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/pkgs/lockout"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// LockoutLogic 登录失败锁定逻辑层
+type LockoutLogic struct {
+	logic
+	user  *lockout.Guard
+	ip    *lockout.Guard
+	audit *AuditLogic
+}
+
+// NewLockoutLogic 创建登录失败锁定逻辑层
+func NewLockoutLogic() *LockoutLogic {
+	t := &LockoutLogic{}
+	t.init()
+	t.user = shared.GlobalLockoutUser
+	t.ip = shared.GlobalLockoutIP
+	t.audit = NewAuditLogic()
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *LockoutLogic) WithContext(ctx context.Context) *LockoutLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	if t.audit != nil {
+		c.audit = t.audit.WithContext(ctx)
+	}
+	return &c
+}
+
+// LockoutAttempt 在校验凭据前登记一次尝试，返回剩余的锁定时长，为 0 时允许校验，username 为空时仅登记 IP
+// 尝试先计为失败，并发请求各自占用一次计数，超过允许次数的请求在校验前即被拒绝；
+// 校验通过或未校验凭据时须调用 LockoutRelease 撤销
+func (t *LockoutLogic) LockoutAttempt(username string, ip string) time.Duration {
+	if t.user == nil {
+		return 0
+	}
+
+	now := time.Now()
+	wait := t.ip.Check(ip, now)
+	if username != "" {
+		wait = max(wait, t.user.Check(username, now))
+	}
+	// 锁定期间的请求不计数
+	if wait > 0 {
+		return wait
+	}
+
+	if username != "" {
+		if delay := t.user.Fail(username, now); delay > 0 {
+			t.lockoutAudit("user", username, username, ip, delay)
+			wait = delay
+		}
+	}
+	if delay := t.ip.Fail(ip, now); delay > 0 {
+		t.lockoutAudit("ip", ip, username, ip, delay)
+		wait = max(wait, delay)
+	}
+	return wait
+}
+
+// LockoutRelease 撤销 LockoutAttempt 登记的尝试，参数须与登记时一致
+func (t *LockoutLogic) LockoutRelease(username string, ip string) {
+	if t.user == nil {
+		return
+	}
+
+	if username != "" {
+		t.user.Undo(username)
+	}
+	t.ip.Undo(ip)
+}
+
+// LockoutFail 记录用户名的一次登录失败，用于校验前无法确定用户名的两步验证，超过允许次数后锁定并记录审计事件
+func (t *LockoutLogic) LockoutFail(username string, ip string) {
+	if t.user == nil {
+		return
+	}
+
+	if delay := t.user.Fail(username, time.Now()); delay > 0 {
+		t.lockoutAudit("user", username, username, ip, delay)
+	}
+}
+
+// LockoutReset 登录成功后清除用户名的失败记录，IP 的记录保留至过期
+func (t *LockoutLogic) LockoutReset(username string) {
+	if t.user == nil {
+		return
+	}
+	t.user.Reset(username)
+}
+
+// lockoutAudit 记录锁定的审计事件
+func (t *LockoutLogic) lockoutAudit(targetType string, targetID string, username string, ip string, delay time.Duration) {
+	slog.WarnContext(t.ctx, "login locked", "target_type", targetType, "target_id", targetID, "delay", delay)
+	t.audit.AuditRecord(types.AuditEvent{
+		Actor:      types.Principal{Username: username},
+		Action:     model.AuditLockout,
+		TargetType: targetType,
+		TargetID:   targetID,
+		After:      map[string]any{"seconds": int64(delay / time.Second)},
+	}, ip, "")
+}
@@ -0,0 +1,312 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/sso"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+const (
+	oidcStateLength    = 32 // state 与 nonce 长度
+	oidcVerifierLength = 64 // PKCE code_verifier 长度（43-128）
+)
+
+// OidcState 授权请求的上下文，回调时用于校验
+type OidcState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Redirect string `json:"redirect,omitempty"` // 登录成功后跳转的前端地址
+	Remember bool   `json:"remember,omitempty"`
+}
+
+// OidcLogic OIDC 单点登录逻辑层
+type OidcLogic struct {
+	logic
+	config  *types.CfgOIDC
+	client  *sso.Client
+	session *SessionLogic
+	audit   *AuditLogic
+}
+
+// NewOidcLogic 创建 OIDC 逻辑层
+func NewOidcLogic() *OidcLogic {
+	t := &OidcLogic{}
+	t.init()
+	t.config = shared.GlobalOIDC
+	t.client = shared.GlobalSSO
+	t.session = NewSessionLogic()
+	t.audit = NewAuditLogic()
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *OidcLogic) WithContext(ctx context.Context) *OidcLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	if t.session != nil {
+		c.session = t.session.WithContext(ctx)
+	}
+	if t.audit != nil {
+		c.audit = t.audit.WithContext(ctx)
+	}
+	return &c
+}
+
+// OidcEnabled 是否开启 OIDC 登录
+func (t *OidcLogic) OidcEnabled() bool {
+	return t.config != nil
+}
+
+// OidcPasswordLogin 是否允许账号密码登录
+func (t *OidcLogic) OidcPasswordLogin() bool {
+	return t.config == nil || t.config.PasswordLogin
+}
+
+// OidcRedirectAllowed 登录成功后的跳转地址是否在允许列表中
+// 协议与主机（含端口）须完全一致，允许的地址带路径时仅匹配该路径及其下级路径
+func (t *OidcLogic) OidcRedirectAllowed(redirect string) bool {
+	u, err := url.Parse(redirect)
+	if err != nil || u.Host == "" || u.User != nil || u.Fragment != "" {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return false
+	}
+	// 不接受 . 或 .. 路径段，避免跳出允许的路径
+	if u.Path != "" && strings.TrimSuffix(path.Clean(u.Path), "/") != strings.TrimSuffix(u.Path, "/") {
+		return false
+	}
+
+	for _, item := range t.config.AllowedRedirects {
+		allowed, err := url.Parse(strings.TrimSpace(item))
+		if err != nil || allowed.Host == "" {
+			continue
+		}
+		if strings.ToLower(allowed.Scheme) != scheme || !strings.EqualFold(allowed.Host, u.Host) {
+			continue
+		}
+		prefix := strings.TrimSuffix(allowed.Path, "/")
+		if prefix == "" || u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// OidcStart 生成授权地址及其上下文
+func (t *OidcLogic) OidcStart(ctx context.Context, redirect string, remember bool) (int, string, OidcState) {
+	state := OidcState{
+		State:    utils.GenerateSecret(oidcStateLength),
+		Nonce:    utils.GenerateSecret(oidcStateLength),
+		Verifier: utils.GenerateSecret(oidcVerifierLength),
+		Redirect: redirect,
+		Remember: remember,
+	}
+
+	authURL, err := t.client.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		slog.ErrorContext(ctx, "oidc start failed", "err", err)
+		return ecodes.ErrCodeUserAuthFailed, "", OidcState{}
+	}
+	return ecodes.ErrCodeSuccess, authURL, state
+}
+
+// OidcCallback 使用授权码完成登录，将身份提供方的用户映射为本地用户并创建登录会话
+func (t *OidcLogic) OidcCallback(ctx context.Context, code string, state OidcState, ip string, userAgent string) (int, string, time.Time) {
+	claims, err := t.client.Exchange(ctx, code, state.Verifier, state.Nonce)
+	if err != nil {
+		slog.ErrorContext(ctx, "oidc callback failed", "err", err)
+		return ecodes.ErrCodeUserAuthFailed, "", time.Time{}
+	}
+
+	sub, _ := claims["sub"].(string)
+	username := oidcClaimString(claims, t.config.UsernameClaim)
+	if username == "" {
+		username = oidcClaimString(claims, "email")
+	}
+	if sub == "" || username == "" || len(username) > 64 {
+		return ecodes.ErrCodeUserAuthFailed, "", time.Time{}
+	}
+
+	// 按角色声明映射角色并限制可登录的用户
+	values := oidcClaimValues(claims, t.config.RoleClaim)
+	isAdmin := oidcContainsAny(values, t.config.AdminValues)
+	if len(t.config.AllowedValues) > 0 && !isAdmin && !oidcContainsAny(values, t.config.AllowedValues) {
+		return ecodes.ErrCodeUserPermissionDenied, "", time.Time{}
+	}
+	role := access.RoleUser
+	if isAdmin {
+		role = access.RoleAdmin
+	}
+
+	errCode, user := t.oidcUser(sub, username, role)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, "", time.Time{}
+	}
+
+	token, session, err := t.session.SessionCreate(user, model.SessionKindLogin, nil, state.Remember, ip, userAgent)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), "", time.Time{}
+	}
+
+	t.audit.AuditRecord(types.AuditEvent{
+		Actor:      types.Principal{UserID: user.ID, Username: user.Username, Role: user.Role},
+		Action:     model.AuditLogin,
+		TargetType: "user",
+		TargetID:   strconv.FormatInt(user.ID, 10),
+		After:      map[string]any{"method": "oidc", "token_type": "session"},
+	}, ip, userAgent)
+
+	return ecodes.ErrCodeSuccess, token, session.ExpiresAt
+}
+
+// oidcUser 查找、关联或创建本地用户，配置了角色声明时同步角色
+func (t *OidcLogic) oidcUser(sub string, username string, role string) (int, model.User) {
+	var user model.User
+	err := t.db.Where("oidc_sub = ?", sub).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), user
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = t.db.Where("username = ?", username).First(&user).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return t.logError(ecodes.ErrCodeDatabaseError, err), user
+		}
+
+		nowTime := time.Now().Local()
+		if err == nil {
+			// 同名本地用户仅在允许时关联，避免身份提供方的用户接管本地账号
+			if !t.config.LinkExisting || user.OidcSub != nil {
+				return ecodes.ErrCodeUserExists, user
+			}
+			user.OidcSub = &sub
+			user.UpdatedAt = nowTime
+			if err := t.db.Model(&user).Updates(map[string]any{
+				"oidc_sub":   sub,
+				"updated_at": user.UpdatedAt,
+			}).Error; err != nil {
+				return t.logError(ecodes.ErrCodeDatabaseError, err), user
+			}
+		} else {
+			if !t.config.AutoCreate {
+				return ecodes.ErrCodeUserNotFound, user
+			}
+			// 通过 OIDC 创建的用户使用随机密码，无法通过账号密码登录
+			hash, err := utils.HashPassword(utils.GenerateSecret(32))
+			if err != nil {
+				return t.logError(ecodes.ErrCodeSystemInternalError, err), user
+			}
+			user = model.User{
+				Username:  username,
+				Password:  hash,
+				Role:      role,
+				OidcSub:   &sub,
+				CreatedAt: nowTime,
+				UpdatedAt: nowTime,
+			}
+			err = t.db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+				return workspaceJoinDefault(tx, user.ID)
+			})
+			if err != nil {
+				return t.logError(ecodes.ErrCodeDatabaseError, err), user
+			}
+			return ecodes.ErrCodeSuccess, user
+		}
+	}
+
+	if t.config.RoleClaim != "" && user.Role != role {
+		if errCode := t.oidcSyncRole(&user, role); errCode != ecodes.ErrCodeSuccess {
+			return errCode, user
+		}
+	}
+
+	return ecodes.ErrCodeSuccess, user
+}
+
+// oidcSyncRole 同步角色，角色变更后注销用户已有的会话；不会降级最后一个管理员
+func (t *OidcLogic) oidcSyncRole(user *model.User, role string) int {
+	updatedAt := time.Now().Local()
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if user.Role == access.RoleAdmin {
+			if err := userCheckLastAdmin(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Model(user).Updates(map[string]any{
+			"role":       role,
+			"updated_at": updatedAt,
+		}).Error
+	})
+	if errors.Is(err, errLastAdmin) {
+		slog.WarnContext(t.ctx, "oidc keeps role of last admin", "username", user.Username)
+		return ecodes.ErrCodeSuccess
+	}
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+	user.Role = role
+	user.UpdatedAt = updatedAt
+
+	if err := t.session.SessionRevokeUser(user.ID); err != nil {
+		slog.ErrorContext(t.ctx, "revoke user sessions failed", "err", err)
+	}
+	return ecodes.ErrCodeSuccess
+}
+
+// oidcClaimString 读取字符串类型的声明
+func oidcClaimString(claims map[string]any, name string) string {
+	value, _ := claims[name].(string)
+	return strings.TrimSpace(value)
+}
+
+// oidcClaimValues 读取字符串或字符串数组类型的声明
+func oidcClaimValues(claims map[string]any, name string) []string {
+	if name == "" {
+		return nil
+	}
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// oidcContainsAny values 是否包含 targets 中的任一值
+func oidcContainsAny(values []string, targets []string) bool {
+	for _, target := range targets {
+		if slices.Contains(values, target) {
+			return true
+		}
+	}
+	return false
+}
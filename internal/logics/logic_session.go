@@ -0,0 +1,408 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+const (
+	sessionTokenLength = 48         // 登录令牌长度
+	sessionCachePrefix = "session:" // 会话缓存 key 前缀
+
+	// sessionTouchInterval 顺延过期时间的最小间隔，避免每次请求都写库
+	sessionTouchInterval = time.Minute
+	// sessionChallengeTTL 两步验证挑战的有效期
+	sessionChallengeTTL = 5 * time.Minute
+)
+
+// sessionCache 缓存中保存的会话信息
+type sessionCache struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	Kind       string    `json:"kind"`
+	Scopes     string    `json:"scopes"`
+	Remember   bool      `json:"remember"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// principal 会话对应的身份，登录会话不受权限范围限制
+func (t sessionCache) principal() types.Principal {
+	principal := types.Principal{
+		UserID:    t.UserID,
+		Username:  t.Username,
+		Role:      t.Role,
+		SessionID: t.ID,
+	}
+	if t.Kind == model.SessionKindRefresh {
+		principal.Scopes = apiKeyScopes(t.Scopes)
+	}
+	return principal
+}
+
+// SessionLogic 登录会话逻辑层
+type SessionLogic struct {
+	logic
+	config *types.CfgSession
+}
+
+// NewSessionLogic 创建登录会话逻辑层
+func NewSessionLogic() *SessionLogic {
+	t := &SessionLogic{}
+	t.init()
+	t.config = shared.GlobalSession
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *SessionLogic) WithContext(ctx context.Context) *SessionLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
+// SessionCreate 为用户创建指定类型的会话，返回令牌与会话
+// 令牌仅返回一次，数据库中只保存其哈希；scopes 仅用于刷新令牌
+func (t *SessionLogic) SessionCreate(user model.User, kind string, scopes []string, remember bool, ip string, userAgent string) (string, model.Session, error) {
+	ttl := t.sessionTTL(remember)
+	if kind == model.SessionKindMFA {
+		ttl = sessionChallengeTTL
+	}
+
+	token := utils.GenerateSecret(sessionTokenLength)
+	nowTime := time.Now().Local()
+	session := model.Session{
+		UserID:     user.ID,
+		TokenHash:  utils.HashSecret(token),
+		Kind:       kind,
+		Scopes:     strings.Join(scopes, " "),
+		Remember:   remember,
+		IP:         ip,
+		UserAgent:  truncateStr(userAgent, 255),
+		ExpiresAt:  nowTime.Add(ttl),
+		LastSeenAt: nowTime,
+		CreatedAt:  nowTime,
+	}
+	if err := t.db.Create(&session).Error; err != nil {
+		return "", model.Session{}, err
+	}
+
+	t.sessionCacheSet(session.TokenHash, sessionCache{
+		ID:         session.ID,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		Kind:       kind,
+		Scopes:     session.Scopes,
+		Remember:   remember,
+		ExpiresAt:  session.ExpiresAt,
+		LastSeenAt: session.LastSeenAt,
+	})
+
+	return token, session, nil
+}
+
+// SessionVerify 校验登录令牌，返回其对应的身份，并顺延会话的过期时间
+// 令牌不存在、已过期、不是登录令牌或所属用户已删除时返回 false
+func (t *SessionLogic) SessionVerify(token string) (types.Principal, bool, error) {
+	tokenHash := utils.HashSecret(token)
+
+	data, ok, err := t.sessionCheck(tokenHash, model.SessionKindLogin)
+	if err != nil || !ok {
+		return types.Principal{}, false, err
+	}
+
+	nowTime := time.Now().Local()
+	if nowTime.Sub(data.LastSeenAt) >= sessionTouchInterval {
+		data.LastSeenAt = nowTime
+		data.ExpiresAt = nowTime.Add(t.sessionTTL(data.Remember))
+		if err := t.db.Model(&model.Session{}).Where("id = ?", data.ID).UpdateColumns(map[string]any{
+			"expires_at":   data.ExpiresAt,
+			"last_seen_at": data.LastSeenAt,
+		}).Error; err != nil {
+			slog.ErrorContext(t.ctx, "update session last seen failed", "err", err)
+		} else {
+			t.sessionCacheSet(tokenHash, data)
+		}
+	}
+
+	return data.principal(), true, nil
+}
+
+// SessionRefresh 校验刷新令牌并轮换，旧令牌立即失效，会话过期时间顺延
+// 返回新的刷新令牌、其对应的身份与新的过期时间
+func (t *SessionLogic) SessionRefresh(token string) (string, types.Principal, time.Time, bool, error) {
+	tokenHash := utils.HashSecret(token)
+
+	data, ok, err := t.sessionCheck(tokenHash, model.SessionKindRefresh)
+	if err != nil || !ok {
+		return "", types.Principal{}, time.Time{}, false, err
+	}
+
+	newToken := utils.GenerateSecret(sessionTokenLength)
+	newTokenHash := utils.HashSecret(newToken)
+	nowTime := time.Now().Local()
+	data.LastSeenAt = nowTime
+	data.ExpiresAt = nowTime.Add(t.sessionTTL(data.Remember))
+
+	// 以旧令牌哈希为条件，并发刷新时只有一个请求成功
+	res := t.db.Model(&model.Session{}).Where("id = ? AND token_hash = ?", data.ID, tokenHash).UpdateColumns(map[string]any{
+		"token_hash":   newTokenHash,
+		"expires_at":   data.ExpiresAt,
+		"last_seen_at": data.LastSeenAt,
+	})
+	if res.Error != nil {
+		return "", types.Principal{}, time.Time{}, false, res.Error
+	}
+	t.sessionCacheDelete(tokenHash)
+	if res.RowsAffected == 0 {
+		return "", types.Principal{}, time.Time{}, false, nil
+	}
+	t.sessionCacheSet(newTokenHash, data)
+
+	return newToken, data.principal(), data.ExpiresAt, true, nil
+}
+
+// SessionTake 取出指定类型的会话，会话随即删除，仅能使用一次
+// 令牌不存在或已过期时返回 false
+func (t *SessionLogic) SessionTake(token string, kind string) (model.Session, bool, error) {
+	tokenHash := utils.HashSecret(token)
+
+	var session model.Session
+	if err := t.db.Where("token_hash = ? AND kind = ?", tokenHash, kind).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, false, nil
+		}
+		return session, false, err
+	}
+
+	// 并发使用时只有一个请求成功
+	res := t.db.Where("id = ? AND token_hash = ?", session.ID, tokenHash).Delete(&model.Session{})
+	if res.Error != nil {
+		return session, false, res.Error
+	}
+	t.sessionCacheDelete(tokenHash)
+	if res.RowsAffected == 0 || !session.ExpiresAt.After(time.Now()) {
+		return session, false, nil
+	}
+
+	return session, true, nil
+}
+
+// SessionRevoke 注销当前用户的指定会话
+func (t *SessionLogic) SessionRevoke(user types.Principal, id int64) int {
+	var session model.Session
+	if err := t.db.Where("id = ? AND user_id = ?", id, user.UserID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeNotFound
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+
+	if err := t.db.Delete(&session).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+	t.sessionCacheDelete(session.TokenHash)
+
+	return ecodes.ErrCodeSuccess
+}
+
+// SessionRevokeOthers 注销当前用户除当前会话外的所有会话
+func (t *SessionLogic) SessionRevokeOthers(user types.Principal) int {
+	if err := t.sessionRevoke(t.db.Where("user_id = ? AND id <> ?", user.UserID, user.SessionID)); err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+	return ecodes.ErrCodeSuccess
+}
+
+// SessionRevokeUser 注销用户的所有会话
+func (t *SessionLogic) SessionRevokeUser(userID int64) error {
+	return t.sessionRevoke(t.db.Where("user_id = ?", userID))
+}
+
+// SessionAll 获取当前用户未过期的会话列表
+func (t *SessionLogic) SessionAll(user types.Principal, reqQuery types.ReqQuery) (int, []types.ResSession, types.ResPage) {
+	results := make([]types.ResSession, 0)
+	pageInfo := types.ResPage{}
+
+	query := t.db.Model(&model.Session{}).
+		Where("user_id = ? AND kind <> ? AND expires_at > ?", user.UserID, model.SessionKindMFA, time.Now().Local()).
+		Order(fmt.Sprintf("%s %s", reqQuery.SortBy, reqQuery.Order))
+
+	// 计算总条数
+	var total int64
+	query = query.Count(&total)
+	if query.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, query.Error), results, pageInfo
+	}
+
+	// 分页查询
+	data := make([]model.Session, 0)
+	resDB := query.Offset(int((reqQuery.Page - 1) * reqQuery.PageSize)).
+		Limit(int(reqQuery.PageSize)).
+		Find(&data)
+	if resDB.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, resDB.Error), results, pageInfo
+	}
+
+	// 页码信息
+	pageInfo.Page = reqQuery.Page
+	pageInfo.PageSize = reqQuery.PageSize
+	pageInfo.CurrentCount = resDB.RowsAffected
+	pageInfo.TotalItems = total
+	pageInfo.TotalPages = total / int64(reqQuery.PageSize)
+	if total%int64(reqQuery.PageSize) != 0 {
+		pageInfo.TotalPages++
+	}
+
+	for _, item := range data {
+		results = append(results, types.ResSession{
+			ID:         item.ID,
+			Current:    item.ID == user.SessionID,
+			Kind:       item.Kind,
+			Remember:   item.Remember,
+			IP:         item.IP,
+			UserAgent:  item.UserAgent,
+			ExpiresAt:  utils.TimeToStr(item.ExpiresAt),
+			LastSeenAt: utils.TimeToStr(item.LastSeenAt),
+			CreatedAt:  utils.TimeToStr(item.CreatedAt),
+		})
+	}
+
+	return ecodes.ErrCodeSuccess, results, pageInfo
+}
+
+// SessionPurgeRun 按周期清理过期会话，直到 ctx 取消
+func (t *SessionLogic) SessionPurgeRun(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.db.Where("expires_at <= ?", time.Now().Local()).Delete(&model.Session{}).Error; err != nil {
+			slog.ErrorContext(ctx, "purge expired sessions failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sessionLoad 按令牌哈希读取会话，优先读取缓存
+func (t *SessionLogic) sessionLoad(tokenHash string) (sessionCache, bool, error) {
+	var data sessionCache
+	if cacheData, err := t.cache.Get(t.cache.GetKey(sessionCachePrefix + tokenHash)); err == nil {
+		if err := sonic.Unmarshal([]byte(cacheData), &data); err == nil {
+			return data, true, nil
+		}
+	}
+
+	var session model.Session
+	if err := t.db.Where("token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, false, nil
+		}
+		return data, false, err
+	}
+
+	var user model.User
+	if err := t.db.Where("id = ?", session.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, false, nil
+		}
+		return data, false, err
+	}
+
+	data = sessionCache{
+		ID:         session.ID,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		Kind:       session.Kind,
+		Scopes:     session.Scopes,
+		Remember:   session.Remember,
+		ExpiresAt:  session.ExpiresAt,
+		LastSeenAt: session.LastSeenAt,
+	}
+	t.sessionCacheSet(tokenHash, data)
+
+	return data, true, nil
+}
+
+// sessionCheck 读取会话并检查类型与有效期
+func (t *SessionLogic) sessionCheck(tokenHash string, kind string) (sessionCache, bool, error) {
+	data, ok, err := t.sessionLoad(tokenHash)
+	if err != nil || !ok {
+		return data, false, err
+	}
+	if data.Kind != kind {
+		return data, false, nil
+	}
+	if !data.ExpiresAt.After(time.Now()) {
+		t.sessionCacheDelete(tokenHash)
+		return data, false, nil
+	}
+	return data, true, nil
+}
+
+// sessionRevoke 删除查询条件匹配的会话及其缓存
+func (t *SessionLogic) sessionRevoke(query *gorm.DB) error {
+	var tokenHashes []string
+	if err := query.Model(&model.Session{}).Pluck("token_hash", &tokenHashes).Error; err != nil {
+		return err
+	}
+	if len(tokenHashes) == 0 {
+		return nil
+	}
+
+	if err := t.db.Where("token_hash IN ?", tokenHashes).Delete(&model.Session{}).Error; err != nil {
+		return err
+	}
+	for _, tokenHash := range tokenHashes {
+		t.sessionCacheDelete(tokenHash)
+	}
+	return nil
+}
+
+// sessionCacheSet 缓存会话，缓存有效期与会话一致
+func (t *SessionLogic) sessionCacheSet(tokenHash string, data sessionCache) {
+	ttl := time.Until(data.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+	if err := t.cache.Set(t.cache.GetKey(sessionCachePrefix+tokenHash), data, ttl); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		slog.ErrorContext(t.ctx, "cache session failed", "err", err)
+	}
+}
+
+// sessionCacheDelete 删除会话缓存
+func (t *SessionLogic) sessionCacheDelete(tokenHash string) {
+	if err := t.cache.Delete(t.cache.GetKey(sessionCachePrefix + tokenHash)); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		slog.ErrorContext(t.ctx, "delete session cache failed", "err", err)
+	}
+}
+
+// sessionTTL 会话有效期
+func (t *SessionLogic) sessionTTL(remember bool) time.Duration {
+	if remember {
+		return time.Duration(t.config.RememberTTL) * time.Second
+	}
+	return time.Duration(t.config.TTL) * time.Second
+}
@@ -0,0 +1,48 @@
+package logics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+)
+
+// SettingLogic 系统设置逻辑层
+type SettingLogic struct {
+	logic
+}
+
+// NewSettingLogic 创建系统设置逻辑层
+func NewSettingLogic() *SettingLogic {
+	t := &SettingLogic{}
+	t.init()
+	return t
+}
+
+// SettingGet 获取设置，不存在时返回空字符串
+func (t *SettingLogic) SettingGet(name string) (string, error) {
+	var setting model.Setting
+	if err := t.db.Where("name = ?", name).First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return setting.Value, nil
+}
+
+// SettingSet 保存设置
+func (t *SettingLogic) SettingSet(name string, value string) error {
+	setting := model.Setting{
+		Name:      name,
+		Value:     value,
+		UpdatedAt: time.Now().Local(),
+	}
+	return t.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&setting).Error
+}
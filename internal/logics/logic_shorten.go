@@ -1,15 +1,22 @@
 package logics
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/bytedance/sonic"
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 
 	"go.xoder.cn/shortener/internal/dal/db/model"
 	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/policy"
+	"go.xoder.cn/shortener/internal/pkgs/urlnorm"
+	"go.xoder.cn/shortener/internal/shared"
 	"go.xoder.cn/shortener/internal/types"
 	"go.xoder.cn/shortener/internal/utils"
 )
@@ -17,91 +24,269 @@ import (
 // ShortenLogic 短链接逻辑层
 type ShortenLogic struct {
 	logic
+	chain           chain
+	deepLinkSchemes []string
 }
 
 // NewShortenLogic 创建短链接逻辑层
 func NewShortenLogic() *ShortenLogic {
 	t := &ShortenLogic{}
 	t.init()
+	t.initChain()
+
+	if shared.GlobalDeepLink != nil {
+		t.deepLinkSchemes = shared.GlobalDeepLink.Schemes
+	}
 	return t
 }
 
+// WithContext 返回使用请求上下文的副本
+func (t *ShortenLogic) WithContext(ctx context.Context) *ShortenLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
 // ShortenAdd 添加短链接
-func (t *ShortenLogic) ShortenAdd(code string, originalURL string, describe string) (int, types.ResShorten) {
+func (t *ShortenLogic) ShortenAdd(user types.Principal, code string, originalURL string, fallbackURL string, describe string) (int, types.ResShorten) {
 	result := types.ResShorten{}
 	existingURL := model.Url{}
 
-	// 1. 检查短码是否已存在（使用 GORM 的 Find 直接判断）
+	// 0. 检查目标地址是否符合策略，以及是否形成循环跳转
+	errCode, target, canonical, fallback := t.shortenCheckDestination(code, originalURL, fallbackURL)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, result
+	}
+	originalURL = target
+
+	// 1. 检查工作区的短链接数量配额
+	if exceeded, err := t.workspaceLinksExceeded(user.WorkspaceID); err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), result
+	} else if exceeded {
+		return ecodes.ErrCodeQuotaLinks, result
+	}
+
+	// 2. 检查短码是否已存在（短码全局唯一，使用 GORM 的 Find 直接判断）
 	if err := t.db.Where("short_code = ?", code).First(&existingURL).Error; err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			return ecodes.ErrCodeDatabaseError, result // 数据库查询错误
+			return t.logError(ecodes.ErrCodeDatabaseError, err), result // 数据库查询错误
 		}
 		// 短码不存在，继续流程
 	} else {
 		return ecodes.ErrCodeConflict, result // 短码已存在
 	}
 
-	// 2. 创建新记录
+	// 3. 创建新记录
 	nowTime := time.Now().Local()
 	newURL := model.Url{
-		ShortCode:   code,
-		OriginalURL: originalURL,
-		Describe:    describe,
-		Status:      0,
-		CreatedAt:   nowTime,
-		UpdatedAt:   nowTime,
+		WorkspaceID:   user.WorkspaceID,
+		UserID:        user.UserID,
+		ShortCode:     code,
+		OriginalURL:   originalURL,
+		CanonicalURL:  canonical,
+		CanonicalHash: urlnorm.Hash(canonical),
+		FallbackURL:   fallback,
+		Describe:      describe,
+		Status:        0,
+		CreatedAt:     nowTime,
+		UpdatedAt:     nowTime,
 	}
 
 	if err := t.db.Create(&newURL).Error; err != nil {
-		return ecodes.ErrCodeDatabaseError, result // 创建失败
+		return t.logError(ecodes.ErrCodeDatabaseError, err), result // 创建失败
 	}
 
-	// 3. 缓存短链接
-	if err := t.cache.Set(t.cache.GetKey(newURL.ShortCode), newURL); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
-		return ecodes.ErrCodeCacheError, result // 缓存失败
+	// 4. 缓存短链接
+	if err := t.shortenCacheSet(newURL); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return t.logError(ecodes.ErrCodeCacheError, err), result // 缓存失败
 	}
 
-	// 4. 构造返回结果
+	// 5. 构造返回结果
 	result = types.ResShorten{
-		ID:          newURL.ID,
-		Code:        newURL.ShortCode,
-		ShortURL:    t.GetSiteURL(newURL.ShortCode),
-		OriginalURL: newURL.OriginalURL,
-		Describe:    newURL.Describe,
-		Status:      newURL.Status,
-		CreatedAt:   utils.TimeToStr(nowTime),
-		UpdatedAt:   utils.TimeToStr(nowTime),
+		ID:           newURL.ID,
+		WorkspaceID:  newURL.WorkspaceID,
+		UserID:       newURL.UserID,
+		Code:         newURL.ShortCode,
+		ShortURL:     t.shortURL(newURL.WorkspaceID, newURL.ShortCode),
+		OriginalURL:  newURL.OriginalURL,
+		CanonicalURL: newURL.CanonicalURL,
+		FallbackURL:  newURL.FallbackURL,
+		Describe:     newURL.Describe,
+		Status:       newURL.Status,
+		CreatedAt:    utils.TimeToStr(nowTime),
+		UpdatedAt:    utils.TimeToStr(nowTime),
 	}
 
 	return ecodes.ErrCodeSuccess, result
 }
 
+// shortenCheckDestination 检查目标地址，应用深度链接改为检查其网页回退地址
+// 返回实际保存的目标地址、规范化地址及网页回退地址
+func (t *ShortenLogic) shortenCheckDestination(code string, originalURL string, fallbackURL string) (int, string, string, string) {
+	scheme, ok := t.deepLinkScheme(originalURL)
+	if !ok {
+		errCode, target, canonical := t.shortenCheckTarget(code, originalURL)
+		return errCode, target, canonical, ""
+	}
+
+	if fallbackURL == "" {
+		return ecodes.ErrCodeInvalidParam, "", "", ""
+	}
+	errCode, fallback, _ := t.shortenCheckTarget(code, fallbackURL)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, "", "", ""
+	}
+
+	originalURL = strings.TrimSpace(originalURL)
+	if scheme == "intent" {
+		if errCode, originalURL = t.shortenCheckIntent(code, originalURL); errCode != ecodes.ErrCodeSuccess {
+			return errCode, "", "", ""
+		}
+	}
+
+	canonical := scheme + originalURL[len(scheme):]
+	return ecodes.ErrCodeSuccess, originalURL, canonical, fallback
+}
+
+// shortenCheckIntent 检查 Android intent 链接的目标主机及其中的网页回退地址
+// 回退地址须符合策略，检查后从链接中移除，跳转时改用已检查的 fallback_url
+func (t *ShortenLogic) shortenCheckIntent(code string, intentURL string) (int, string) {
+	u, err := url.Parse(intentURL)
+	if err != nil {
+		return ecodes.ErrCodeInvalidParam, ""
+	}
+	// intent://host/path 在未安装应用时可由浏览器按主机打开
+	if u.Host != "" {
+		if err := shared.GlobalPolicy.Load().Check(t.ctx, "https://"+u.Host); err != nil {
+			return ecodes.ErrCodeDestinationRejected, ""
+		}
+	}
+
+	rest, embedded, err := utils.SplitIntentFallback(intentURL)
+	if err != nil {
+		return ecodes.ErrCodeInvalidParam, ""
+	}
+	if embedded != "" {
+		if !utils.IsURL(embedded) {
+			return ecodes.ErrCodeInvalidParam, ""
+		}
+		if errCode, _, _ := t.shortenCheckTarget(code, embedded); errCode != ecodes.ErrCodeSuccess {
+			return errCode, ""
+		}
+	}
+	return ecodes.ErrCodeSuccess, rest
+}
+
+// deepLinkScheme 判断是否为允许的应用深度链接，是则返回小写协议名
+func (t *ShortenLogic) deepLinkScheme(rawURL string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme == "" {
+		return "", false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme == "http" || scheme == "https" {
+		return "", false
+	}
+	if !slices.Contains(t.deepLinkSchemes, scheme) {
+		return "", false
+	}
+	return scheme, true
+}
+
+// shortenCheckTarget 规范化并检查目标地址
+// 返回实际保存的目标地址（链路折叠时为最终地址）及其规范化形式
+func (t *ShortenLogic) shortenCheckTarget(code string, originalURL string) (int, string, string) {
+	canonical, err := shared.GlobalURLNorm.Load().Normalize(originalURL)
+	if err != nil {
+		return ecodes.ErrCodeInvalidParam, "", ""
+	}
+
+	if err := shared.GlobalPolicy.Load().Check(t.ctx, canonical); err != nil {
+		return ecodes.ErrCodeDestinationRejected, "", ""
+	}
+
+	errCode, final := t.chainCheck(t.ctx, code, canonical)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, "", ""
+	}
+	if final == canonical {
+		return ecodes.ErrCodeSuccess, originalURL, canonical
+	}
+
+	// 链路已折叠，按最终地址重新规范化并检查
+	originalURL = final
+	if canonical, err = shared.GlobalURLNorm.Load().Normalize(final); err != nil {
+		canonical = final
+	}
+	if err := shared.GlobalPolicy.Load().Check(t.ctx, canonical); err != nil {
+		return ecodes.ErrCodeDestinationRejected, "", ""
+	}
+
+	return ecodes.ErrCodeSuccess, originalURL, canonical
+}
+
+// ShortenDedupe 查找用户在当前工作区中规范化地址相同的正常短链接，未开启去重时返回 ErrCodeNotFound
+func (t *ShortenLogic) ShortenDedupe(user types.Principal, originalURL string) (int, types.ResShorten) {
+	if !viper.GetBool("normalize.dedupe") {
+		return ecodes.ErrCodeNotFound, types.ResShorten{}
+	}
+
+	canonical, err := shared.GlobalURLNorm.Load().Normalize(originalURL)
+	if err != nil {
+		return ecodes.ErrCodeInvalidParam, types.ResShorten{}
+	}
+
+	var existingURL model.Url
+	err = t.db.Where("canonical_hash = ? AND canonical_url = ? AND status = ? AND workspace_id = ? AND user_id = ?",
+		urlnorm.Hash(canonical), canonical, model.UrlStatusNormal, user.WorkspaceID, user.UserID).
+		Order("id ASC").
+		First(&existingURL).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeNotFound, types.ResShorten{}
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResShorten{}
+	}
+
+	return t.ShortenFind(user, existingURL.ShortCode)
+}
+
 // ShortenDelete 删除短链接
-func (t *ShortenLogic) ShortenDelete(code string) int {
-	if res := t.db.Where("short_code = ?", code).Delete(&model.Url{}); res.Error != nil {
-		return ecodes.ErrCodeDatabaseError
+func (t *ShortenLogic) ShortenDelete(user types.Principal, code string) int {
+	if res := t.db.Scopes(inWorkspace(user)).Where("short_code = ?", code).Delete(&model.Url{}); res.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, res.Error)
 	} else if res.RowsAffected == 0 {
 		return ecodes.ErrCodeNotFound
 	}
 
 	// 删除缓存
-	if err := t.cache.Delete(t.cache.GetKey(code)); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
-		return ecodes.ErrCodeCacheError // 缓存删除失败
+	if err := t.shortenCacheDelete(user.WorkspaceID, code); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return t.logError(ecodes.ErrCodeCacheError, err) // 缓存删除失败
 	}
 
 	return ecodes.ErrCodeSuccess
 }
 
 // ShortenDeleteAll 删除所有短链接
-func (t *ShortenLogic) ShortenDeleteAll(ids []string) int {
-	if res := t.db.Where("id in (?)", ids).Delete(&model.Url{}); res.Error != nil {
-		return ecodes.ErrCodeDatabaseError
+func (t *ShortenLogic) ShortenDeleteAll(user types.Principal, ids []string) int {
+	// 缓存以短码为键，删除前先查出短码
+	var codes []string
+	if err := t.db.Model(&model.Url{}).Scopes(inWorkspace(user)).Where("id in (?)", ids).Pluck("short_code", &codes).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+	if len(codes) == 0 {
+		return ecodes.ErrCodeSuccess
+	}
+
+	if res := t.db.Scopes(inWorkspace(user)).Where("short_code in (?)", codes).Delete(&model.Url{}); res.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, res.Error)
 	}
 
 	// 删除缓存
-	for _, id := range ids {
-		if err := t.cache.Delete(t.cache.GetKey(id)); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
-			return ecodes.ErrCodeCacheError // 缓存删除失败
+	for _, code := range codes {
+		if err := t.shortenCacheDelete(user.WorkspaceID, code); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+			return t.logError(ecodes.ErrCodeCacheError, err) // 缓存删除失败
 		}
 	}
 
@@ -109,23 +294,47 @@ func (t *ShortenLogic) ShortenDeleteAll(ids []string) int {
 }
 
 // ShortenUpdate 更新短链接
-func (t *ShortenLogic) ShortenUpdate(code string, originalURL string, describe string) (int, types.ResShorten) {
+func (t *ShortenLogic) ShortenUpdate(user types.Principal, code string, originalURL string, fallbackURL string, describe string) (int, types.ResShorten) {
 	result := types.ResShorten{}
 
 	var existingURL model.Url
-	if err := t.db.Where("short_code = ?", code).First(&existingURL).Error; err != nil {
+	if err := t.db.Scopes(inWorkspace(user)).Where("short_code = ?", code).First(&existingURL).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ecodes.ErrCodeNotFound, result
 		}
-		return ecodes.ErrCodeDatabaseError, result
+		return t.logError(ecodes.ErrCodeDatabaseError, err), result
 	}
 
 	// 准备更新字段
 	updates := make(map[string]any)
 	updates["updated_at"] = time.Now().Unix()
 
-	if originalURL != "" {
+	urlChanged := originalURL != "" && originalURL != existingURL.OriginalURL
+	fallbackChanged := fallbackURL != "" && fallbackURL != existingURL.FallbackURL
+	if urlChanged || fallbackChanged {
+		if originalURL == "" {
+			originalURL = existingURL.OriginalURL
+		}
+		if fallbackURL == "" {
+			fallbackURL = existingURL.FallbackURL
+		}
+		errCode, target, canonical, fallback := t.shortenCheckDestination(code, originalURL, fallbackURL)
+		if errCode != ecodes.ErrCodeSuccess {
+			return errCode, result
+		}
+		originalURL = target
+		updates["canonical_url"] = canonical
+		updates["canonical_hash"] = urlnorm.Hash(canonical)
+		updates["fallback_url"] = fallback
+		// 被策略禁止的短链接更换为合规地址后恢复正常
+		if existingURL.Status == model.UrlStatusBlocked {
+			updates["status"] = model.UrlStatusNormal
+		}
 		updates["original_url"] = originalURL
+		// 目标地址变更后重置健康检测结果
+		updates["health_code"] = 0
+		updates["health_latency"] = 0
+		updates["health_checked_at"] = nil
 	}
 	if describe != "" {
 		updates["describe"] = describe
@@ -135,96 +344,161 @@ func (t *ShortenLogic) ShortenUpdate(code string, originalURL string, describe s
 	updates["updated_at"] = nowTime
 
 	if err := t.db.Model(&existingURL).Updates(updates).Error; err != nil {
-		return ecodes.ErrCodeDatabaseError, result
+		return t.logError(ecodes.ErrCodeDatabaseError, err), result
 	}
 
-	if err := t.cache.Set(t.cache.GetKey(existingURL.ShortCode), existingURL); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
-		return ecodes.ErrCodeCacheError, result // 缓存失败
+	if err := t.shortenCacheSet(existingURL); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return t.logError(ecodes.ErrCodeCacheError, err), result // 缓存失败
 	}
 
 	result = types.ResShorten{
-		ID:          existingURL.ID,
-		Code:        existingURL.ShortCode,
-		ShortURL:    t.GetSiteURL(existingURL.ShortCode),
-		OriginalURL: existingURL.OriginalURL,
-		Describe:    existingURL.Describe,
-		Status:      existingURL.Status,
-		UpdatedAt:   utils.TimeToStr(nowTime),
-		CreatedAt:   utils.TimeToStr(existingURL.CreatedAt),
+		ID:           existingURL.ID,
+		WorkspaceID:  existingURL.WorkspaceID,
+		UserID:       existingURL.UserID,
+		Code:         existingURL.ShortCode,
+		ShortURL:     t.shortURL(existingURL.WorkspaceID, existingURL.ShortCode),
+		OriginalURL:  existingURL.OriginalURL,
+		CanonicalURL: existingURL.CanonicalURL,
+		FallbackURL:  existingURL.FallbackURL,
+		Describe:     existingURL.Describe,
+		Status:       existingURL.Status,
+		UpdatedAt:    utils.TimeToStr(nowTime),
+		CreatedAt:    utils.TimeToStr(existingURL.CreatedAt),
+
+		HealthCode:      existingURL.HealthCode,
+		HealthLatency:   existingURL.HealthLatency,
+		HealthCheckedAt: timePtrToStr(existingURL.HealthCheckedAt),
 	}
 
 	return ecodes.ErrCodeSuccess, result
 }
 
-// ShortenFind 获取短链接
-func (t *ShortenLogic) ShortenFind(code string) (int, types.ResShorten) {
-	var data model.Url
+// ShortenFind 获取当前工作区中的短链接，普通成员仅可获取自己的短链接
+func (t *ShortenLogic) ShortenFind(user types.Principal, code string) (int, types.ResShorten) {
+	data, errCode := t.shortenLoad(code)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResShorten{}
+	}
+	if data.WorkspaceID != user.WorkspaceID || (!user.IsWorkspaceAdmin() && data.UserID != user.UserID) {
+		return ecodes.ErrCodeNotFound, types.ResShorten{}
+	}
+
+	return ecodes.ErrCodeSuccess, t.shortenResult(data)
+}
+
+// ShortenResolve 按访问的域名解析短码，用于跳转
+// 工作区绑定的域名仅解析本工作区的短链接，工作区本月访问次数已达上限时返回 ErrCodeQuotaClicks
+func (t *ShortenLogic) ShortenResolve(host string, code string) (int, types.ResShorten) {
+	data, errCode := t.shortenLoad(code)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResShorten{}
+	}
+	if workspaceID, ok := t.workspaceByHost(host); ok && workspaceID != data.WorkspaceID {
+		return ecodes.ErrCodeNotFound, types.ResShorten{}
+	}
 
+	if exceeded, err := t.workspaceClicksExceeded(data.WorkspaceID); err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResShorten{}
+	} else if exceeded {
+		return ecodes.ErrCodeQuotaClicks, types.ResShorten{}
+	}
+
+	return ecodes.ErrCodeSuccess, t.shortenResult(data)
+}
+
+// shortenLoad 按短码获取短链接，优先从缓存读取
+func (t *ShortenLogic) shortenLoad(code string) (model.Url, int) {
 	// 1. 从缓存中获取
-	cacheKey := t.cache.GetKey(code)
-	if cacheData, err := t.cache.Get(cacheKey); err == nil {
-		// log.Printf("cacheData: %v", cacheData)
-		if err := sonic.Unmarshal([]byte(cacheData), &data); err != nil {
-			return ecodes.ErrCodeCacheError, types.ResShorten{} // 缓存反序列化失败
-		}
-	} else {
-		// 从数据库中获取
-		if err := t.db.Where("short_code = ?", code).First(&data).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return ecodes.ErrCodeNotFound, types.ResShorten{}
-			}
-			return ecodes.ErrCodeDatabaseError, types.ResShorten{}
-		}
+	if data, ok := t.shortenCacheGet(code); ok {
+		return data, ecodes.ErrCodeSuccess
+	}
 
-		// log.Printf("data: %v", data)
-		// 缓存短链接
-		if err := t.cache.Set(cacheKey, data); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
-			return ecodes.ErrCodeCacheError, types.ResShorten{} // 缓存失败
+	// 2. 从数据库中获取
+	var data model.Url
+	if err := t.db.Where("short_code = ?", code).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, ecodes.ErrCodeNotFound
 		}
+		return data, t.logError(ecodes.ErrCodeDatabaseError, err)
 	}
 
-	result := types.ResShorten{
-		ID:          data.ID,
-		Code:        data.ShortCode,
-		ShortURL:    t.GetSiteURL(data.ShortCode),
-		OriginalURL: data.OriginalURL,
-		Describe:    data.Describe,
-		Status:      data.Status,
-		CreatedAt:   utils.TimeToStr(data.CreatedAt),
-		UpdatedAt:   utils.TimeToStr(data.UpdatedAt),
+	// 3. 缓存短链接
+	if err := t.shortenCacheSet(data); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return data, t.logError(ecodes.ErrCodeCacheError, err) // 缓存失败
 	}
 
-	return ecodes.ErrCodeSuccess, result
+	return data, ecodes.ErrCodeSuccess
+}
+
+// shortenResult 构造短链接响应
+func (t *ShortenLogic) shortenResult(data model.Url) types.ResShorten {
+	return types.ResShorten{
+		ID:           data.ID,
+		WorkspaceID:  data.WorkspaceID,
+		UserID:       data.UserID,
+		Code:         data.ShortCode,
+		ShortURL:     t.shortURL(data.WorkspaceID, data.ShortCode),
+		OriginalURL:  data.OriginalURL,
+		CanonicalURL: data.CanonicalURL,
+		FallbackURL:  data.FallbackURL,
+		Describe:     data.Describe,
+		Status:       data.Status,
+		CreatedAt:    utils.TimeToStr(data.CreatedAt),
+		UpdatedAt:    utils.TimeToStr(data.UpdatedAt),
+
+		HealthCode:      data.HealthCode,
+		HealthLatency:   data.HealthLatency,
+		HealthCheckedAt: timePtrToStr(data.HealthCheckedAt),
+	}
 }
 
 // ShortenAll 获取所有短链接
-func (t *ShortenLogic) ShortenAll(reqQuery types.ReqQueryShorten) (int, []types.ResShorten, types.ResPage) {
+func (t *ShortenLogic) ShortenAll(user types.Principal, reqQuery types.ReqQueryShorten) (int, []types.ResShorten, types.ResPage) {
 	results := make([]types.ResShorten, 0)
 	pageInfo := types.ResPage{}
 
 	// 查询数据库
 	query := t.db.Model(&model.Url{}).
+		Scopes(inWorkspace(user)).
 		Order(fmt.Sprintf("%s %s", reqQuery.SortBy, reqQuery.Order))
 
+	// 管理员或工作区管理员可按所属用户筛选
+	if reqQuery.UserID != nil && user.IsWorkspaceAdmin() {
+		query = query.Where("user_id = ?", *reqQuery.UserID)
+	}
+
 	if reqQuery.Code != "" {
 		query = query.Where("short_code = ?", reqQuery.Code)
 	}
 
 	if reqQuery.OriginalURL != "" {
 		// query = query.Where("original_url = ?", reqQuery.OriginalURL)
-		// 模糊查找
-		query = query.Where("original_url like ?", "%"+reqQuery.OriginalURL+"%")
+		// 模糊查找（同时匹配规范化地址）
+		keyword := "%" + reqQuery.OriginalURL + "%"
+		query = query.Where("(canonical_url like ? OR original_url like ?)", keyword, keyword)
 	}
 
 	if reqQuery.Status != -1 {
 		query = query.Where("status = ?", reqQuery.Status)
 	}
 
+	// 目标地址健康状态
+	switch reqQuery.Health {
+	case "broken":
+		query = query.Where("health_checked_at IS NOT NULL AND (health_code = 0 OR health_code >= ?)", 400)
+	case "healthy":
+		query = query.Where("health_checked_at IS NOT NULL AND health_code > 0 AND health_code < ?", 400)
+	case "blocked":
+		query = query.Where("health_checked_at IS NOT NULL AND health_code = ?", model.HealthCodeBlocked)
+	case "unchecked":
+		query = query.Where("health_checked_at IS NULL")
+	}
+
 	// 计算总条数
 	var total int64
 	query = query.Count(&total)
 	if query.Error != nil {
-		return ecodes.ErrCodeDatabaseError, results, pageInfo
+		return t.logError(ecodes.ErrCodeDatabaseError, query.Error), results, pageInfo
 	}
 
 	// 分页查询
@@ -233,7 +507,7 @@ func (t *ShortenLogic) ShortenAll(reqQuery types.ReqQueryShorten) (int, []types.
 		Limit(int(reqQuery.PageSize)).
 		Find(&data)
 	if resDB.Error != nil {
-		return ecodes.ErrCodeDatabaseError, results, pageInfo
+		return t.logError(ecodes.ErrCodeDatabaseError, resDB.Error), results, pageInfo
 	}
 
 	// 页码信息
@@ -248,16 +522,76 @@ func (t *ShortenLogic) ShortenAll(reqQuery types.ReqQueryShorten) (int, []types.
 
 	for _, item := range data {
 		results = append(results, types.ResShorten{
-			ID:          item.ID,
-			Code:        item.ShortCode,
-			ShortURL:    t.GetSiteURL(item.ShortCode),
-			OriginalURL: item.OriginalURL,
-			Describe:    item.Describe,
-			Status:      item.Status,
-			CreatedAt:   utils.TimeToStr(item.CreatedAt),
-			UpdatedAt:   utils.TimeToStr(item.UpdatedAt),
+			ID:           item.ID,
+			WorkspaceID:  item.WorkspaceID,
+			UserID:       item.UserID,
+			Code:         item.ShortCode,
+			ShortURL:     t.shortURL(item.WorkspaceID, item.ShortCode),
+			OriginalURL:  item.OriginalURL,
+			CanonicalURL: item.CanonicalURL,
+			FallbackURL:  item.FallbackURL,
+			Describe:     item.Describe,
+			Status:       item.Status,
+			CreatedAt:    utils.TimeToStr(item.CreatedAt),
+			UpdatedAt:    utils.TimeToStr(item.UpdatedAt),
+
+			HealthCode:      item.HealthCode,
+			HealthLatency:   item.HealthLatency,
+			HealthCheckedAt: timePtrToStr(item.HealthCheckedAt),
 		})
 	}
 
 	return ecodes.ErrCodeSuccess, results, pageInfo
 }
+
+// ShortenRecheckPolicy 按目标地址策略重新检查所有短链接
+// 违反策略的短链接标记为 UrlStatusBlocked，此前被禁止但已合规的恢复为 UrlStatusNormal
+func (t *ShortenLogic) ShortenRecheckPolicy(ctx context.Context) (blocked int, restored int, err error) {
+	// 整个检查使用同一份策略，不受检查期间的配置重载影响
+	p := shared.GlobalPolicy.Load()
+
+	var batch []model.Url
+	err = t.db.Model(&model.Url{}).
+		Where("status IN ?", []int8{model.UrlStatusNormal, model.UrlStatusBlocked}).
+		FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				item := &batch[i]
+
+				// 应用深度链接检查其网页回退地址
+				target := item.OriginalURL
+				if item.FallbackURL != "" {
+					target = item.FallbackURL
+				}
+
+				status := model.UrlStatusNormal
+				err := p.Check(ctx, target)
+				if err != nil {
+					status = model.UrlStatusBlocked
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				// 暂时无法解析的域名保持原状态，避免解析故障时批量禁用短链接
+				if status == item.Status || errors.Is(err, policy.ErrUnresolved) {
+					continue
+				}
+
+				if err := t.db.Model(&model.Url{}).Where("id = ?", item.ID).UpdateColumn("status", status).Error; err != nil {
+					return err
+				}
+				item.Status = status
+				if err := t.shortenCacheSet(*item); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+					return err
+				}
+
+				if status == model.UrlStatusBlocked {
+					blocked++
+				} else {
+					restored++
+				}
+			}
+			return nil
+		}).Error
+
+	return blocked, restored, err
+}
@@ -0,0 +1,266 @@
+package logics
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/totp"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+const (
+	totpRecoveryCount  = 10  // 恢复码数量
+	totpRecoveryLength = 10  // 恢复码长度，展示时以 - 分为两段
+	totpQRCodeSize     = 256 // 二维码尺寸（像素）
+)
+
+// TotpLogic TOTP 两步验证逻辑层
+type TotpLogic struct {
+	logic
+	issuer string
+}
+
+// NewTotpLogic 创建 TOTP 逻辑层
+func NewTotpLogic() *TotpLogic {
+	t := &TotpLogic{}
+	t.init()
+	t.issuer = viper.GetString("auth.totp.issuer")
+	if t.issuer == "" {
+		t.issuer = "Shortener"
+	}
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *TotpLogic) WithContext(ctx context.Context) *TotpLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
+// TotpStatus 获取当前用户的两步验证状态
+func (t *TotpLogic) TotpStatus(user types.Principal) (int, types.ResTotpStatus) {
+	errCode, data := t.totpUser(user.UserID)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResTotpStatus{}
+	}
+	return ecodes.ErrCodeSuccess, types.ResTotpStatus{
+		Enabled:       data.TotpEnabled,
+		RecoveryCount: len(strings.Fields(data.TotpRecovery)),
+	}
+}
+
+// TotpSetup 生成待确认的 TOTP 密钥，再次调用会替换未确认的密钥
+func (t *TotpLogic) TotpSetup(user types.Principal) (int, types.ResTotpSetup) {
+	errCode, data := t.totpUser(user.UserID)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResTotpSetup{}
+	}
+	if data.TotpEnabled {
+		return ecodes.ErrCodeConflict, types.ResTotpSetup{}
+	}
+
+	secret := totp.GenerateSecret()
+	uri := totp.URI(t.issuer, data.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, totpQRCodeSize)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeSystemInternalError, err), types.ResTotpSetup{}
+	}
+
+	if err := t.db.Model(&data).Updates(map[string]any{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+		"updated_at":     time.Now().Local(),
+	}).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResTotpSetup{}
+	}
+
+	return ecodes.ErrCodeSuccess, types.ResTotpSetup{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}
+}
+
+// TotpEnable 使用验证码确认密钥并启用两步验证，返回恢复码
+func (t *TotpLogic) TotpEnable(user types.Principal, code string) (int, types.ResTotpStatus) {
+	errCode, data := t.totpUser(user.UserID)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResTotpStatus{}
+	}
+	if data.TotpEnabled {
+		return ecodes.ErrCodeConflict, types.ResTotpStatus{}
+	}
+	if data.TotpSecret == "" {
+		return ecodes.ErrCodeBadRequest, types.ResTotpStatus{}
+	}
+
+	step, ok := totp.Validate(data.TotpSecret, code, time.Now(), data.TotpLastStep)
+	if !ok {
+		return ecodes.ErrCodeUserTotpError, types.ResTotpStatus{}
+	}
+
+	codes, hashes := totpRecoveryCodes()
+	if err := t.db.Model(&data).Updates(map[string]any{
+		"totp_enabled":   true,
+		"totp_last_step": step,
+		"totp_recovery":  hashes,
+		"updated_at":     time.Now().Local(),
+	}).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResTotpStatus{}
+	}
+
+	return ecodes.ErrCodeSuccess, types.ResTotpStatus{
+		Enabled:       true,
+		RecoveryCodes: codes,
+		RecoveryCount: len(codes),
+	}
+}
+
+// TotpDisable 使用验证码或恢复码关闭两步验证
+func (t *TotpLogic) TotpDisable(user types.Principal, code string) int {
+	errCode, data := t.totpUser(user.UserID)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode
+	}
+	if !data.TotpEnabled {
+		return ecodes.ErrCodeBadRequest
+	}
+
+	if errCode := t.TotpCheck(&data, code); errCode != ecodes.ErrCodeSuccess {
+		return errCode
+	}
+
+	return t.TotpReset(data.ID)
+}
+
+// TotpRecoveryRenew 使用验证码重新生成恢复码，原有恢复码失效
+func (t *TotpLogic) TotpRecoveryRenew(user types.Principal, code string) (int, types.ResTotpStatus) {
+	errCode, data := t.totpUser(user.UserID)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResTotpStatus{}
+	}
+	if !data.TotpEnabled {
+		return ecodes.ErrCodeBadRequest, types.ResTotpStatus{}
+	}
+
+	step, ok := totp.Validate(data.TotpSecret, code, time.Now(), data.TotpLastStep)
+	if !ok {
+		return ecodes.ErrCodeUserTotpError, types.ResTotpStatus{}
+	}
+
+	codes, hashes := totpRecoveryCodes()
+	if err := t.db.Model(&data).Updates(map[string]any{
+		"totp_last_step": step,
+		"totp_recovery":  hashes,
+		"updated_at":     time.Now().Local(),
+	}).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResTotpStatus{}
+	}
+
+	return ecodes.ErrCodeSuccess, types.ResTotpStatus{
+		Enabled:       true,
+		RecoveryCodes: codes,
+		RecoveryCount: len(codes),
+	}
+}
+
+// TotpReset 清除用户的两步验证（管理员重置或用户关闭）
+func (t *TotpLogic) TotpReset(userID int64) int {
+	res := t.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"totp_secret":    "",
+		"totp_enabled":   false,
+		"totp_last_step": 0,
+		"totp_recovery":  "",
+		"updated_at":     time.Now().Local(),
+	})
+	if res.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, res.Error)
+	} else if res.RowsAffected == 0 {
+		return ecodes.ErrCodeUserNotFound
+	}
+	return ecodes.ErrCodeSuccess
+}
+
+// TotpCheck 校验验证码或恢复码，验证码不可重复使用，恢复码使用后失效
+func (t *TotpLogic) TotpCheck(user *model.User, code string) int {
+	if !user.TotpEnabled || user.TotpSecret == "" {
+		return ecodes.ErrCodeUserTotpError
+	}
+	code = strings.TrimSpace(code)
+
+	if step, ok := totp.Validate(user.TotpSecret, code, time.Now(), user.TotpLastStep); ok {
+		// 以原时间步为条件，并发使用同一验证码时只有一个请求成功
+		res := t.db.Model(&model.User{}).
+			Where("id = ? AND totp_last_step = ?", user.ID, user.TotpLastStep).
+			UpdateColumn("totp_last_step", step)
+		if res.Error != nil {
+			return t.logError(ecodes.ErrCodeDatabaseError, res.Error)
+		} else if res.RowsAffected == 0 {
+			return ecodes.ErrCodeUserTotpError
+		}
+		user.TotpLastStep = step
+		return ecodes.ErrCodeSuccess
+	}
+
+	hash := utils.HashSecret(totpNormalizeRecovery(code))
+	hashes := strings.Fields(user.TotpRecovery)
+	for i, item := range hashes {
+		if item != hash {
+			continue
+		}
+		remaining := strings.Join(append(hashes[:i:i], hashes[i+1:]...), " ")
+		res := t.db.Model(&model.User{}).
+			Where("id = ? AND totp_recovery = ?", user.ID, user.TotpRecovery).
+			UpdateColumn("totp_recovery", remaining)
+		if res.Error != nil {
+			return t.logError(ecodes.ErrCodeDatabaseError, res.Error)
+		} else if res.RowsAffected == 0 {
+			return ecodes.ErrCodeUserTotpError
+		}
+		user.TotpRecovery = remaining
+		return ecodes.ErrCodeSuccess
+	}
+
+	return ecodes.ErrCodeUserTotpError
+}
+
+// totpUser 获取用户
+func (t *TotpLogic) totpUser(id int64) (int, model.User) {
+	var user model.User
+	if err := t.db.Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeUserNotFound, user
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), user
+	}
+	return ecodes.ErrCodeSuccess, user
+}
+
+// totpRecoveryCodes 生成恢复码，返回恢复码及其哈希（以空格分隔）
+func totpRecoveryCodes() ([]string, string) {
+	codes := make([]string, 0, totpRecoveryCount)
+	hashes := make([]string, 0, totpRecoveryCount)
+	for range totpRecoveryCount {
+		code := strings.ToLower(utils.GenerateSecret(totpRecoveryLength))
+		codes = append(codes, code[:totpRecoveryLength/2]+"-"+code[totpRecoveryLength/2:])
+		hashes = append(hashes, utils.HashSecret(code))
+	}
+	return codes, strings.Join(hashes, " ")
+}
+
+// totpNormalizeRecovery 规范化用户输入的恢复码（忽略大小写与分隔符）
+func totpNormalizeRecovery(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
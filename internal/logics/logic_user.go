@@ -0,0 +1,284 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+// UserLogic 用户逻辑层
+type UserLogic struct {
+	logic
+	session *SessionLogic
+	totp    *TotpLogic
+}
+
+// NewUserLogic 创建用户逻辑层
+func NewUserLogic() *UserLogic {
+	t := &UserLogic{}
+	t.init()
+	t.session = NewSessionLogic()
+	t.totp = NewTotpLogic()
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *UserLogic) WithContext(ctx context.Context) *UserLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	if t.session != nil {
+		c.session = t.session.WithContext(ctx)
+	}
+	if t.totp != nil {
+		c.totp = t.totp.WithContext(ctx)
+	}
+	return &c
+}
+
+// UserAdd 添加用户，按配置加入默认工作区
+func (t *UserLogic) UserAdd(username string, password string, role string) (int, types.ResUser) {
+	var count int64
+	if err := t.db.Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResUser{}
+	}
+	if count > 0 {
+		return ecodes.ErrCodeUserExists, types.ResUser{}
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeSystemInternalError, err), types.ResUser{}
+	}
+
+	nowTime := time.Now().Local()
+	user := model.User{
+		Username:  username,
+		Password:  hash,
+		Role:      role,
+		CreatedAt: nowTime,
+		UpdatedAt: nowTime,
+	}
+	err = t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return workspaceJoinDefault(tx, user.ID)
+	})
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResUser{}
+	}
+
+	return ecodes.ErrCodeSuccess, userResult(user)
+}
+
+// UserUpdate 更新用户密码或角色，空值表示不修改
+func (t *UserLogic) UserUpdate(id int64, password string, role string) (int, types.ResUser) {
+	var user model.User
+	if err := t.db.Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeUserNotFound, types.ResUser{}
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResUser{}
+	}
+
+	updates := make(map[string]any)
+	if password != "" {
+		hash, err := utils.HashPassword(password)
+		if err != nil {
+			return t.logError(ecodes.ErrCodeSystemInternalError, err), types.ResUser{}
+		}
+		updates["password"] = hash
+		user.Password = hash
+	}
+	demote := false
+	if role != "" && role != user.Role {
+		demote = user.Role == access.RoleAdmin
+		updates["role"] = role
+		user.Role = role
+	}
+	user.UpdatedAt = time.Now().Local()
+	updates["updated_at"] = user.UpdatedAt
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		// 至少保留一个管理员
+		if demote {
+			if err := userCheckLastAdmin(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if errors.Is(err, errLastAdmin) {
+		return ecodes.ErrCodeUserPermissionDenied, types.ResUser{}
+	}
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResUser{}
+	}
+
+	// 密码或角色变更后需重新登录
+	t.userRevokeSessions(user.ID)
+
+	return ecodes.ErrCodeSuccess, userResult(user)
+}
+
+// UserDelete 删除用户及其 API Key 与工作区成员关系，其短链接转为无所属用户（仅管理员及工作区管理员可见）
+func (t *UserLogic) UserDelete(id int64) int {
+	var user model.User
+	if err := t.db.Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeUserNotFound
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		// 至少保留一个管理员
+		if user.Role == access.RoleAdmin {
+			if err := userCheckLastAdmin(tx); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.Url{}).Where("user_id = ?", user.ID).UpdateColumn("user_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.ApiKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if errors.Is(err, errLastAdmin) {
+		return ecodes.ErrCodeUserPermissionDenied
+	}
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+
+	t.userRevokeSessions(user.ID)
+
+	return ecodes.ErrCodeSuccess
+}
+
+// UserTotpReset 重置用户的两步验证，用户丢失验证器与恢复码时由管理员操作
+func (t *UserLogic) UserTotpReset(id int64) int {
+	if errCode := t.totp.TotpReset(id); errCode != ecodes.ErrCodeSuccess {
+		return errCode
+	}
+
+	// 重置后需重新登录
+	t.userRevokeSessions(id)
+
+	return ecodes.ErrCodeSuccess
+}
+
+// UserFind 获取用户
+func (t *UserLogic) UserFind(id int64) (int, types.ResUser) {
+	var user model.User
+	if err := t.db.Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeUserNotFound, types.ResUser{}
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResUser{}
+	}
+
+	return ecodes.ErrCodeSuccess, userResult(user)
+}
+
+// UserAll 获取用户列表
+func (t *UserLogic) UserAll(reqQuery types.ReqQueryUser) (int, []types.ResUser, types.ResPage) {
+	results := make([]types.ResUser, 0)
+	pageInfo := types.ResPage{}
+
+	query := t.db.Model(&model.User{}).
+		Order(fmt.Sprintf("%s %s", reqQuery.SortBy, reqQuery.Order))
+
+	if reqQuery.Username != "" {
+		query = query.Where("username like ?", "%"+reqQuery.Username+"%")
+	}
+
+	if reqQuery.Role != "" {
+		query = query.Where("role = ?", reqQuery.Role)
+	}
+
+	// 计算总条数
+	var total int64
+	query = query.Count(&total)
+	if query.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, query.Error), results, pageInfo
+	}
+
+	// 分页查询
+	data := make([]model.User, 0)
+	resDB := query.Offset(int((reqQuery.Page - 1) * reqQuery.PageSize)).
+		Limit(int(reqQuery.PageSize)).
+		Find(&data)
+	if resDB.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, resDB.Error), results, pageInfo
+	}
+
+	// 页码信息
+	pageInfo.Page = reqQuery.Page
+	pageInfo.PageSize = reqQuery.PageSize
+	pageInfo.CurrentCount = resDB.RowsAffected
+	pageInfo.TotalItems = total
+	pageInfo.TotalPages = total / int64(reqQuery.PageSize)
+	if total%int64(reqQuery.PageSize) != 0 {
+		pageInfo.TotalPages++
+	}
+
+	for _, item := range data {
+		results = append(results, userResult(item))
+	}
+
+	return ecodes.ErrCodeSuccess, results, pageInfo
+}
+
+// errLastAdmin 操作会移除最后一个管理员
+var errLastAdmin = errors.New("last admin")
+
+// userCheckLastAdmin 在事务中锁定所有管理员并检查是否只剩一个，是则返回 errLastAdmin
+// 须与降级或删除管理员在同一事务中执行，避免并发操作移除所有管理员
+func userCheckLastAdmin(tx *gorm.DB) error {
+	// 锁定管理员行而不是 count(*)，PostgreSQL 不支持对聚合查询加锁
+	var ids []int64
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&model.User{}).
+		Where("role = ?", access.RoleAdmin).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) <= 1 {
+		return errLastAdmin
+	}
+	return nil
+}
+
+// userRevokeSessions 注销用户的所有登录会话
+func (t *UserLogic) userRevokeSessions(userID int64) {
+	if err := t.session.SessionRevokeUser(userID); err != nil {
+		slog.ErrorContext(t.ctx, "revoke user sessions failed", "err", err)
+	}
+}
+
+// userResult 构造用户响应
+func userResult(user model.User) types.ResUser {
+	return types.ResUser{
+		ID:          user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		TotpEnabled: user.TotpEnabled,
+		CreatedAt:   utils.TimeToStr(user.CreatedAt),
+		UpdatedAt:   utils.TimeToStr(user.UpdatedAt),
+	}
+}
@@ -0,0 +1,737 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go.xoder.cn/shortener/internal/access"
+	"go.xoder.cn/shortener/internal/dal/db/model"
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
+	"go.xoder.cn/shortener/internal/utils"
+)
+
+const (
+	workspaceCachePrefix = "ws:"   // 工作区缓存键前缀，其下保存工作区的短链接与配额状态
+	shortenIndexPrefix   = "code:" // 短码所属工作区的缓存键前缀，跳转时据此定位短链接
+
+	// workspaceStateTTL 配额状态的缓存时间，期间新增的访问不计入，访问次数可能略微超出配额
+	workspaceStateTTL = time.Minute
+	// workspaceDomainsTTL 进程内工作区域名的刷新间隔，多实例部署时域名变更在此时间内生效
+	workspaceDomainsTTL = time.Minute
+)
+
+// workspaceSlugPattern 工作区标识仅允许小写字母、数字与 -
+var workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// WorkspaceCachePrefix 工作区的缓存键前缀（未加全局前缀）
+func WorkspaceCachePrefix(workspaceID int64) string {
+	return workspaceCachePrefix + strconv.FormatInt(workspaceID, 10) + ":"
+}
+
+// ShortenCacheKeys 短链接及其短码索引的缓存键（未加全局前缀）
+func ShortenCacheKeys(workspaceID int64, code string) (string, string) {
+	return WorkspaceCachePrefix(workspaceID) + "link:" + code, shortenIndexPrefix + code
+}
+
+// usagePeriod 用量统计的月份
+func usagePeriod(now time.Time) string {
+	return now.Local().Format("2006-01")
+}
+
+// workspaceState 工作区配额状态
+type workspaceState struct {
+	ClickQuota int64 `json:"click_quota"`
+	Clicks     int64 `json:"clicks"`
+}
+
+// workspaceDomains 工作区域名，进程内缓存并定期刷新
+var workspaceDomains struct {
+	sync.Mutex
+	byID     map[int64]string
+	byHost   map[string]int64
+	loadedAt time.Time
+}
+
+// workspaceDomainsReset 工作区域名变更后，下次使用时重新读取
+func workspaceDomainsReset() {
+	workspaceDomains.Lock()
+	workspaceDomains.loadedAt = time.Time{}
+	workspaceDomains.Unlock()
+}
+
+// workspaceDomainsLoad 按需刷新进程内的工作区域名，调用方须持有锁；读取失败时沿用原有数据
+func (t *logic) workspaceDomainsLoad() {
+	now := time.Now()
+	if now.Sub(workspaceDomains.loadedAt) < workspaceDomainsTTL {
+		return
+	}
+	workspaceDomains.loadedAt = now
+
+	var items []model.Workspace
+	if err := t.db.Select("id", "domain").Where("domain <> ?", "").Find(&items).Error; err != nil {
+		slog.ErrorContext(t.ctx, "load workspace domains failed", "err", err)
+		return
+	}
+
+	workspaceDomains.byID = make(map[int64]string, len(items))
+	workspaceDomains.byHost = make(map[string]int64, len(items))
+	for _, item := range items {
+		workspaceDomains.byID[item.ID] = item.Domain
+		workspaceDomains.byHost[item.Domain] = item.ID
+	}
+}
+
+// workspaceDomain 工作区的短链接域名，未设置时返回空字符串
+func (t *logic) workspaceDomain(workspaceID int64) string {
+	workspaceDomains.Lock()
+	defer workspaceDomains.Unlock()
+	t.workspaceDomainsLoad()
+	return workspaceDomains.byID[workspaceID]
+}
+
+// workspaceByHost 域名所属的工作区
+func (t *logic) workspaceByHost(host string) (int64, bool) {
+	workspaceDomains.Lock()
+	defer workspaceDomains.Unlock()
+	t.workspaceDomainsLoad()
+	host = strings.ToLower(host)
+	if id, ok := workspaceDomains.byHost[host]; ok {
+		return id, true
+	}
+	// 域名未设置端口时忽略端口比较
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		id, ok := workspaceDomains.byHost[hostname]
+		return id, ok
+	}
+	return 0, false
+}
+
+// shortURL 短链接的完整地址，工作区设置了域名时使用其域名
+func (t *logic) shortURL(workspaceID int64, code string) string {
+	domain := t.workspaceDomain(workspaceID)
+	if domain == "" {
+		return t.GetSiteURL(code)
+	}
+
+	scheme := "http"
+	if u, err := url.Parse(t.site_url); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	return scheme + "://" + domain + "/" + code
+}
+
+// shortenCacheSet 缓存短链接及其短码索引
+func (t *logic) shortenCacheSet(item model.Url) error {
+	entry, index := ShortenCacheKeys(item.WorkspaceID, item.ShortCode)
+	if err := t.cache.Set(t.cache.GetKey(entry), item); err != nil {
+		return err
+	}
+	return t.cache.Set(t.cache.GetKey(index), item.WorkspaceID)
+}
+
+// shortenCacheGet 从缓存获取短链接，先由短码索引确定所属工作区，未命中时返回 false
+func (t *logic) shortenCacheGet(code string) (model.Url, bool) {
+	var data model.Url
+	index, err := t.cache.Get(t.cache.GetKey(shortenIndexPrefix + code))
+	if err != nil {
+		return data, false
+	}
+	workspaceID, err := strconv.ParseInt(index, 10, 64)
+	if err != nil {
+		return data, false
+	}
+
+	entry, _ := ShortenCacheKeys(workspaceID, code)
+	cacheData, err := t.cache.Get(t.cache.GetKey(entry))
+	if err != nil {
+		return data, false
+	}
+	if err := sonic.Unmarshal([]byte(cacheData), &data); err != nil {
+		return data, false
+	}
+	return data, true
+}
+
+// shortenCacheDelete 删除短链接及其短码索引的缓存
+func (t *logic) shortenCacheDelete(workspaceID int64, code string) error {
+	entry, index := ShortenCacheKeys(workspaceID, code)
+	if err := t.cache.Delete(t.cache.GetKey(entry)); err != nil {
+		return err
+	}
+	return t.cache.Delete(t.cache.GetKey(index))
+}
+
+// workspaceClicksExceeded 工作区本月访问次数是否已达上限
+func (t *logic) workspaceClicksExceeded(workspaceID int64) (bool, error) {
+	var state workspaceState
+	cacheKey := t.cache.GetKey(WorkspaceCachePrefix(workspaceID) + "state")
+	if cacheData, err := t.cache.Get(cacheKey); err == nil && sonic.Unmarshal([]byte(cacheData), &state) == nil {
+		return state.ClickQuota > 0 && state.Clicks >= state.ClickQuota, nil
+	}
+
+	var workspace model.Workspace
+	if err := t.db.Select("id", "click_quota").Where("id = ?", workspaceID).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	state.ClickQuota = workspace.ClickQuota
+	if state.ClickQuota > 0 {
+		var usage model.WorkspaceUsage
+		if err := t.db.Where("workspace_id = ? AND period = ?", workspaceID, usagePeriod(time.Now())).Limit(1).Find(&usage).Error; err != nil {
+			return false, err
+		}
+		state.Clicks = usage.Clicks
+	}
+
+	if err := t.cache.Set(cacheKey, state, workspaceStateTTL); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return false, err
+	}
+	return state.ClickQuota > 0 && state.Clicks >= state.ClickQuota, nil
+}
+
+// workspaceLinksExceeded 工作区短链接数量是否已达上限
+func (t *logic) workspaceLinksExceeded(workspaceID int64) (bool, error) {
+	var workspace model.Workspace
+	if err := t.db.Select("id", "link_quota").Where("id = ?", workspaceID).First(&workspace).Error; err != nil {
+		return false, err
+	}
+	if workspace.LinkQuota <= 0 {
+		return false, nil
+	}
+
+	var count int64
+	if err := t.db.Model(&model.Url{}).Where("workspace_id = ?", workspaceID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count >= workspace.LinkQuota, nil
+}
+
+// workspaceJoin 将用户加入工作区，已是成员时不做修改
+func workspaceJoin(db *gorm.DB, workspaceID int64, userID int64, role string) error {
+	member := model.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        role,
+		CreatedAt:   time.Now().Local(),
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error
+}
+
+// workspaceJoinDefault 按配置将新用户加入默认工作区
+func workspaceJoinDefault(db *gorm.DB, userID int64) error {
+	if shared.GlobalWorkspace == nil || !shared.GlobalWorkspace.JoinDefault {
+		return nil
+	}
+	return workspaceJoin(db, shared.GlobalWorkspace.DefaultID, userID, access.WorkspaceRoleMember)
+}
+
+// WorkspaceLogic 工作区逻辑层
+type WorkspaceLogic struct {
+	logic
+}
+
+// NewWorkspaceLogic 创建工作区逻辑层
+func NewWorkspaceLogic() *WorkspaceLogic {
+	t := &WorkspaceLogic{}
+	t.init()
+	return t
+}
+
+// WithContext 返回使用请求上下文的副本
+func (t *WorkspaceLogic) WithContext(ctx context.Context) *WorkspaceLogic {
+	c := *t
+	c.logic = t.logic.withContext(ctx)
+	return &c
+}
+
+// WorkspaceResolve 确定请求使用的工作区及成员角色，requested 为 0 时使用最早加入的工作区
+// API Key 固定为其所属工作区；系统管理员无需加入即可访问任意工作区，未加入任何工作区时使用默认工作区
+func (t *WorkspaceLogic) WorkspaceResolve(user types.Principal, requested int64) (types.Principal, int) {
+	if user.ApiKeyID != 0 {
+		if requested != 0 && requested != user.WorkspaceID {
+			return user, ecodes.ErrCodeWorkspaceForbidden
+		}
+		requested = user.WorkspaceID
+	}
+
+	var member model.WorkspaceMember
+	query := t.db.Where("user_id = ?", user.UserID)
+	if requested != 0 {
+		query = query.Where("workspace_id = ?", requested)
+	}
+	err := query.Order("workspace_id ASC").First(&member).Error
+	if err == nil {
+		user.WorkspaceID = member.WorkspaceID
+		user.WorkspaceRole = member.Role
+		return user, ecodes.ErrCodeSuccess
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+
+	if !user.IsAdmin() {
+		if requested != 0 {
+			return user, ecodes.ErrCodeWorkspaceForbidden
+		}
+		return user, ecodes.ErrCodeWorkspaceRequired
+	}
+
+	if requested == 0 {
+		requested = shared.GlobalWorkspace.DefaultID
+	}
+	if errCode, _ := t.workspaceFind(requested); errCode != ecodes.ErrCodeSuccess {
+		return user, errCode
+	}
+	user.WorkspaceID = requested
+	user.WorkspaceRole = access.WorkspaceRoleAdmin
+	return user, ecodes.ErrCodeSuccess
+}
+
+// WorkspaceAdd 创建工作区
+func (t *WorkspaceLogic) WorkspaceAdd(name string, slug string, domain string, linkQuota int64, clickQuota int64) (int, types.ResWorkspace) {
+	if !workspaceSlugPattern.MatchString(slug) {
+		return ecodes.ErrCodeInvalidParam, types.ResWorkspace{}
+	}
+	domain, errCode := t.workspaceCheckDomain(domain, 0)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResWorkspace{}
+	}
+
+	var count int64
+	if err := t.db.Model(&model.Workspace{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspace{}
+	}
+	if count > 0 {
+		return ecodes.ErrCodeConflict, types.ResWorkspace{}
+	}
+
+	nowTime := time.Now().Local()
+	workspace := model.Workspace{
+		Name:       name,
+		Slug:       slug,
+		Domain:     domain,
+		LinkQuota:  linkQuota,
+		ClickQuota: clickQuota,
+		CreatedAt:  nowTime,
+		UpdatedAt:  nowTime,
+	}
+	if err := t.db.Create(&workspace).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspace{}
+	}
+	if domain != "" {
+		workspaceDomainsReset()
+	}
+
+	return ecodes.ErrCodeSuccess, workspaceResult(workspace, 0, 0)
+}
+
+// WorkspaceUpdate 更新工作区名称、域名或配额，nil 或空值表示不修改
+func (t *WorkspaceLogic) WorkspaceUpdate(id int64, name string, domain *string, linkQuota *int64, clickQuota *int64) (int, types.ResWorkspace) {
+	errCode, workspace := t.workspaceFind(id)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResWorkspace{}
+	}
+
+	updates := make(map[string]any)
+	if name != "" {
+		updates["name"] = name
+		workspace.Name = name
+	}
+	if domain != nil {
+		checked, errCode := t.workspaceCheckDomain(*domain, workspace.ID)
+		if errCode != ecodes.ErrCodeSuccess {
+			return errCode, types.ResWorkspace{}
+		}
+		updates["domain"] = checked
+		workspace.Domain = checked
+	}
+	if linkQuota != nil {
+		updates["link_quota"] = *linkQuota
+		workspace.LinkQuota = *linkQuota
+	}
+	if clickQuota != nil {
+		updates["click_quota"] = *clickQuota
+		workspace.ClickQuota = *clickQuota
+	}
+	workspace.UpdatedAt = time.Now().Local()
+	updates["updated_at"] = workspace.UpdatedAt
+
+	if err := t.db.Model(&workspace).Updates(updates).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspace{}
+	}
+
+	if domain != nil {
+		workspaceDomainsReset()
+	}
+	if err := t.cache.Delete(t.cache.GetKey(WorkspaceCachePrefix(workspace.ID) + "state")); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return t.logError(ecodes.ErrCodeCacheError, err), types.ResWorkspace{}
+	}
+
+	return t.WorkspaceFind(workspace.ID)
+}
+
+// WorkspaceDelete 删除工作区及其短链接、访问记录、API Key、成员与用量，默认工作区不可删除
+func (t *WorkspaceLogic) WorkspaceDelete(id int64) int {
+	errCode, workspace := t.workspaceFind(id)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode
+	}
+	if workspace.Slug == model.WorkspaceDefaultSlug {
+		return ecodes.ErrCodeWorkspaceDefault
+	}
+
+	var codes []string
+	if err := t.db.Model(&model.Url{}).Where("workspace_id = ?", id).Pluck("short_code", &codes).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range []any{&model.History{}, &model.Url{}, &model.ApiKey{}, &model.WorkspaceMember{}, &model.WorkspaceUsage{}} {
+			if err := tx.Where("workspace_id = ?", id).Delete(item).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&workspace).Error
+	})
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+	workspaceDomainsReset()
+
+	// 清理工作区的缓存及短码索引
+	if err := t.cache.ClearPrefix(t.cache.GetKey(WorkspaceCachePrefix(id))); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+		return t.logError(ecodes.ErrCodeCacheError, err)
+	}
+	for _, code := range codes {
+		if err := t.cache.Delete(t.cache.GetKey(shortenIndexPrefix + code)); err != nil && !errors.Is(err, ecodes.ErrCacheDisabled) {
+			return t.logError(ecodes.ErrCodeCacheError, err)
+		}
+	}
+
+	return ecodes.ErrCodeSuccess
+}
+
+// WorkspaceFind 获取工作区及其用量
+func (t *WorkspaceLogic) WorkspaceFind(id int64) (int, types.ResWorkspace) {
+	errCode, workspace := t.workspaceFind(id)
+	if errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResWorkspace{}
+	}
+
+	results, err := t.workspaceResults([]model.Workspace{workspace})
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspace{}
+	}
+	return ecodes.ErrCodeSuccess, results[0]
+}
+
+// WorkspaceAll 获取工作区列表
+func (t *WorkspaceLogic) WorkspaceAll(reqQuery types.ReqQueryWorkspace) (int, []types.ResWorkspace, types.ResPage) {
+	results := make([]types.ResWorkspace, 0)
+	pageInfo := types.ResPage{}
+
+	query := t.db.Model(&model.Workspace{}).
+		Order(fmt.Sprintf("%s %s", reqQuery.SortBy, reqQuery.Order))
+
+	if reqQuery.Name != "" {
+		query = query.Where("name like ?", "%"+reqQuery.Name+"%")
+	}
+
+	// 计算总条数
+	var total int64
+	query = query.Count(&total)
+	if query.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, query.Error), results, pageInfo
+	}
+
+	// 分页查询
+	data := make([]model.Workspace, 0)
+	resDB := query.Offset(int((reqQuery.Page - 1) * reqQuery.PageSize)).
+		Limit(int(reqQuery.PageSize)).
+		Find(&data)
+	if resDB.Error != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, resDB.Error), results, pageInfo
+	}
+
+	// 页码信息
+	pageInfo.Page = reqQuery.Page
+	pageInfo.PageSize = reqQuery.PageSize
+	pageInfo.CurrentCount = resDB.RowsAffected
+	pageInfo.TotalItems = total
+	pageInfo.TotalPages = total / int64(reqQuery.PageSize)
+	if total%int64(reqQuery.PageSize) != 0 {
+		pageInfo.TotalPages++
+	}
+
+	results, err := t.workspaceResults(data)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), make([]types.ResWorkspace, 0), types.ResPage{}
+	}
+
+	return ecodes.ErrCodeSuccess, results, pageInfo
+}
+
+// WorkspaceMine 获取用户加入的工作区
+func (t *WorkspaceLogic) WorkspaceMine(user types.Principal) (int, []types.ResWorkspace) {
+	var members []model.WorkspaceMember
+	if err := t.db.Where("user_id = ?", user.UserID).Order("workspace_id ASC").Find(&members).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), nil
+	}
+	roles := make(map[int64]string, len(members))
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		roles[member.WorkspaceID] = member.Role
+		ids = append(ids, member.WorkspaceID)
+	}
+
+	data := make([]model.Workspace, 0)
+	if len(ids) > 0 {
+		if err := t.db.Where("id IN ?", ids).Order("id ASC").Find(&data).Error; err != nil {
+			return t.logError(ecodes.ErrCodeDatabaseError, err), nil
+		}
+	}
+
+	results, err := t.workspaceResults(data)
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), nil
+	}
+	for i := range results {
+		results[i].Role = roles[results[i].ID]
+	}
+	return ecodes.ErrCodeSuccess, results
+}
+
+// WorkspaceMembers 获取工作区成员
+func (t *WorkspaceLogic) WorkspaceMembers(id int64) (int, []types.ResWorkspaceMember) {
+	if errCode, _ := t.workspaceFind(id); errCode != ecodes.ErrCodeSuccess {
+		return errCode, nil
+	}
+
+	var rows []struct {
+		UserID    int64
+		Username  string
+		Role      string
+		CreatedAt time.Time
+	}
+	err := t.db.Model(&model.WorkspaceMember{}).
+		Select("workspace_members.user_id, users.username, workspace_members.role, workspace_members.created_at").
+		Joins("LEFT JOIN users ON users.id = workspace_members.user_id").
+		Where("workspace_members.workspace_id = ?", id).
+		Order("workspace_members.id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), nil
+	}
+
+	results := make([]types.ResWorkspaceMember, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, types.ResWorkspaceMember{
+			WorkspaceID: id,
+			UserID:      row.UserID,
+			Username:    row.Username,
+			Role:        row.Role,
+			CreatedAt:   utils.TimeToStr(row.CreatedAt),
+		})
+	}
+	return ecodes.ErrCodeSuccess, results
+}
+
+// WorkspaceMemberAdd 添加工作区成员
+func (t *WorkspaceLogic) WorkspaceMemberAdd(id int64, userID int64, role string) (int, types.ResWorkspaceMember) {
+	if errCode, _ := t.workspaceFind(id); errCode != ecodes.ErrCodeSuccess {
+		return errCode, types.ResWorkspaceMember{}
+	}
+
+	var user model.User
+	if err := t.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeUserNotFound, types.ResWorkspaceMember{}
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspaceMember{}
+	}
+
+	var count int64
+	if err := t.db.Model(&model.WorkspaceMember{}).Where("workspace_id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspaceMember{}
+	}
+	if count > 0 {
+		return ecodes.ErrCodeConflict, types.ResWorkspaceMember{}
+	}
+
+	member := model.WorkspaceMember{
+		WorkspaceID: id,
+		UserID:      userID,
+		Role:        role,
+		CreatedAt:   time.Now().Local(),
+	}
+	if err := t.db.Create(&member).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspaceMember{}
+	}
+
+	return ecodes.ErrCodeSuccess, workspaceMemberResult(member, user.Username)
+}
+
+// WorkspaceMemberUpdate 修改工作区成员角色
+func (t *WorkspaceLogic) WorkspaceMemberUpdate(id int64, userID int64, role string) (int, types.ResWorkspaceMember) {
+	var member model.WorkspaceMember
+	if err := t.db.Where("workspace_id = ? AND user_id = ?", id, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeNotFound, types.ResWorkspaceMember{}
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspaceMember{}
+	}
+
+	if err := t.db.Model(&member).Update("role", role).Error; err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err), types.ResWorkspaceMember{}
+	}
+
+	var user model.User
+	_ = t.db.Select("id", "username").Where("id = ?", userID).First(&user).Error
+	return ecodes.ErrCodeSuccess, workspaceMemberResult(member, user.Username)
+}
+
+// WorkspaceMemberDelete 移除工作区成员及其在该工作区的 API Key，短链接仍保留在工作区中
+func (t *WorkspaceLogic) WorkspaceMemberDelete(id int64, userID int64) int {
+	var affected int64
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("workspace_id = ? AND user_id = ?", id, userID).Delete(&model.WorkspaceMember{})
+		if res.Error != nil {
+			return res.Error
+		}
+		affected = res.RowsAffected
+		return tx.Where("workspace_id = ? AND user_id = ?", id, userID).Delete(&model.ApiKey{}).Error
+	})
+	if err != nil {
+		return t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+	if affected == 0 {
+		return ecodes.ErrCodeNotFound
+	}
+	return ecodes.ErrCodeSuccess
+}
+
+// workspaceFind 获取工作区
+func (t *WorkspaceLogic) workspaceFind(id int64) (int, model.Workspace) {
+	var workspace model.Workspace
+	if err := t.db.Where("id = ?", id).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ecodes.ErrCodeWorkspaceNotFound, workspace
+		}
+		return t.logError(ecodes.ErrCodeDatabaseError, err), workspace
+	}
+	return ecodes.ErrCodeSuccess, workspace
+}
+
+// workspaceCheckDomain 规范化并检查工作区域名，不能为站点地址或其他工作区已使用的域名
+func (t *WorkspaceLogic) workspaceCheckDomain(domain string, exceptID int64) (string, int) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return "", ecodes.ErrCodeSuccess
+	}
+
+	// 仅允许主机名及可选端口
+	u, err := url.Parse("//" + domain)
+	if err != nil || u.Host != domain || u.Hostname() == "" || u.User != nil {
+		return "", ecodes.ErrCodeInvalidParam
+	}
+	if site, err := url.Parse(t.site_url); err == nil && strings.ToLower(site.Host) == domain {
+		return "", ecodes.ErrCodeConflict
+	}
+
+	var count int64
+	if err := t.db.Model(&model.Workspace{}).Where("domain = ? AND id <> ?", domain, exceptID).Count(&count).Error; err != nil {
+		return "", t.logError(ecodes.ErrCodeDatabaseError, err)
+	}
+	if count > 0 {
+		return "", ecodes.ErrCodeConflict
+	}
+	return domain, ecodes.ErrCodeSuccess
+}
+
+// workspaceResults 构造工作区响应，附带短链接数量与本月访问次数
+func (t *WorkspaceLogic) workspaceResults(items []model.Workspace) ([]types.ResWorkspace, error) {
+	results := make([]types.ResWorkspace, 0, len(items))
+	if len(items) == 0 {
+		return results, nil
+	}
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	var links []struct {
+		WorkspaceID int64
+		Total       int64
+	}
+	err := t.db.Model(&model.Url{}).
+		Select("workspace_id, COUNT(*) AS total").
+		Where("workspace_id IN ?", ids).
+		Group("workspace_id").
+		Scan(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	linkCounts := make(map[int64]int64, len(links))
+	for _, item := range links {
+		linkCounts[item.WorkspaceID] = item.Total
+	}
+
+	var usages []model.WorkspaceUsage
+	if err := t.db.Where("workspace_id IN ? AND period = ?", ids, usagePeriod(time.Now())).Find(&usages).Error; err != nil {
+		return nil, err
+	}
+	clicks := make(map[int64]int64, len(usages))
+	for _, item := range usages {
+		clicks[item.WorkspaceID] = item.Clicks
+	}
+
+	for _, item := range items {
+		results = append(results, workspaceResult(item, linkCounts[item.ID], clicks[item.ID]))
+	}
+	return results, nil
+}
+
+// workspaceResult 构造工作区响应
+func workspaceResult(workspace model.Workspace, links int64, clicks int64) types.ResWorkspace {
+	return types.ResWorkspace{
+		ID:         workspace.ID,
+		Name:       workspace.Name,
+		Slug:       workspace.Slug,
+		Domain:     workspace.Domain,
+		LinkQuota:  workspace.LinkQuota,
+		ClickQuota: workspace.ClickQuota,
+		Links:      links,
+		Clicks:     clicks,
+		CreatedAt:  utils.TimeToStr(workspace.CreatedAt),
+		UpdatedAt:  utils.TimeToStr(workspace.UpdatedAt),
+	}
+}
+
+// workspaceMemberResult 构造工作区成员响应
+func workspaceMemberResult(member model.WorkspaceMember, username string) types.ResWorkspaceMember {
+	return types.ResWorkspaceMember{
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+		Username:    username,
+		Role:        member.Role,
+		CreatedAt:   utils.TimeToStr(member.CreatedAt),
+	}
+}
@@ -1,37 +1,69 @@
 package middlewares
 
 import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"go.xoder.cn/shortener/internal/ecodes"
-	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/pkgs/hmacsign"
 	"go.xoder.cn/shortener/internal/types"
 )
 
+// PrincipalKey 请求上下文中保存身份的键
+const PrincipalKey = "principal"
+
 type Authenticator interface {
 	Authenticate(c *gin.Context) (bool, error)
 }
 
+// APIKeyStore API Key 存储
+type APIKeyStore interface {
+	// ApiKeyVerify 校验密钥，返回其对应的身份
+	ApiKeyVerify(key string) (types.Principal, bool, error)
+}
+
 type APIKeyAuth struct {
-	ValidKeys map[string]bool
-	Header    string
-	Query     string
+	Store  APIKeyStore
+	Header string
+	Query  string
 }
 
 func (a *APIKeyAuth) Authenticate(c *gin.Context) (bool, error) {
-	if key := c.GetHeader(a.Header); key != "" && a.ValidKeys[key] {
-		return true, nil
+	key := c.GetHeader(a.Header)
+	if key == "" {
+		key = c.Query(a.Query)
 	}
-	if key := c.Query(a.Query); key != "" && a.ValidKeys[key] {
-		return true, nil
+	if key == "" {
+		return false, nil
+	}
+
+	principal, ok, err := a.Store.ApiKeyVerify(key)
+	if err != nil || !ok {
+		return false, err
 	}
-	return false, nil
+
+	c.Set(PrincipalKey, principal)
+	return true, nil
 }
 
-type BearerTokenAuth struct{}
+// SessionStore 登录会话存储
+type SessionStore interface {
+	// SessionVerify 校验登录令牌，返回其对应的身份
+	SessionVerify(token string) (types.Principal, bool, error)
+}
+
+type BearerTokenAuth struct {
+	Store SessionStore
+}
 
 func (b *BearerTokenAuth) Authenticate(c *gin.Context) (bool, error) {
 	authHeader := c.GetHeader("Authorization")
@@ -44,19 +76,203 @@ func (b *BearerTokenAuth) Authenticate(c *gin.Context) (bool, error) {
 		return false, nil
 	}
 
-	userInfo, ok := shared.GlobalUserCache.Load(token)
-	if !ok || userInfo != shared.GlobalUser.Username {
+	principal, ok, err := b.Store.SessionVerify(token)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	c.Set(PrincipalKey, principal)
+	return true, nil
+}
+
+// JWTStore JWT 访问令牌校验
+type JWTStore interface {
+	// JwtVerify 校验访问令牌，返回其声明的身份
+	JwtVerify(token string) (types.Principal, bool, error)
+}
+
+type JWTAuth struct {
+	Store JWTStore
+}
+
+func (j *JWTAuth) Authenticate(c *gin.Context) (bool, error) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	// JWT 由三段组成，其余令牌交给其他认证方式
+	if !ok || strings.Count(token, ".") != 2 {
 		return false, nil
 	}
 
+	principal, ok, err := j.Store.JwtVerify(token)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	c.Set(PrincipalKey, principal)
+	return true, nil
+}
+
+// HMACStore 请求签名密钥存储
+type HMACStore interface {
+	// ApiKeySignVerify 按密钥ID查找签名密钥并交由 verify 校验，返回其对应的身份
+	ApiKeySignVerify(keyID string, verify func(secret string) (bool, error)) (types.Principal, bool, error)
+}
+
+// HMACAuth 校验 Authorization: HMAC-SHA256 请求签名
+type HMACAuth struct {
+	Store    HMACStore
+	Verifier *hmacsign.Verifier
+	MaxBody  int64 // 参与签名的请求体最大字节数
+}
+
+func (h *HMACAuth) Authenticate(c *gin.Context) (bool, error) {
+	params, ok := hmacsign.Parse(c.GetHeader("Authorization"))
+	if !ok {
+		return false, nil
+	}
+	// 先校验时间，过期请求无需读取请求体与查询密钥
+	if !h.Verifier.CheckTime(params, time.Now()) {
+		return false, nil
+	}
+
+	// 读取请求体计算哈希，并还原供后续处理
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, h.MaxBody+1))
+	if err != nil || int64(len(body)) > h.MaxBody {
+		return false, nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	stringToSign := hmacsign.StringToSign(c.Request.Method, c.Request.URL.EscapedPath(), c.Request.URL.RawQuery,
+		params, hmacsign.BodyHash(body))
+	principal, ok, err := h.Store.ApiKeySignVerify(params.KeyID, func(secret string) (bool, error) {
+		err := h.Verifier.Verify(params, secret, stringToSign, time.Now())
+		if errors.Is(err, hmacsign.ErrInvalid) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+
+	c.Set(PrincipalKey, principal)
 	return true, nil
 }
 
+// CertStore 客户端证书身份映射
+type CertStore interface {
+	// CertVerify 按客户端证书匹配身份
+	CertVerify(cert *x509.Certificate) (types.Principal, bool, error)
+}
+
+// ClientCertAuth 校验 TLS 握手时提供的客户端证书
+type ClientCertAuth struct {
+	Store CertStore
+}
+
+func (a *ClientCertAuth) Authenticate(c *gin.Context) (bool, error) {
+	// 仅使用已通过 CA 校验的证书链
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+		return false, nil
+	}
+
+	principal, ok, err := a.Store.CertVerify(c.Request.TLS.VerifiedChains[0][0])
+	if err != nil || !ok {
+		return false, err
+	}
+
+	c.Set(PrincipalKey, principal)
+	return true, nil
+}
+
+// CurrentPrincipal 获取当前请求的身份
+func CurrentPrincipal(c *gin.Context) types.Principal {
+	if value, ok := c.Get(PrincipalKey); ok {
+		if principal, ok := value.(types.Principal); ok {
+			return principal
+		}
+	}
+	return types.Principal{}
+}
+
+// RequireScope 要求 API Key 具有指定权限范围，登录会话不受限制
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CurrentPrincipal(c).HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, types.ResErr{
+				ErrCode: ecodes.ErrCodeUserPermissionDenied,
+				ErrInfo: ecodes.GetErrCodeMessage(ecodes.ErrCodeUserPermissionDenied),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin 仅允许管理员访问
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CurrentPrincipal(c).IsAdmin() {
+			c.AbortWithStatusJSON(http.StatusForbidden, types.ResErr{
+				ErrCode: ecodes.ErrCodeUserPermissionDenied,
+				ErrInfo: ecodes.GetErrCodeMessage(ecodes.ErrCodeUserPermissionDenied),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// WorkspaceHeader 指定请求所用工作区的请求头
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceStore 工作区成员关系
+type WorkspaceStore interface {
+	// WorkspaceResolve 确定请求使用的工作区，requested 为 0 时由存储选择，返回补充了工作区的身份
+	WorkspaceResolve(principal types.Principal, requested int64) (types.Principal, int)
+}
+
+// RequireWorkspace 确定当前请求的工作区，可通过 X-Workspace-ID 请求头指定
+func RequireWorkspace(store WorkspaceStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requested int64
+		if value := c.GetHeader(WorkspaceHeader); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, types.ResErr{
+					ErrCode: ecodes.ErrCodeInvalidParam,
+					ErrInfo: ecodes.GetErrCodeMessage(ecodes.ErrCodeInvalidParam),
+				})
+				return
+			}
+			requested = id
+		}
+
+		principal, errCode := store.WorkspaceResolve(CurrentPrincipal(c), requested)
+		if errCode != ecodes.ErrCodeSuccess {
+			status := http.StatusInternalServerError
+			if errCode == ecodes.ErrCodeWorkspaceRequired || errCode == ecodes.ErrCodeWorkspaceForbidden {
+				status = http.StatusForbidden
+			} else if errCode == ecodes.ErrCodeWorkspaceNotFound {
+				status = http.StatusNotFound
+			}
+			c.AbortWithStatusJSON(status, types.ResErr{
+				ErrCode: errCode,
+				ErrInfo: ecodes.GetErrCodeMessage(errCode),
+			})
+			return
+		}
+
+		c.Set(PrincipalKey, principal)
+		c.Next()
+	}
+}
+
 func MultiAuthMiddleware(authenticators ...Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		for _, auth := range authenticators {
 			success, err := auth.Authenticate(c)
 			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "authenticate failed", "errcode", ecodes.ErrCodeUserAuthFailed, "err", err)
 				c.AbortWithStatusJSON(http.StatusInternalServerError, types.ResErr{
 					ErrCode: ecodes.ErrCodeUserAuthFailed,
 					ErrInfo: ecodes.GetErrCodeMessage(ecodes.ErrCodeUserAuthFailed),
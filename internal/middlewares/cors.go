@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// CORS 跨域资源共享，为允许的来源添加 Access-Control-* 响应头
+// 预检请求在此直接响应，不进入后续的认证等处理
+func CORS(cfg *types.CfgCORS) gin.HandlerFunc {
+	anyOrigin := slices.Contains(cfg.AllowOrigins, "*")
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(cfg.MaxAge)
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		c.Writer.Header().Add("Vary", "Origin")
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !anyOrigin && !corsOriginAllowed(cfg.AllowOrigins, origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// 启动时已拒绝 * 与携带凭据同时开启
+		if anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		if allowMethods != "" {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+		}
+		if allowHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+		}
+		if cfg.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// corsOriginAllowed 判断来源是否允许，支持 https://*.example.com 形式的通配
+func corsOriginAllowed(allowOrigins []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range allowOrigins {
+		allowed = strings.ToLower(allowed)
+		prefix, suffix, wildcard := strings.Cut(allowed, "*")
+		if !wildcard {
+			if origin == allowed {
+				return true
+			}
+			continue
+		}
+
+		// 通配部分不能为空，且不能跨越路径或端口
+		if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			if !strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:") {
+				return true
+			}
+		}
+	}
+	return false
+}
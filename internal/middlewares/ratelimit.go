@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/ecodes"
+	"go.xoder.cn/shortener/internal/pkgs/ratelimit"
+	"go.xoder.cn/shortener/internal/types"
+)
+
+// RateLimitKey 返回请求的限流计数键
+type RateLimitKey func(c *gin.Context) string
+
+// RateLimitByIP 按客户端 IP 计数
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByPrincipal 按 API Key、用户计数，未认证时按客户端 IP 计数
+func RateLimitByPrincipal(c *gin.Context) string {
+	principal := CurrentPrincipal(c)
+	if principal.ApiKeyID > 0 {
+		return "key:" + strconv.FormatInt(principal.ApiKeyID, 10)
+	}
+	if principal.UserID > 0 {
+		return "user:" + strconv.FormatInt(principal.UserID, 10)
+	}
+	return RateLimitByIP(c)
+}
+
+// RateLimit 请求限流，返回 RateLimit-* 响应头，超出限制时返回 429
+// limiter 为 nil 时不限制，计数存储不可用时放行请求
+func RateLimit(limiter *ratelimit.Limiter, key RateLimitKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(key(c), time.Now())
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		policy := limiter.Policy()
+		reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+		c.Header("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(int(policy.Window/time.Second)))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", reset)
+
+		if !result.Allowed {
+			c.Header("Retry-After", reset)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, types.ResErr{
+				ErrCode: ecodes.ErrCodeTooManyRequests,
+				ErrInfo: ecodes.GetErrCodeMessage(ecodes.ErrCodeTooManyRequests),
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.xoder.cn/shortener/internal/pkgs/ratelimit"
+)
+
+// newRateLimitRouter 创建按客户端 IP 限流的路由
+func newRateLimitRouter(t *testing.T, trustedProxies []string, limit int) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), "test:", ratelimit.Policy{Limit: limit, Window: time.Minute})
+	router.GET("/", RateLimit(limiter, RateLimitByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func doRequest(router *gin.Engine, remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitByIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	router := newRateLimitRouter(t, nil, 2)
+
+	for i := range 3 {
+		w := doRequest(router, "203.0.113.7:1234", "198.51.100."+strconv.Itoa(i))
+		want := http.StatusOK
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("request %d: got status %d, want %d", i, w.Code, want)
+		}
+	}
+
+	w := doRequest(router, "203.0.113.7:1234", "198.51.100.99")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("rotated X-Forwarded-For: got status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestRateLimitByIPTrustedProxy(t *testing.T) {
+	router := newRateLimitRouter(t, []string{"127.0.0.1"}, 1)
+
+	// 可信代理转发的不同客户端分别计数
+	if w := doRequest(router, "127.0.0.1:1234", "198.51.100.1"); w.Code != http.StatusOK {
+		t.Fatalf("first client: got status %d", w.Code)
+	}
+	if w := doRequest(router, "127.0.0.1:1234", "198.51.100.2"); w.Code != http.StatusOK {
+		t.Fatalf("second client: got status %d", w.Code)
+	}
+	if w := doRequest(router, "127.0.0.1:1234", "198.51.100.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("first client again: got status %d, want 429", w.Code)
+	}
+	if got := doRequest(router, "127.0.0.1:1234", "198.51.100.3").Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+}
@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HSTS 为 HTTPS 请求添加 Strict-Transport-Security 响应头
+func HSTS(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 浏览器忽略 HTTP 响应中的 HSTS
+		if c.Request.TLS != nil {
+			c.Header("Strict-Transport-Security", header)
+		}
+		c.Next()
+	}
+}
+
+// HTTPSRedirect 将 HTTP 请求永久跳转到 HTTPS，httpsPort 为 HTTPS 监听端口
+func HTTPSRedirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// AltSvcHeader 生成通告 HTTP/3 端口的 Alt-Svc 响应头，maxAge 为客户端缓存的时长（秒）
+func AltSvcHeader(port string, maxAge int) string {
+	return `h3=":` + port + `"; ma=` + strconv.Itoa(maxAge)
+}
+
+// AltSvc 为 HTTPS 请求添加 Alt-Svc 响应头，告知客户端可通过 HTTP/3 访问
+func AltSvc(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS != nil && c.Request.ProtoMajor < 3 {
+			c.Header("Alt-Svc", header)
+		}
+		c.Next()
+	}
+}
+
+// LocalPeer 将 Unix 套接字连接的对端地址视为本机，server.trusted_proxies 包含 127.0.0.1 时
+// 反向代理传递的客户端地址（X-Forwarded-For）可被识别
+func LocalPeer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := net.SplitHostPort(r.RemoteAddr); err != nil {
+			r.RemoteAddr = "127.0.0.1:0"
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,180 @@
+package certauth
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Identity 证书身份映射规则，已设置的条件须全部满足
+type Identity struct {
+	Name     string   // 身份名称，用于审计
+	Subject  string   // 证书主题的 CommonName
+	SAN      string   // DNS、URI、邮箱或 IP 类型的 SAN
+	SPKI     string   // 公钥指纹，SubjectPublicKeyInfo 的 SHA-256（十六进制）
+	Username string   // 映射的本地用户名
+	Scopes   []string // 权限范围
+}
+
+// matches 证书是否满足规则的全部条件
+func (id Identity) matches(cert *x509.Certificate) bool {
+	if id.Subject != "" && cert.Subject.CommonName != id.Subject {
+		return false
+	}
+	if id.SAN != "" && !slices.Contains(SANs(cert), id.SAN) {
+		return false
+	}
+	if id.SPKI != "" && SPKIFingerprint(cert) != id.SPKI {
+		return false
+	}
+	return true
+}
+
+// SANs 证书的全部 SAN，URI 与 IP 转为字符串
+func SANs(cert *x509.Certificate) []string {
+	names := make([]string, 0, len(cert.DNSNames)+len(cert.URIs)+len(cert.EmailAddresses)+len(cert.IPAddresses))
+	names = append(names, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+// SPKIFingerprint 公钥指纹，证书续期但不更换密钥时保持不变
+func SPKIFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint 证书指纹，证书 DER 的 SHA-256
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeFingerprint 统一指纹格式：小写、去掉冒号
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// Authority 按规则将客户端证书映射为身份，并检查吊销状态
+type Authority struct {
+	identities []Identity
+	issuers    []*x509.Certificate // 用于校验吊销列表签名的 CA 证书
+	crlFiles   []string
+	denied     map[string]struct{} // 吊销的证书指纹或公钥指纹
+
+	mu      sync.RWMutex
+	revoked map[string]struct{} // 吊销列表中的证书，键为签发者与序列号
+}
+
+// New 创建 Authority，issuers 为签发客户端证书的 CA，吊销列表须由其中之一签名
+func New(identities []Identity, issuers []*x509.Certificate, crlFiles []string, deny []string) (*Authority, error) {
+	for i, id := range identities {
+		if id.Subject == "" && id.SAN == "" && id.SPKI == "" {
+			return nil, fmt.Errorf("identity %q: subject, san or spki is required", id.Name)
+		}
+		if id.Username == "" {
+			return nil, fmt.Errorf("identity %q: username is required", id.Name)
+		}
+		identities[i].SPKI = normalizeFingerprint(id.SPKI)
+	}
+
+	a := &Authority{
+		identities: identities,
+		issuers:    issuers,
+		crlFiles:   crlFiles,
+		denied:     make(map[string]struct{}, len(deny)),
+		revoked:    make(map[string]struct{}),
+	}
+	for _, fingerprint := range deny {
+		a.denied[normalizeFingerprint(fingerprint)] = struct{}{}
+	}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Match 返回证书匹配的第一条规则
+func (a *Authority) Match(cert *x509.Certificate) (Identity, bool) {
+	for _, id := range a.identities {
+		if id.matches(cert) {
+			return id, true
+		}
+	}
+	return Identity{}, false
+}
+
+// Revoked 证书是否在拒绝列表或吊销列表中
+func (a *Authority) Revoked(cert *x509.Certificate) bool {
+	if _, ok := a.denied[Fingerprint(cert)]; ok {
+		return true
+	}
+	if _, ok := a.denied[SPKIFingerprint(cert)]; ok {
+		return true
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	_, ok := a.revoked[revokedKey(cert.RawIssuer, cert.SerialNumber.String())]
+	return ok
+}
+
+// Reload 重新加载吊销列表，任一文件无效时保留原列表并返回错误
+func (a *Authority) Reload() error {
+	revoked := make(map[string]struct{})
+	for _, file := range a.crlFiles {
+		crl, err := a.loadCRL(file)
+		if err != nil {
+			return fmt.Errorf("load crl %s: %w", file, err)
+		}
+		// 不检查 NextUpdate，过期的吊销列表仍然生效，避免已吊销的证书因此恢复可用
+		for _, entry := range crl.RevokedCertificateEntries {
+			revoked[revokedKey(crl.RawIssuer, entry.SerialNumber.String())] = struct{}{}
+		}
+	}
+
+	a.mu.Lock()
+	a.revoked = revoked
+	a.mu.Unlock()
+	return nil
+}
+
+// loadCRL 读取 PEM 或 DER 格式的吊销列表并校验签名
+func (a *Authority) loadCRL(file string) (*x509.RevocationList, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, issuer := range a.issuers {
+		if crl.CheckSignatureFrom(issuer) == nil {
+			return crl, nil
+		}
+	}
+	return nil, errors.New("crl is not signed by client ca")
+}
+
+// revokedKey 吊销列表的键，序列号仅在同一签发者内唯一
+func revokedKey(rawIssuer []byte, serial string) string {
+	return string(rawIssuer) + "/" + serial
+}
@@ -0,0 +1,98 @@
+package certwatch
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Watcher 服务器证书，证书或私钥文件变更后重新加载，无需重启服务
+type Watcher struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	version string // 证书与私钥文件的修改时间及大小，用于判断文件是否变更
+}
+
+// New 加载证书与私钥，创建 Watcher
+func New(certFile string, keyFile string) (*Watcher, error) {
+	w := &Watcher{certFile: certFile, keyFile: keyFile}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// GetCertificate 返回当前证书，用于 tls.Config.GetCertificate
+func (w *Watcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cert, nil
+}
+
+// Reload 重新加载证书，文件无效时保留原证书并返回错误
+func (w *Watcher) Reload() error {
+	version, err := w.fileVersion()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.cert = &cert
+	w.version = version
+	w.mu.Unlock()
+	return nil
+}
+
+// ReloadIfChanged 文件变更时重新加载证书，返回是否已加载新证书
+func (w *Watcher) ReloadIfChanged() (bool, error) {
+	version, err := w.fileVersion()
+	if err != nil {
+		return false, err
+	}
+
+	w.mu.RLock()
+	changed := version != w.version
+	w.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	// 证书与私钥可能尚未同时写入，加载失败时下次检查继续尝试
+	if err := w.Reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// NotAfter 当前证书的过期时间
+func (w *Watcher) NotAfter() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.cert == nil || w.cert.Leaf == nil {
+		return time.Time{}
+	}
+	return w.cert.Leaf.NotAfter
+}
+
+// fileVersion 证书与私钥文件的修改时间及大小
+func (w *Watcher) fileVersion() (string, error) {
+	var version string
+	for _, file := range []string{w.certFile, w.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		version += fmt.Sprintf("%d/%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return version, nil
+}
@@ -13,6 +13,7 @@ type GeoIP interface {
 	Search(ip []byte) (string, error)
 	SearchByStr(ip string) (string, error)
 	Parse(data string) *GeoIPData
+	Close() error
 }
 
 // GeoIPData 地理IP数据
@@ -51,6 +52,11 @@ func (t *GeoIPManager) Parse(data string) *GeoIPData {
 	return t.GeoIP.Parse(data)
 }
 
+// Close 关闭IP地址库
+func (t *GeoIPManager) Close() error {
+	return t.GeoIP.Close()
+}
+
 // IP2Long 将IP转换为long
 func (t *GeoIPManager) IP2Long(ip string) (uint32, error) {
 	ps := strings.Split(strings.TrimSpace(ip), ".")
@@ -103,3 +103,11 @@ func (t *IP2Region) Parse(data string) *GeoIPData {
 		ISP:      isp,
 	}
 }
+
+// Close 关闭 IP2Region 数据库文件
+func (t *IP2Region) Close() error {
+	if t.searcher != nil {
+		t.searcher.Close()
+	}
+	return nil
+}
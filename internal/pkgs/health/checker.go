@@ -0,0 +1,372 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked 目标地址、重定向地址或实际连接的 IP 地址被禁止访问
+var ErrBlocked = errors.New("target is blocked")
+
+// Target 待检测的目标地址
+type Target struct {
+	ID  int64
+	URL string
+}
+
+// Result 检测结果
+type Result struct {
+	ID         int64
+	StatusCode int // 0 表示请求失败
+	Latency    time.Duration
+	CheckedAt  time.Time
+	Err        error
+	Blocked    bool // 被禁止访问，未完成检测
+}
+
+// Broken 目标地址是否失效，被禁止访问的目标不视为失效
+func (r Result) Broken() bool {
+	return !r.Blocked && (r.StatusCode == 0 || r.StatusCode >= http.StatusBadRequest)
+}
+
+// Options 检测器配置
+type Options struct {
+	Client      *http.Client  // 为空时根据 Timeout 创建
+	Timeout     time.Duration // 单次请求超时
+	Concurrency int           // 最大并发数
+	HostDelay   time.Duration // 同一主机两次请求的最小间隔
+	Method      string        // HEAD 或 GET
+	UserAgent   string
+	// Allow 检查目标地址及每一跳重定向地址是否允许访问，为空时不检查
+	Allow func(ctx context.Context, rawURL string) error
+	// AllowIP 检查实际连接的 IP 地址是否允许访问，避免检查后域名被重新解析到内网地址；
+	// 为空或 Client 不为空时不检查
+	AllowIP func(addr netip.Addr) error
+}
+
+// maxRedirects 最多跟随的重定向次数，与 net/http 默认值一致
+const maxRedirects = 10
+
+// Checker 目标地址健康检测器
+type Checker struct {
+	client    *http.Client
+	method    string
+	userAgent string
+	hostDelay time.Duration
+	allow     func(ctx context.Context, rawURL string) error
+	workers   int
+	sem       chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*hostGate
+}
+
+// hostGate 同一主机的请求闸门，保证请求串行且间隔不小于 hostDelay
+type hostGate struct {
+	mu   sync.Mutex
+	last time.Time
+	refs int // 正在使用闸门的请求数，由 Checker.mu 保护
+}
+
+// NewChecker 创建检测器
+func NewChecker(opts Options) *Checker {
+	client := &http.Client{Timeout: opts.Timeout}
+	if opts.Client != nil {
+		// 复制一份，避免修改调用方的 CheckRedirect
+		copied := *opts.Client
+		client = &copied
+	} else if opts.AllowIP != nil {
+		client.Transport = newTransport(opts.AllowIP)
+	}
+	if opts.Allow != nil {
+		next := client.CheckRedirect
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if err := opts.Allow(req.Context(), req.URL.String()); err != nil {
+				return fmt.Errorf("%w: %w", ErrBlocked, err)
+			}
+			if next != nil {
+				return next(req, via)
+			}
+			if len(via) >= maxRedirects {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+
+	method := strings.ToUpper(opts.Method)
+	if method != http.MethodGet {
+		method = http.MethodHead
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return &Checker{
+		client:    client,
+		method:    method,
+		userAgent: opts.UserAgent,
+		hostDelay: opts.HostDelay,
+		allow:     opts.Allow,
+		workers:   concurrency,
+		sem:       make(chan struct{}, concurrency),
+		hosts:     make(map[string]*hostGate),
+	}
+}
+
+// newTransport 创建直连目标的 Transport，建立连接前检查实际连接的 IP 地址
+func newTransport(allowIP func(addr netip.Addr) error) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if err := allowIP(addrPort.Addr()); err != nil {
+				return fmt.Errorf("%w: %w", ErrBlocked, err)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 经代理连接时实际连接的是代理地址，无法检查目标地址
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// Check 并发检测所有目标，结果顺序与 targets 一致
+//
+// 最多同时检测 Concurrency 个目标，同一主机的目标依次检测；
+// 等待主机间隔的目标不占用并发名额，避免慢主机拖住其他主机
+func (t *Checker) Check(ctx context.Context, targets []Target) []Result {
+	results := make([]Result, len(targets))
+	defer t.prune()
+
+	// 按主机分组，组内保持原有顺序
+	var hosts []string
+	queues := make(map[string][]int)
+	for i, target := range targets {
+		u, err := url.Parse(target.URL)
+		if err != nil {
+			results[i] = Result{ID: target.ID, CheckedAt: time.Now().Local(), Err: err}
+			continue
+		}
+		if _, ok := queues[u.Host]; !ok {
+			hosts = append(hosts, u.Host)
+		}
+		queues[u.Host] = append(queues[u.Host], i)
+	}
+
+	finished := make(chan string)
+	busy := make(map[string]bool)
+	for {
+		// 派发已到间隔的主机，并记录最早可派发的时间
+		wait := time.Duration(-1)
+		for _, host := range hosts {
+			if len(busy) >= t.workers {
+				break
+			}
+			queue := queues[host]
+			if len(queue) == 0 || busy[host] || ctx.Err() != nil {
+				continue
+			}
+			if d := time.Until(t.readyAt(host)); d > 0 {
+				if wait < 0 || d < wait {
+					wait = d
+				}
+				continue
+			}
+
+			busy[host] = true
+			queues[host] = queue[1:]
+			go func(host string, i int) {
+				results[i] = t.CheckOne(ctx, targets[i])
+				finished <- host
+			}(host, queue[0])
+		}
+
+		if len(busy) == 0 && (wait < 0 || ctx.Err() != nil) {
+			break
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case host := <-finished:
+			delete(busy, host)
+		case <-timeout:
+		case <-ctx.Done():
+			// 等待进行中的检测随 ctx 结束
+			for len(busy) > 0 {
+				delete(busy, <-finished)
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+
+	// ctx 结束后未检测的目标
+	for _, host := range hosts {
+		for _, i := range queues[host] {
+			results[i] = Result{ID: targets[i].ID, CheckedAt: time.Now().Local(), Err: ctx.Err()}
+		}
+	}
+
+	return results
+}
+
+// CheckOne 检测单个目标
+func (t *Checker) CheckOne(ctx context.Context, target Target) Result {
+	result := Result{ID: target.ID}
+
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		result.CheckedAt = time.Now().Local()
+		result.Err = err
+		return result
+	}
+
+	gate := t.acquire(u.Host)
+	defer t.release(gate)
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+
+	if wait := time.Until(gate.last.Add(t.hostDelay)); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			result.CheckedAt = time.Now().Local()
+			result.Err = ctx.Err()
+			return result
+		case <-timer.C:
+		}
+	}
+
+	// 先取得主机闸门再占用全局并发名额
+	select {
+	case <-ctx.Done():
+		result.CheckedAt = time.Now().Local()
+		result.Err = ctx.Err()
+		return result
+	case t.sem <- struct{}{}:
+	}
+	defer func() { <-t.sem }()
+	defer func() { gate.last = time.Now() }()
+
+	if t.allow != nil {
+		if err := t.allow(ctx, target.URL); err != nil {
+			result.CheckedAt = time.Now().Local()
+			result.Err = fmt.Errorf("%w: %w", ErrBlocked, err)
+			result.Blocked = true
+			return result
+		}
+	}
+
+	start := time.Now()
+	code, err := t.do(ctx, t.method, target.URL)
+	// 部分服务器不支持 HEAD，回退到 GET
+	if err == nil && t.method == http.MethodHead &&
+		(code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		start = time.Now()
+		code, err = t.do(ctx, http.MethodGet, target.URL)
+	}
+
+	result.StatusCode = code
+	result.Latency = time.Since(start)
+	result.CheckedAt = time.Now().Local()
+	result.Err = err
+	result.Blocked = errors.Is(err, ErrBlocked)
+	return result
+}
+
+// do 发送请求并返回状态码
+func (t *Checker) do(ctx context.Context, method string, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// acquire 获取主机对应的闸门
+func (t *Checker) acquire(host string) *hostGate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gate, ok := t.hosts[host]
+	if !ok {
+		gate = &hostGate{}
+		t.hosts[host] = gate
+	}
+	gate.refs++
+	return gate
+}
+
+// release 释放主机闸门
+func (t *Checker) release(gate *hostGate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gate.refs--
+}
+
+// readyAt 主机下一次允许请求的时间
+func (t *Checker) readyAt(host string) time.Time {
+	t.mu.Lock()
+	gate, ok := t.hosts[host]
+	t.mu.Unlock()
+	if !ok {
+		return time.Time{}
+	}
+
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	return gate.last.Add(t.hostDelay)
+}
+
+// prune 清理无人使用且已过间隔的主机闸门，避免闸门随主机数量一直增长
+func (t *Checker) prune() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for host, gate := range t.hosts {
+		if gate.refs > 0 || !gate.mu.TryLock() {
+			continue
+		}
+		if time.Since(gate.last) >= t.hostDelay {
+			delete(t.hosts, host)
+		}
+		gate.mu.Unlock()
+	}
+}
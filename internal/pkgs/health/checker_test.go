@@ -0,0 +1,318 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckOneHeadFallback(t *testing.T) {
+	for _, code := range []int{http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		var methods []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			if r.Method == http.MethodHead {
+				w.WriteHeader(code)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		checker := NewChecker(Options{Timeout: time.Second})
+		result := checker.CheckOne(context.Background(), Target{ID: 1, URL: srv.URL})
+		srv.Close()
+
+		if result.StatusCode != http.StatusOK || result.Broken() {
+			t.Errorf("HEAD %d: got status %d, err %v", code, result.StatusCode, result.Err)
+		}
+		if len(methods) != 2 || methods[0] != http.MethodHead || methods[1] != http.MethodGet {
+			t.Errorf("HEAD %d: got methods %v, want [HEAD GET]", code, methods)
+		}
+	}
+}
+
+func TestCheckOneNoFallbackForGet(t *testing.T) {
+	var count int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer srv.Close()
+
+	checker := NewChecker(Options{Timeout: time.Second, Method: "get"})
+	result := checker.CheckOne(context.Background(), Target{URL: srv.URL})
+	if result.StatusCode != http.StatusMethodNotAllowed || !result.Broken() {
+		t.Errorf("got status %d, want 405 and broken", result.StatusCode)
+	}
+	if count != 1 {
+		t.Errorf("got %d requests, want 1", count)
+	}
+}
+
+func TestCheckHostDelay(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	checker := NewChecker(Options{Timeout: time.Second, Concurrency: 4, HostDelay: delay})
+	targets := []Target{{ID: 1, URL: srv.URL}, {ID: 2, URL: srv.URL + "/a"}, {ID: 3, URL: srv.URL + "/b"}}
+	results := checker.Check(context.Background(), targets)
+
+	for i, result := range results {
+		if result.ID != targets[i].ID || result.StatusCode != http.StatusOK {
+			t.Errorf("result %d: got id %d status %d, err %v", i, result.ID, result.StatusCode, result.Err)
+		}
+	}
+	if len(times) != len(targets) {
+		t.Fatalf("got %d requests, want %d", len(times), len(targets))
+	}
+	for i := 1; i < len(times); i++ {
+		// 留少量余量给计时误差
+		if gap := times[i].Sub(times[i-1]); gap < delay-10*time.Millisecond {
+			t.Errorf("request %d: gap %v shorter than host delay %v", i, gap, delay)
+		}
+	}
+}
+
+func TestCheckSlowHostDoesNotBlockOthers(t *testing.T) {
+	const delay = 500 * time.Millisecond
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer slow.Close()
+
+	var hitAt time.Time
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hitAt = time.Now()
+	}))
+	defer fast.Close()
+
+	// 并发数为 1 时，等待主机间隔的任务不能占住唯一的名额
+	checker := NewChecker(Options{Timeout: time.Second, Concurrency: 1, HostDelay: delay})
+	start := time.Now()
+	results := checker.Check(context.Background(), []Target{
+		{ID: 1, URL: slow.URL + "/a"},
+		{ID: 2, URL: slow.URL + "/b"},
+		{ID: 3, URL: fast.URL},
+	})
+
+	if results[2].StatusCode != http.StatusOK {
+		t.Fatalf("fast host: got status %d, err %v", results[2].StatusCode, results[2].Err)
+	}
+	if elapsed := hitAt.Sub(start); elapsed >= delay {
+		t.Errorf("fast host checked after %v, blocked by slow host delay %v", elapsed, delay)
+	}
+}
+
+func TestCheckOneTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	checker := NewChecker(Options{Timeout: 50 * time.Millisecond})
+	result := checker.CheckOne(context.Background(), Target{URL: srv.URL})
+	if !result.Broken() || result.StatusCode != 0 || result.Err == nil {
+		t.Errorf("got status %d, err %v, want broken with error", result.StatusCode, result.Err)
+	}
+}
+
+func TestCheckOneConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	addr := srv.URL
+	srv.Close()
+
+	checker := NewChecker(Options{Timeout: time.Second})
+	result := checker.CheckOne(context.Background(), Target{URL: addr})
+	if !result.Broken() || result.StatusCode != 0 || result.Err == nil {
+		t.Errorf("got status %d, err %v, want broken with error", result.StatusCode, result.Err)
+	}
+}
+
+func TestCheckOneFollowsRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/missing", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	checker := NewChecker(Options{Timeout: time.Second})
+
+	result := checker.CheckOne(context.Background(), Target{URL: srv.URL + "/a"})
+	if result.StatusCode != http.StatusNoContent || result.Broken() {
+		t.Errorf("redirect chain: got status %d, err %v, want 204", result.StatusCode, result.Err)
+	}
+
+	result = checker.CheckOne(context.Background(), Target{URL: srv.URL + "/gone"})
+	if result.StatusCode != http.StatusNotFound || !result.Broken() {
+		t.Errorf("redirect to missing page: got status %d, want 404 and broken", result.StatusCode)
+	}
+}
+
+func TestCheckOneAllowRedirectHop(t *testing.T) {
+	errBlocked := errors.New("blocked")
+
+	var blockedHit bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		blockedHit = true
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var checked []string
+	checker := NewChecker(Options{
+		Timeout: time.Second,
+		Allow: func(ctx context.Context, rawURL string) error {
+			checked = append(checked, rawURL)
+			if rawURL == srv.URL+"/internal" {
+				return errBlocked
+			}
+			return nil
+		},
+	})
+
+	result := checker.CheckOne(context.Background(), Target{URL: srv.URL + "/start"})
+	if !result.Blocked || result.Broken() || !errors.Is(result.Err, errBlocked) {
+		t.Errorf("got status %d, err %v, want blocked and not broken", result.StatusCode, result.Err)
+	}
+	if blockedHit {
+		t.Error("blocked redirect hop was requested")
+	}
+	if len(checked) != 2 {
+		t.Errorf("got allow calls %v, want target and redirect hop", checked)
+	}
+
+	result = checker.CheckOne(context.Background(), Target{URL: srv.URL + "/internal"})
+	if !result.Blocked || !errors.Is(result.Err, errBlocked) || blockedHit {
+		t.Errorf("blocked target: got err %v, requested %v", result.Err, blockedHit)
+	}
+}
+
+func TestCheckOneAllowIP(t *testing.T) {
+	errPrivate := errors.New("private")
+
+	var hit bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	// 域名检查通过，但实际连接的是回环地址
+	var dialed []netip.Addr
+	checker := NewChecker(Options{
+		Timeout: time.Second,
+		Allow: func(ctx context.Context, rawURL string) error {
+			return nil
+		},
+		AllowIP: func(addr netip.Addr) error {
+			dialed = append(dialed, addr)
+			if addr.IsLoopback() {
+				return errPrivate
+			}
+			return nil
+		},
+	})
+
+	result := checker.CheckOne(context.Background(), Target{URL: srv.URL})
+	if !result.Blocked || result.Broken() || !errors.Is(result.Err, errPrivate) {
+		t.Errorf("got status %d, err %v, want blocked by dialed address", result.StatusCode, result.Err)
+	}
+	if hit {
+		t.Error("blocked address was requested")
+	}
+	if len(dialed) == 0 || !dialed[0].IsLoopback() {
+		t.Errorf("got dialed addresses %v, want loopback", dialed)
+	}
+}
+
+func TestCheckConcurrencyLimit(t *testing.T) {
+	const concurrency = 2
+
+	var (
+		mu      sync.Mutex
+		running int
+		peak    int
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	var targets []Target
+	for i := range 6 {
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+		targets = append(targets, Target{ID: int64(i), URL: srv.URL}, Target{ID: int64(i), URL: srv.URL + "/a"})
+	}
+
+	checker := NewChecker(Options{Timeout: time.Second, Concurrency: concurrency})
+	results := checker.Check(context.Background(), targets)
+
+	for i, result := range results {
+		if result.StatusCode != http.StatusOK {
+			t.Errorf("result %d: got status %d, err %v", i, result.StatusCode, result.Err)
+		}
+	}
+	if peak > concurrency {
+		t.Errorf("got %d concurrent requests, want at most %d", peak, concurrency)
+	}
+	if len(checker.hosts) != 0 {
+		t.Errorf("got %d host gates after check, want idle gates pruned", len(checker.hosts))
+	}
+}
+
+func TestCheckCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	checker := NewChecker(Options{Timeout: time.Second, HostDelay: time.Second})
+	results := checker.Check(ctx, []Target{{ID: 1, URL: srv.URL}, {ID: 2, URL: srv.URL + "/a"}})
+	for i, result := range results {
+		if result.ID != int64(i+1) || !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("result %d: got id %d err %v, want canceled", i, result.ID, result.Err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package hmacsign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Scheme Authorization 请求头中的认证方案
+	Scheme = "HMAC-SHA256"
+
+	nonceSize     = 16 // 随机数字节数
+	nonceMinChars = 16 // 随机数最短长度
+	nonceMaxChars = 64 // 随机数最长长度
+)
+
+// Params 签名参数，格式为 HMAC-SHA256 KeyId=1, Timestamp=1700000000, Nonce=xxx, Signature=xxx
+type Params struct {
+	KeyID     string // 密钥ID
+	Timestamp int64  // 签名时间（Unix 秒）
+	Nonce     string // 随机数，同一密钥在有效期内不可重复
+	Signature string // 签名（十六进制）
+}
+
+// String 生成 Authorization 请求头的值
+func (p Params) String() string {
+	return Scheme + " KeyId=" + p.KeyID +
+		", Timestamp=" + strconv.FormatInt(p.Timestamp, 10) +
+		", Nonce=" + p.Nonce +
+		", Signature=" + p.Signature
+}
+
+// Parse 解析 Authorization 请求头，非本方案或格式错误时返回 false
+func Parse(header string) (Params, bool) {
+	var p Params
+	rest, ok := strings.CutPrefix(header, Scheme+" ")
+	if !ok {
+		return p, false
+	}
+
+	for _, part := range strings.Split(rest, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || value == "" {
+			return p, false
+		}
+		switch name {
+		case "KeyId":
+			p.KeyID = value
+		case "Timestamp":
+			ts, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return p, false
+			}
+			p.Timestamp = ts
+		case "Nonce":
+			p.Nonce = value
+		case "Signature":
+			p.Signature = value
+		default:
+			return p, false
+		}
+	}
+
+	if p.KeyID == "" || p.Timestamp == 0 || p.Signature == "" || !validNonce(p.Nonce) {
+		return p, false
+	}
+	return p, true
+}
+
+// validNonce 随机数仅允许字母、数字、- 与 _，避免拼接缓存键时产生歧义
+func validNonce(nonce string) bool {
+	if len(nonce) < nonceMinChars || len(nonce) > nonceMaxChars {
+		return false
+	}
+	for _, r := range nonce {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewNonce 生成随机数
+func NewNonce() string {
+	buf := make([]byte, nonceSize)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// BodyHash 计算请求体的 SHA-256（十六进制），空请求体同样参与计算
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// StringToSign 构造待签名字符串，各部分以换行分隔：
+// 请求方法、转义后的路径、原始查询字符串、时间戳、随机数、请求体哈希
+func StringToSign(method string, path string, rawQuery string, p Params, bodyHash string) string {
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		rawQuery,
+		strconv.FormatInt(p.Timestamp, 10),
+		p.Nonce,
+		bodyHash,
+	}, "\n")
+}
+
+// Sign 计算签名
+func Sign(secret string, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Equal 以固定时间比较签名
+func Equal(secret string, stringToSign string, signature string) bool {
+	expected := Sign(secret, stringToSign)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// SignRequest 为请求签名并设置 Authorization 请求头，请求体读取后会被替换为可重复读取的副本
+func SignRequest(req *http.Request, keyID string, secret string, now time.Time) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return err
+		}
+		body = data
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	p := Params{
+		KeyID:     keyID,
+		Timestamp: now.Unix(),
+		Nonce:     NewNonce(),
+	}
+	p.Signature = Sign(secret, StringToSign(req.Method, req.URL.EscapedPath(), req.URL.RawQuery, p, BodyHash(body)))
+	req.Header.Set("Authorization", p.String())
+	return nil
+}
+
+// Transport 为每个请求签名的 http.RoundTripper
+type Transport struct {
+	KeyID  string
+	Secret string
+	Base   http.RoundTripper // 为空时使用 http.DefaultTransport
+}
+
+// RoundTrip 复制请求并签名后发送，重试或跳转时会重新生成时间戳与随机数
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	if err := SignRequest(signed, t.KeyID, t.Secret, time.Now()); err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// NonceStore 已使用随机数的存储
+type NonceStore interface {
+	// Claim 记录随机数，已存在时返回 false
+	Claim(key string, ttl time.Duration) (bool, error)
+}
+
+// ErrInvalid 签名无效、已过期或随机数重复
+var ErrInvalid = errors.New("hmacsign: invalid signature")
+
+// Verifier 校验签名时间与随机数
+type Verifier struct {
+	nonces  NonceStore
+	prefix  string
+	maxSkew time.Duration
+}
+
+// NewVerifier 创建 Verifier，maxSkew 为签名时间与服务器时间允许的最大偏差
+// 随机数保留 2*maxSkew，覆盖时间戳可被接受的整个区间
+func NewVerifier(nonces NonceStore, prefix string, maxSkew time.Duration) *Verifier {
+	if maxSkew <= 0 {
+		maxSkew = 5 * time.Minute
+	}
+	return &Verifier{nonces: nonces, prefix: prefix, maxSkew: maxSkew}
+}
+
+// CheckTime 校验签名时间是否在允许的偏差内
+func (v *Verifier) CheckTime(p Params, now time.Time) bool {
+	skew := now.Sub(time.Unix(p.Timestamp, 0))
+	return skew <= v.maxSkew && skew >= -v.maxSkew
+}
+
+// Verify 校验时间与签名，通过后占用随机数，失败时返回 ErrInvalid，存储出错时返回其错误
+func (v *Verifier) Verify(p Params, secret string, stringToSign string, now time.Time) error {
+	if secret == "" || !v.CheckTime(p, now) || !Equal(secret, stringToSign, p.Signature) {
+		return ErrInvalid
+	}
+
+	// 签名通过后再占用随机数，避免伪造请求消耗他人的随机数
+	ok, err := v.nonces.Claim(v.prefix+p.KeyID+":"+p.Nonce, 2*v.maxSkew)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// MemoryNonceStore 进程内随机数存储，用于未启用缓存时
+type MemoryNonceStore struct {
+	mu      sync.Mutex
+	items   map[string]time.Time
+	sweptAt time.Time
+}
+
+// memorySweepInterval 清理过期随机数的最短间隔
+const memorySweepInterval = time.Minute
+
+// NewMemoryNonceStore 创建进程内随机数存储
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{items: make(map[string]time.Time)}
+}
+
+// Claim 记录随机数，顺带清理过期记录
+func (s *MemoryNonceStore) Claim(key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sweptAt) >= memorySweepInterval {
+		for k, expiresAt := range s.items {
+			if !expiresAt.After(now) {
+				delete(s.items, k)
+			}
+		}
+		s.sweptAt = now
+	}
+
+	if expiresAt, ok := s.items[key]; ok && expiresAt.After(now) {
+		return false, nil
+	}
+	s.items[key] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,194 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnsupportedAlg = errors.New("unsupported jwt algorithm")
+	ErrMissingKey     = errors.New("jwt key is missing")
+	ErrCannotSign     = errors.New("jwt private key is not configured")
+)
+
+// Options JWT 配置
+type Options struct {
+	Algorithm      string        // HS256、RS256 或 EdDSA
+	Secret         string        // HS256 密钥
+	PrivateKeyFile string        // RS256/EdDSA 私钥（PEM），为空时仅能校验
+	PublicKeyFile  string        // RS256/EdDSA 公钥（PEM），为空时由私钥推导
+	Issuer         string        // 签发者
+	Audience       string        // 接收方，为空表示不校验
+	AccessTTL      time.Duration // 访问令牌有效期
+	Leeway         time.Duration // 校验时间时允许的误差
+}
+
+// Claims 访问令牌声明
+type Claims struct {
+	jwt.RegisteredClaims
+	Name      string `json:"name"`          // 用户名
+	Role      string `json:"role"`          // 用户角色
+	Scope     string `json:"scope"`         // 权限范围，以空格分隔
+	SessionID int64  `json:"sid,omitempty"` // 对应的刷新令牌会话ID
+}
+
+// Manager 签发与校验 JWT
+type Manager struct {
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	issuer    string
+	audience  string
+	accessTTL time.Duration
+	parser    *jwt.Parser
+}
+
+// New 创建 JWT 管理器
+func New(opts Options) (*Manager, error) {
+	m := &Manager{
+		issuer:    opts.Issuer,
+		audience:  opts.Audience,
+		accessTTL: opts.AccessTTL,
+	}
+
+	switch opts.Algorithm {
+	case AlgHS256:
+		if opts.Secret == "" {
+			return nil, ErrMissingKey
+		}
+		m.method = jwt.SigningMethodHS256
+		m.signKey = []byte(opts.Secret)
+		m.verifyKey = m.signKey
+	case AlgRS256, AlgEdDSA:
+		if err := m.loadKeys(opts); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, opts.Algorithm)
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+	m.parser = jwt.NewParser(parserOpts...)
+
+	return m, nil
+}
+
+// CanSign 是否可以签发令牌
+func (m *Manager) CanSign() bool {
+	return m.signKey != nil
+}
+
+// Sign 签发访问令牌，自动填充签发者、接收方、签发时间、过期时间与令牌ID
+func (m *Manager) Sign(claims Claims) (string, time.Time, error) {
+	if !m.CanSign() {
+		return "", time.Time{}, ErrCannotSign
+	}
+
+	nowTime := time.Now()
+	expiresAt := nowTime.Add(m.accessTTL)
+	claims.Issuer = m.issuer
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+	claims.ID = rand.Text()
+	claims.IssuedAt = jwt.NewNumericDate(nowTime)
+	claims.NotBefore = jwt.NewNumericDate(nowTime)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	token, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Parse 校验签名与有效期并返回声明
+func (m *Manager) Parse(token string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := m.parser.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return m.verifyKey, nil
+	}); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// loadKeys 加载 RS256/EdDSA 密钥，至少需要私钥或公钥之一
+func (m *Manager) loadKeys(opts Options) error {
+	if opts.PrivateKeyFile == "" && opts.PublicKeyFile == "" {
+		return ErrMissingKey
+	}
+
+	if opts.PrivateKeyFile != "" {
+		data, err := os.ReadFile(opts.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+
+		var signer crypto.Signer
+		if opts.Algorithm == AlgRS256 {
+			m.method = jwt.SigningMethodRS256
+			signer, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+		} else {
+			m.method = jwt.SigningMethodEdDSA
+			var key crypto.PrivateKey
+			if key, err = jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+				signer = key.(crypto.Signer)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("parse jwt private key: %w", err)
+		}
+		m.signKey = signer
+		m.verifyKey = signer.Public()
+	}
+
+	if opts.PublicKeyFile != "" {
+		data, err := os.ReadFile(opts.PublicKeyFile)
+		if err != nil {
+			return err
+		}
+
+		var publicKey any
+		if opts.Algorithm == AlgRS256 {
+			m.method = jwt.SigningMethodRS256
+			var key *rsa.PublicKey
+			key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+			publicKey = key
+		} else {
+			m.method = jwt.SigningMethodEdDSA
+			publicKey, err = jwt.ParseEdPublicKeyFromPEM(data)
+		}
+		if err != nil {
+			return fmt.Errorf("parse jwt public key: %w", err)
+		}
+		m.verifyKey = publicKey
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// Store 失败记录存储，计数须为原子操作以便多实例共享
+type Store interface {
+	// Incr 计数加一并返回加一后的值，同时将过期时间设为 ttl
+	Incr(key string, ttl time.Duration) (int64, error)
+	// Decr 计数减一并返回减一后的值，同时将过期时间设为 ttl
+	Decr(key string, ttl time.Duration) (int64, error)
+	// Get 读取值，不存在时返回 0
+	Get(key string) (int64, error)
+	// Set 保存值
+	Set(key string, value int64, ttl time.Duration)
+	Delete(key string)
+}
+
+// Policy 退避策略
+type Policy struct {
+	Attempts   int           // 允许连续失败的次数，超过后开始退避
+	Backoff    time.Duration // 首次退避时长，此后每次失败翻倍
+	MaxLockout time.Duration // 最长锁定时长
+	Window     time.Duration // 最后一次失败后记录的保留时长，另加最长锁定时长以免锁定期间过期
+}
+
+// Guard 按键记录连续失败次数并按指数退避锁定
+// 失败次数与锁定截止时间分两个键保存，失败次数通过原子自增累计
+type Guard struct {
+	store  Store
+	prefix string
+	policy Policy
+}
+
+// New 创建 Guard，prefix 用于区分同一存储中的不同 Guard
+func New(store Store, prefix string, policy Policy) *Guard {
+	if policy.Attempts < 1 {
+		policy.Attempts = 1
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = time.Second
+	}
+	if policy.MaxLockout < policy.Backoff {
+		policy.MaxLockout = policy.Backoff
+	}
+	return &Guard{store: store, prefix: prefix, policy: policy}
+}
+
+// Check 返回剩余的锁定时长，未锁定时返回 0
+func (g *Guard) Check(key string, now time.Time) time.Duration {
+	until, err := g.store.Get(g.prefix + key + ":until")
+	if err != nil || until <= now.UnixNano() {
+		return 0
+	}
+	return time.Duration(until - now.UnixNano())
+}
+
+// Fail 记录一次失败，返回因此产生的锁定时长，未达到允许次数时返回 0
+// 在校验凭据前调用即可将尝试先计为失败，并发请求各自占用一次计数，校验通过后调用 Undo 撤销
+func (g *Guard) Fail(key string, now time.Time) time.Duration {
+	failures, err := g.store.Incr(g.prefix+key, g.policy.Window+g.policy.MaxLockout)
+	if err != nil {
+		return 0
+	}
+
+	over := failures - int64(g.policy.Attempts)
+	if over <= 0 {
+		return 0
+	}
+
+	delay := g.policy.MaxLockout
+	// 超过 62 次翻倍必然溢出，直接取上限
+	if over <= 62 {
+		if d := g.policy.Backoff << (over - 1); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	g.store.Set(g.prefix+key+":until", now.Add(delay).UnixNano(), delay)
+	return delay
+}
+
+// Undo 撤销 Fail 记录的一次失败，不解除已产生的锁定
+func (g *Guard) Undo(key string) {
+	_, _ = g.store.Decr(g.prefix+key, g.policy.Window+g.policy.MaxLockout)
+}
+
+// Reset 清除失败记录
+func (g *Guard) Reset(key string) {
+	g.store.Delete(g.prefix + key)
+	g.store.Delete(g.prefix + key + ":until")
+}
+
+// MemoryStore 进程内存储，用于未启用缓存时
+type MemoryStore struct {
+	mu      sync.Mutex
+	items   map[string]memoryItem
+	sweptAt time.Time
+}
+
+type memoryItem struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// memorySweepInterval 清理过期记录的最短间隔
+const memorySweepInterval = time.Minute
+
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]memoryItem)}
+}
+
+// Incr 计数加一，顺带清理过期记录
+func (s *MemoryStore) Incr(key string, ttl time.Duration) (int64, error) {
+	return s.add(key, 1, ttl), nil
+}
+
+// Decr 计数减一
+func (s *MemoryStore) Decr(key string, ttl time.Duration) (int64, error) {
+	return s.add(key, -1, ttl), nil
+}
+
+// Get 读取值
+func (s *MemoryStore) Get(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[key]
+	if !ok || !item.expiresAt.After(time.Now()) {
+		return 0, nil
+	}
+	return item.value, nil
+}
+
+// Set 保存值，顺带清理过期记录
+func (s *MemoryStore) Set(key string, value int64, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.sweep()
+	s.items[key] = memoryItem{value: value, expiresAt: now.Add(ttl)}
+}
+
+// Delete 删除记录
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, key)
+}
+
+// add 计数增加 delta，计数不小于 0
+func (s *MemoryStore) add(key string, delta int64, ttl time.Duration) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.sweep()
+	item, ok := s.items[key]
+	if !ok || !item.expiresAt.After(now) {
+		item = memoryItem{}
+	}
+	item.value = max(item.value+delta, 0)
+	item.expiresAt = now.Add(ttl)
+	s.items[key] = item
+	return item.value
+}
+
+// sweep 到达间隔时清理过期记录，调用方须持有锁，返回当前时间
+func (s *MemoryStore) sweep() time.Time {
+	now := time.Now()
+	if now.Sub(s.sweptAt) >= memorySweepInterval {
+		for k, item := range s.items {
+			if !item.expiresAt.After(now) {
+				delete(s.items, k)
+			}
+		}
+		s.sweptAt = now
+	}
+	return now
+}
@@ -0,0 +1,59 @@
+package lockout
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailConcurrent(t *testing.T) {
+	g := New(NewMemoryStore(), "test:", Policy{Attempts: 5, Backoff: time.Minute, MaxLockout: time.Hour, Window: time.Hour})
+	now := time.Now()
+
+	// 并发失败各自计数，恰好允许次数内的请求不产生锁定
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			if g.Fail("alice", now) == 0 {
+				allowed.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != 5 {
+		t.Fatalf("allowed = %d, want 5", got)
+	}
+	if wait := g.Check("alice", now); wait <= 0 {
+		t.Fatalf("wait = %v, want locked", wait)
+	}
+
+	g.Reset("alice")
+	if wait := g.Check("alice", now); wait != 0 {
+		t.Fatalf("wait after reset = %v, want 0", wait)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	g := New(NewMemoryStore(), "test:", Policy{Attempts: 2, Backoff: time.Minute, MaxLockout: time.Hour, Window: time.Hour})
+	now := time.Now()
+
+	// 撤销的尝试不计入失败次数
+	for range 10 {
+		if delay := g.Fail("10.0.0.1", now); delay != 0 {
+			t.Fatalf("delay = %v, want 0", delay)
+		}
+		g.Undo("10.0.0.1")
+	}
+
+	g.Fail("10.0.0.1", now)
+	g.Fail("10.0.0.1", now)
+	if delay := g.Fail("10.0.0.1", now); delay != time.Minute {
+		t.Fatalf("delay = %v, want %v", delay, time.Minute)
+	}
+	if delay := g.Fail("10.0.0.1", now); delay != 2*time.Minute {
+		t.Fatalf("delay = %v, want %v", delay, 2*time.Minute)
+	}
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名称前缀
+const namespace = "shortener"
+
+// 跳转结果
+const (
+	RedirectHit      = "hit"      // 已跳转
+	RedirectMiss     = "miss"     // 短链接不存在
+	RedirectExpired  = "expired"  // 短链接已过期
+	RedirectDisabled = "disabled" // 短链接已禁用或被策略禁止
+	RedirectQuota    = "quota"    // 工作区点击配额已用尽
+	RedirectError    = "error"    // 查询失败
+)
+
+// 缓存读取结果
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// registry 指标注册表，不使用 prometheus 的全局注册表
+var registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests 按路由统计的请求数
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration 按路由统计的请求耗时
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// Redirects 按结果统计的短链接跳转数
+	Redirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Short link redirects by outcome.",
+	}, []string{"outcome"})
+
+	// CacheReads 按结果统计的缓存读取数
+	CacheReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_reads_total",
+		Help:      "Cache reads by result (hit, miss, error).",
+	}, []string{"result"})
+
+	// HistoryQueue 等待写入的访问记录数
+	HistoryQueue = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "history_queue_depth",
+		Help:      "Access histories waiting to be written.",
+	})
+
+	// GeoIPDuration IP 归属地查询耗时
+	GeoIPDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "geoip_lookup_duration_seconds",
+		Help:      "GeoIP lookup latency.",
+		Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05},
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		Redirects,
+		CacheReads,
+		HistoryQueue,
+		GeoIPDuration,
+	)
+}
+
+// RegisterDB 注册数据库连接池指标
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler 以 Prometheus 文本格式输出指标
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedURL     = errors.New("destination url is malformed")
+	ErrDomainBlocked    = errors.New("destination domain is blocked")
+	ErrDomainNotAllowed = errors.New("destination domain is not allowed")
+	ErrIPLiteral        = errors.New("destination ip literal is not allowed")
+	ErrPrivateNetwork   = errors.New("destination resolves to a private network")
+	ErrUnresolved       = errors.New("destination domain cannot be resolved")
+)
+
+// cgnatPrefix 运营商级 NAT 地址段（RFC 6598）
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// Resolver 域名解析接口，便于测试时替换
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// Options 目标地址策略配置
+//
+// 域名规则：
+//   - example.com   仅匹配 example.com
+//   - *.example.com 仅匹配 example.com 的子域名
+//   - .example.com  匹配 example.com 及其子域名
+type Options struct {
+	Enabled        bool
+	AllowedDomains []string      // 为空表示不限制
+	BlockedDomains []string      // 优先级高于 AllowedDomains
+	BlockIPLiteral bool          // 禁止以 IP 作为主机名
+	BlockPrivate   bool          // 禁止指向内网、回环等地址
+	ResolveDNS     bool          // 解析域名并检查解析结果是否为内网地址，无法解析时拒绝
+	DNSTimeout     time.Duration // 域名解析超时
+	Resolver       Resolver      // 为空时使用 net.DefaultResolver
+}
+
+// Policy 目标地址策略
+type Policy struct {
+	Enabled bool
+
+	allowed        []string
+	blocked        []string
+	blockIPLiteral bool
+	blockPrivate   bool
+	resolveDNS     bool
+	dnsTimeout     time.Duration
+	resolver       Resolver
+}
+
+// New 创建目标地址策略
+func New(opts Options) *Policy {
+	resolver := opts.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	dnsTimeout := opts.DNSTimeout
+	if dnsTimeout <= 0 {
+		dnsTimeout = 3 * time.Second
+	}
+
+	return &Policy{
+		Enabled:        opts.Enabled,
+		allowed:        normalizePatterns(opts.AllowedDomains),
+		blocked:        normalizePatterns(opts.BlockedDomains),
+		blockIPLiteral: opts.BlockIPLiteral,
+		blockPrivate:   opts.BlockPrivate,
+		resolveDNS:     opts.ResolveDNS,
+		dnsTimeout:     dnsTimeout,
+		resolver:       resolver,
+	}
+}
+
+// Check 检查目标地址是否符合策略，策略未启用时总是通过
+func (t *Policy) Check(ctx context.Context, rawURL string) error {
+	if t == nil || !t.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return ErrMalformedURL
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	if matchAny(host, t.blocked) {
+		return ErrDomainBlocked
+	}
+
+	addr, err := netip.ParseAddr(host)
+	isIP := err == nil
+	if isIP {
+		if t.blockIPLiteral {
+			return ErrIPLiteral
+		}
+		if t.blockPrivate && isPrivate(addr) {
+			return ErrPrivateNetwork
+		}
+	}
+
+	if len(t.allowed) > 0 && !matchAny(host, t.allowed) {
+		return ErrDomainNotAllowed
+	}
+
+	if !isIP && t.blockPrivate {
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return ErrPrivateNetwork
+		}
+		if t.resolveDNS {
+			return t.checkResolved(ctx, host)
+		}
+	}
+
+	return nil
+}
+
+// CheckAddr 检查实际连接的 IP 地址，策略禁止内网地址时拒绝内网地址
+func (t *Policy) CheckAddr(addr netip.Addr) error {
+	if t == nil || !t.Enabled || !t.blockPrivate {
+		return nil
+	}
+	if isPrivate(addr) {
+		return ErrPrivateNetwork
+	}
+	return nil
+}
+
+// checkResolved 解析域名并检查是否指向内网地址
+func (t *Policy) checkResolved(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.dnsTimeout)
+	defer cancel()
+
+	addrs, err := t.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		// 无法确认解析结果时拒绝，避免解析失败时放行内网地址
+		return ErrUnresolved
+	}
+	for _, addr := range addrs {
+		if isPrivate(addr) {
+			return ErrPrivateNetwork
+		}
+	}
+	return nil
+}
+
+// isPrivate 是否为内网、回环、链路本地等不可公开访问的地址
+func isPrivate(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsPrivate() ||
+		addr.IsLoopback() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		cgnatPrefix.Contains(addr)
+}
+
+// matchAny 主机名是否匹配任一规则
+func matchAny(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		switch {
+		case strings.HasPrefix(pattern, "*."):
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+		case strings.HasPrefix(pattern, "."):
+			if host == pattern[1:] || strings.HasSuffix(host, pattern) {
+				return true
+			}
+		default:
+			if host == pattern {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizePatterns 规则统一转为小写并去除空白
+func normalizePatterns(patterns []string) []string {
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+		if pattern != "" {
+			result = append(result, pattern)
+		}
+	}
+	return result
+}
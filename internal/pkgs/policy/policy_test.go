@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+// stubResolver 返回固定解析结果的解析器
+type stubResolver struct {
+	addrs []netip.Addr
+	err   error
+}
+
+func (r stubResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	return r.addrs, r.err
+}
+
+func TestCheckResolved(t *testing.T) {
+	errLookup := errors.New("lookup failed")
+	cases := []struct {
+		name     string
+		resolver stubResolver
+		want     error
+	}{
+		{"public", stubResolver{addrs: []netip.Addr{netip.MustParseAddr("93.184.216.34")}}, nil},
+		{"private", stubResolver{addrs: []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("10.0.0.1")}}, ErrPrivateNetwork},
+		{"mapped loopback", stubResolver{addrs: []netip.Addr{netip.MustParseAddr("::ffff:127.0.0.1")}}, ErrPrivateNetwork},
+		{"lookup error", stubResolver{err: errLookup}, ErrUnresolved},
+	}
+
+	for _, c := range cases {
+		p := New(Options{Enabled: true, BlockPrivate: true, ResolveDNS: true, Resolver: c.resolver})
+		if err := p.Check(context.Background(), "https://example.com/a"); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	// 未禁止内网地址时不解析域名
+	p := New(Options{Enabled: true, ResolveDNS: true, Resolver: stubResolver{err: errLookup}})
+	if err := p.Check(context.Background(), "https://example.com/a"); err != nil {
+		t.Errorf("block_private off: got %v, want nil", err)
+	}
+}
+
+func TestCheckAddr(t *testing.T) {
+	p := New(Options{Enabled: true, BlockPrivate: true})
+	for addr, want := range map[string]error{
+		"93.184.216.34": nil,
+		"127.0.0.1":     ErrPrivateNetwork,
+		"169.254.1.1":   ErrPrivateNetwork,
+		"100.64.0.1":    ErrPrivateNetwork,
+		"fd00::1":       ErrPrivateNetwork,
+	} {
+		if err := p.CheckAddr(netip.MustParseAddr(addr)); !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", addr, err, want)
+		}
+	}
+
+	var disabled *Policy
+	if err := disabled.CheckAddr(netip.MustParseAddr("127.0.0.1")); err != nil {
+		t.Errorf("nil policy: got %v, want nil", err)
+	}
+}
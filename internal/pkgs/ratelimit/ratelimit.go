@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Store 计数存储
+type Store interface {
+	// Incr 计数加一并返回加一后的值，同时将过期时间设为 ttl
+	Incr(key string, ttl time.Duration) (int64, error)
+	// Get 读取计数，不存在时返回 0
+	Get(key string) (int64, error)
+}
+
+// Policy 限流策略，每个窗口内允许 Limit 次请求
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Result 限流结果
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // 距当前窗口结束的时长
+}
+
+// Limiter 滑动窗口限流，按上一窗口计数的剩余比例与当前窗口计数之和估算窗口内的请求数
+type Limiter struct {
+	store  Store
+	prefix string
+	policy Policy
+}
+
+// New 创建 Limiter，prefix 用于区分同一存储中的不同 Limiter
+func New(store Store, prefix string, policy Policy) *Limiter {
+	if policy.Window <= 0 {
+		policy.Window = time.Minute
+	}
+	return &Limiter{store: store, prefix: prefix, policy: policy}
+}
+
+// Policy 限流策略
+func (l *Limiter) Policy() Policy {
+	return l.policy
+}
+
+// Allow 记录一次请求并返回是否允许，被拒绝的请求同样计数
+func (l *Limiter) Allow(key string, now time.Time) (Result, error) {
+	window := l.policy.Window
+	index := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(window))
+
+	current, err := l.store.Incr(l.prefix+key+":"+strconv.FormatInt(index, 10), 2*window)
+	if err != nil {
+		return Result{}, err
+	}
+	previous, err := l.store.Get(l.prefix + key + ":" + strconv.FormatInt(index-1, 10))
+	if err != nil {
+		return Result{}, err
+	}
+
+	weight := 1 - float64(elapsed)/float64(window)
+	count := int(math.Ceil(float64(previous)*weight)) + int(current)
+
+	return Result{
+		Allowed:   count <= l.policy.Limit,
+		Limit:     l.policy.Limit,
+		Remaining: max(l.policy.Limit-count, 0),
+		Reset:     window - elapsed,
+	}, nil
+}
+
+// MemoryStore 进程内存储，用于未启用缓存时
+type MemoryStore struct {
+	mu      sync.Mutex
+	items   map[string]memoryItem
+	sweptAt time.Time
+}
+
+type memoryItem struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// memorySweepInterval 清理过期记录的最短间隔
+const memorySweepInterval = time.Minute
+
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]memoryItem)}
+}
+
+// Incr 计数加一，顺带清理过期记录
+func (s *MemoryStore) Incr(key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sweptAt) >= memorySweepInterval {
+		for k, item := range s.items {
+			if !item.expiresAt.After(now) {
+				delete(s.items, k)
+			}
+		}
+		s.sweptAt = now
+	}
+
+	item, ok := s.items[key]
+	if !ok || !item.expiresAt.After(now) {
+		item = memoryItem{}
+	}
+	item.count++
+	item.expiresAt = now.Add(ttl)
+	s.items[key] = item
+	return item.count, nil
+}
+
+// Get 读取计数
+func (s *MemoryStore) Get(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[key]
+	if !ok || !item.expiresAt.After(time.Now()) {
+		return 0, nil
+	}
+	return item.count, nil
+}
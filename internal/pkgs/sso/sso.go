@@ -0,0 +1,116 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrMissingIDToken = errors.New("oidc token response has no id_token")
+	ErrNonceMismatch  = errors.New("oidc id_token nonce mismatch")
+)
+
+// Options OIDC 客户端配置
+type Options struct {
+	Issuer       string   // 身份提供方地址，用于发现 /.well-known/openid-configuration
+	ClientID     string   // 客户端ID
+	ClientSecret string   // 客户端密钥，公共客户端可为空
+	RedirectURL  string   // 授权回调地址
+	Scopes       []string // 申请的权限范围，自动包含 openid
+}
+
+// Client OIDC 授权码登录客户端
+//
+// 首次使用时才请求身份提供方的发现文档，失败后下次请求重试，
+// 避免身份提供方不可用时服务无法启动
+type Client struct {
+	opts Options
+
+	mu       sync.Mutex
+	config   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// New 创建 OIDC 客户端
+func New(opts Options) *Client {
+	return &Client{opts: opts}
+}
+
+// AuthCodeURL 生成授权地址，使用 PKCE（S256）并携带 state 与 nonce
+func (c *Client) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	config, _, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange 使用授权码换取令牌，校验 ID Token 及其 nonce 后返回其中的声明
+func (c *Client) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (map[string]any, error) {
+	config, verifier, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("oidc exchange: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("oidc verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	claims := make(map[string]any)
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("oidc parse claims: %w", err)
+	}
+	return claims, nil
+}
+
+// discover 获取身份提供方配置，成功后缓存
+func (c *Client) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.config != nil {
+		return c.config, c.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, c.opts.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range c.opts.Scopes {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	c.config = &oauth2.Config{
+		ClientID:     c.opts.ClientID,
+		ClientSecret: c.opts.ClientSecret,
+		RedirectURL:  c.opts.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	c.verifier = provider.Verifier(&oidc.Config{ClientID: c.opts.ClientID})
+
+	return c.config, c.verifier, nil
+}
@@ -0,0 +1,231 @@
+package urlnorm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var ErrMalformedURL = errors.New("malformed url")
+
+// defaultPorts 各协议的默认端口
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// DefaultTrackingParams 默认移除的跟踪参数，以 * 结尾表示前缀匹配
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"yclid",
+	"mc_cid",
+	"mc_eid",
+	"igshid",
+	"spm",
+}
+
+// Options 规范化配置
+type Options struct {
+	StripTracking  bool     // 是否移除跟踪参数
+	TrackingParams []string // 为空时使用 DefaultTrackingParams
+}
+
+// Normalizer URL 规范化器
+type Normalizer struct {
+	stripTracking  bool
+	trackingParams []string
+}
+
+// New 创建 URL 规范化器
+func New(opts Options) *Normalizer {
+	params := opts.TrackingParams
+	if len(params) == 0 {
+		params = DefaultTrackingParams
+	}
+
+	trackingParams := make([]string, 0, len(params))
+	for _, param := range params {
+		if param = strings.ToLower(strings.TrimSpace(param)); param != "" {
+			trackingParams = append(trackingParams, param)
+		}
+	}
+
+	return &Normalizer{
+		stripTracking:  opts.StripTracking,
+		trackingParams: trackingParams,
+	}
+}
+
+// Normalize 返回 http(s) 地址的规范形式
+//
+//   - 协议与主机名转为小写，国际化域名转为 punycode
+//   - 移除默认端口
+//   - 折叠路径中的 . 与 .. 段，空路径补全为 /
+//   - 可选移除跟踪参数，其余参数按键排序，参数保持原始编码
+func (t *Normalizer) Normalize(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", ErrMalformedURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[u.Scheme]; !ok || u.Opaque != "" {
+		return "", ErrMalformedURL
+	}
+
+	host, err := t.normalizeHost(u)
+	if err != nil {
+		return "", err
+	}
+	u.Host = host
+
+	escapedPath := removeDotSegments(u.EscapedPath())
+	if escapedPath == "" {
+		escapedPath = "/"
+	}
+	if u.Path, err = url.PathUnescape(escapedPath); err != nil {
+		return "", ErrMalformedURL
+	}
+	u.RawPath = escapedPath
+
+	u.RawQuery = t.normalizeQuery(u.RawQuery)
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// normalizeHost 规范化主机名与端口
+func (t *Normalizer) normalizeHost(u *url.URL) (string, error) {
+	hostname := strings.TrimSuffix(u.Hostname(), ".")
+	port := u.Port()
+	if hostname == "" {
+		return "", ErrMalformedURL
+	}
+
+	if ip := net.ParseIP(hostname); ip != nil {
+		hostname = ip.String()
+	} else {
+		ascii, err := idna.Lookup.ToASCII(strings.ToLower(hostname))
+		if err != nil {
+			return "", ErrMalformedURL
+		}
+		hostname = ascii
+	}
+
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+
+	if strings.Contains(hostname, ":") {
+		hostname = "[" + hostname + "]"
+	}
+	if port != "" {
+		return hostname + ":" + port, nil
+	}
+	return hostname, nil
+}
+
+// normalizeQuery 按 & 拆分查询字符串，移除跟踪参数并按键稳定排序
+// 参数不解码、不重新编码，以保留 ; 等目标站点自行解析的字符；
+// 存在无法解码的参数名时原样返回
+func (t *Normalizer) normalizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type pair struct {
+		key string // 解码后的参数名
+		raw string
+	}
+
+	pairs := make([]pair, 0, strings.Count(rawQuery, "&")+1)
+	for raw := range strings.SplitSeq(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		rawKey, _, _ := strings.Cut(raw, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			return rawQuery
+		}
+		if t.stripTracking && t.isTracking(key) {
+			continue
+		}
+		pairs = append(pairs, pair{key: key, raw: raw})
+	}
+
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	raws := make([]string, len(pairs))
+	for i, p := range pairs {
+		raws[i] = p.raw
+	}
+	return strings.Join(raws, "&")
+}
+
+// Hash 计算规范化地址的 SHA-256，用于去重索引
+func Hash(canonicalURL string) string {
+	sum := sha256.Sum256([]byte(canonicalURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// isTracking 是否为跟踪参数
+func (t *Normalizer) isTracking(key string) bool {
+	key = strings.ToLower(key)
+	for _, param := range t.trackingParams {
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == param {
+			return true
+		}
+	}
+	return false
+}
+
+// removeDotSegments 按 RFC 3986 5.2.4 折叠路径中的 . 与 .. 段
+func removeDotSegments(path string) string {
+	if path == "" {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	output := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+			if last {
+				output = append(output, "")
+			}
+		case "..":
+			if len(output) > 1 {
+				output = output[:len(output)-1]
+			}
+			if last {
+				output = append(output, "")
+			}
+		default:
+			output = append(output, segment)
+		}
+	}
+
+	result := strings.Join(output, "/")
+	if strings.HasPrefix(path, "/") && !strings.HasPrefix(result, "/") {
+		result = "/" + result
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package urlnorm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	normalizer := New(Options{StripTracking: true})
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"scheme and host", "HTTPS://Example.COM", "https://example.com/"},
+		{"default port", "http://example.com:80/a", "http://example.com/a"},
+		{"custom port", "https://example.com:8443/a", "https://example.com:8443/a"},
+		{"idn host", "https://bücher.example/", "https://xn--bcher-kva.example/"},
+		{"dot segments", "https://example.com/a/./b/../c", "https://example.com/a/c"},
+		{"sorted query", "https://example.com/?b=2&a=1&a=0", "https://example.com/?a=1&a=0&b=2"},
+		{"tracking params", "https://example.com/?utm_source=x&id=1&fbclid=y", "https://example.com/?id=1"},
+		{"semicolon query", "https://example.com/?a=1;b=2", "https://example.com/?a=1;b=2"},
+		{"semicolon query sorted", "https://example.com/?z=0&a=1;b=2", "https://example.com/?a=1;b=2&z=0"},
+		{"malformed value escape", "https://example.com/?b=%zz&a=1", "https://example.com/?a=1&b=%zz"},
+		{"malformed key escape", "https://example.com/?%zz=1&a=1", "https://example.com/?%zz=1&a=1"},
+		{"raw encoding kept", "https://example.com/?q=a%20b&p=c+d", "https://example.com/?p=c+d&q=a%20b"},
+		{"empty pairs", "https://example.com/?&a=1&&", "https://example.com/?a=1"},
+		{"empty query", "https://example.com/?", "https://example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizer.Normalize(tt.in)
+			if err != nil {
+				t.Fatalf("Normalize(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeMalformed(t *testing.T) {
+	normalizer := New(Options{})
+
+	for _, in := range []string{"ftp://example.com/", "https://", "mailto:user@example.com", "://bad"} {
+		if _, err := normalizer.Normalize(in); !errors.Is(err, ErrMalformedURL) {
+			t.Errorf("Normalize(%q) error = %v, want ErrMalformedURL", in, err)
+		}
+	}
+}
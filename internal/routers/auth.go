@@ -3,16 +3,45 @@ package routers
 import (
 	"github.com/gin-gonic/gin"
 
+	"go.xoder.cn/shortener/internal/logics"
 	"go.xoder.cn/shortener/internal/middlewares"
 	"go.xoder.cn/shortener/internal/shared"
 )
 
 func authMiddleware() gin.HandlerFunc {
-	apiKeyAuth := &middlewares.APIKeyAuth{
-		ValidKeys: map[string]bool{shared.GlobalAPIKey: true},
-		Header:    "X-API-KEY",
-		Query:     "api_key",
+	apiKeyLogic := logics.NewApiKeyLogic()
+
+	bearerTokenAuth := &middlewares.BearerTokenAuth{
+		Store: logics.NewSessionLogic(),
+	}
+
+	authenticators := make([]middlewares.Authenticator, 0, 5)
+	if shared.GlobalSigner != nil {
+		authenticators = append(authenticators, &middlewares.HMACAuth{
+			Store:    apiKeyLogic,
+			Verifier: shared.GlobalSigner,
+			MaxBody:  shared.GlobalHMAC.MaxBody,
+		})
+	}
+	if shared.GlobalHMAC.StaticKeys {
+		authenticators = append(authenticators, &middlewares.APIKeyAuth{
+			Store:  apiKeyLogic,
+			Header: "X-API-KEY",
+			Query:  "api_key",
+		})
+	}
+	if shared.GlobalJWT != nil {
+		authenticators = append(authenticators, &middlewares.JWTAuth{
+			Store: logics.NewJwtLogic(),
+		})
+	}
+	authenticators = append(authenticators, bearerTokenAuth)
+	// 请求头中的凭据优先于客户端证书
+	if shared.GlobalCertAuth != nil {
+		authenticators = append(authenticators, &middlewares.ClientCertAuth{
+			Store: logics.NewCertLogic(),
+		})
 	}
 
-	return middlewares.MultiAuthMiddleware(apiKeyAuth, &middlewares.BearerTokenAuth{})
+	return middlewares.MultiAuthMiddleware(authenticators...)
 }
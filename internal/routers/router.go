@@ -5,11 +5,38 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"go.xoder.cn/shortener/internal/access"
 	"go.xoder.cn/shortener/internal/handlers"
+	"go.xoder.cn/shortener/internal/logics"
+	"go.xoder.cn/shortener/internal/middlewares"
+	"go.xoder.cn/shortener/internal/pkgs/metrics"
+	"go.xoder.cn/shortener/internal/shared"
 )
 
 func NewRouter() *gin.Engine {
-	g := gin.Default()
+	g := gin.New()
+	// 仅信任配置的反向代理传递的客户端地址，避免伪造 X-Forwarded-For 绕过按 IP 的限制
+	if err := g.SetTrustedProxies(shared.GlobalProxies); err != nil {
+		panic("set trusted proxies failed: " + err.Error())
+	}
+	g.Use(middlewares.RequestID())
+	if shared.GlobalMetrics != nil {
+		g.Use(middlewares.Metrics())
+	}
+	if shared.GlobalAccessLog {
+		g.Use(middlewares.AccessLog())
+	}
+	g.Use(middlewares.Recovery())
+	// 预检请求需在认证前响应
+	if shared.GlobalCORS != nil {
+		g.Use(middlewares.CORS(shared.GlobalCORS))
+	}
+	if shared.GlobalHSTS != "" {
+		g.Use(middlewares.HSTS(shared.GlobalHSTS))
+	}
+	if shared.GlobalAltSvc != "" {
+		g.Use(middlewares.AltSvc(shared.GlobalAltSvc))
+	}
 
 	// swagger api docs
 	// g.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -32,6 +59,9 @@ func NewRouter() *gin.Engine {
 	user := handlers.Handle.UserHandler
 	shortener := handlers.Handle.ShortenHandler
 	history := handlers.Handle.HistoryHandler
+	apiKey := handlers.Handle.ApiKeyHandler
+	audit := handlers.Handle.AuditHandler
+	workspace := handlers.Handle.WorkspaceHandler
 
 	// apiV1 := g.Group("/api/v1")
 	apiV1 := g.Group("/api")
@@ -43,26 +73,94 @@ func NewRouter() *gin.Engine {
 		})
 	})
 
-	apiV1.POST("/account/login", account.Login)
-	apiV1.Use(authMiddleware())
+	// Prometheus 指标，未单独监听时挂载在 /api 下，避免覆盖同名短码，并需通过令牌或管理员身份访问
+	if cfg := shared.GlobalMetrics; cfg != nil && cfg.Address == "" {
+		if cfg.Token != "" {
+			apiV1.GET(cfg.Path, gin.WrapH(middlewares.BearerToken(cfg.Token, metrics.Handler())))
+		} else {
+			apiV1.GET(cfg.Path, authMiddleware(), middlewares.RequireAdmin(), gin.WrapH(metrics.Handler()))
+		}
+	}
+
+	// 登录相关接口按客户端 IP 限流
+	loginLimit := middlewares.RateLimit(shared.GlobalRateLogin, middlewares.RateLimitByIP)
+	apiV1.POST("/account/login", loginLimit, account.Login)
+	apiV1.POST("/account/login/totp", loginLimit, account.LoginTotp)
+	apiV1.POST("/account/refresh", loginLimit, account.Refresh)
+	apiV1.GET("/account/oidc/login", loginLimit, account.OidcLogin)
+	apiV1.GET("/account/oidc/callback", loginLimit, account.OidcCallback)
+	apiV1.Use(authMiddleware(), middlewares.RateLimit(shared.GlobalRateAPI, middlewares.RateLimitByPrincipal))
 	{
-		apiV1.POST("/shortens", shortener.ShortenAdd)
-		apiV1.GET("/shortens", shortener.ShortenList)
-		apiV1.DELETE("/shortens", shortener.ShortenDeleteAll)
-		apiV1.GET("/shortens/:code", shortener.ShortenFind)
-		apiV1.PUT("/shortens/:code", shortener.ShortenUpdate)
-		apiV1.DELETE("/shortens/:code", shortener.ShortenDelete)
+		// API Key 权限范围
+		linksRead := middlewares.RequireScope(access.ScopeLinksRead)
+		linksWrite := middlewares.RequireScope(access.ScopeLinksWrite)
+		historyRead := middlewares.RequireScope(access.ScopeHistoryRead)
+		adminScope := middlewares.RequireScope(access.ScopeAdmin)
+
+		// 短链接、访问记录与 API Key 属于工作区，可通过 X-Workspace-ID 请求头指定
+		scoped := apiV1.Group("", middlewares.RequireWorkspace(logics.NewWorkspaceLogic()))
+		scoped.POST("/shortens", linksWrite, shortener.ShortenAdd)
+		scoped.GET("/shortens", linksRead, shortener.ShortenList)
+		scoped.DELETE("/shortens", linksWrite, shortener.ShortenDeleteAll)
+		scoped.GET("/shortens/:code", linksRead, shortener.ShortenFind)
+		scoped.PUT("/shortens/:code", linksWrite, shortener.ShortenUpdate)
+		scoped.DELETE("/shortens/:code", linksWrite, shortener.ShortenDelete)
 
-		apiV1.GET("/histories", history.HistoryList)
-		apiV1.DELETE("/histories", history.HistoryDeleteAll)
+		scoped.GET("/histories", historyRead, history.HistoryList)
+		scoped.DELETE("/histories", linksWrite, history.HistoryDeleteAll)
 
 		apiV1.POST("/account/logout", account.Logout)
+		apiV1.GET("/account/sessions", adminScope, account.SessionList)
+		apiV1.DELETE("/account/sessions", adminScope, account.SessionDeleteOthers)
+		apiV1.DELETE("/account/sessions/:id", adminScope, account.SessionDelete)
+		apiV1.GET("/account/totp", adminScope, account.TotpStatus)
+		apiV1.POST("/account/totp", adminScope, account.TotpSetup)
+		apiV1.POST("/account/totp/enable", adminScope, account.TotpEnable)
+		apiV1.POST("/account/totp/disable", adminScope, account.TotpDisable)
+		apiV1.POST("/account/totp/recovery-codes", adminScope, account.TotpRecoveryCodes)
+		apiV1.GET("/account/workspaces", workspace.WorkspaceMine)
 		apiV1.GET("/users/current", user.Current)
+		apiV1.PUT("/users/current", adminScope, user.CurrentUpdate)
+
+		// 管理 API Key 需登录会话或具有 admin 权限范围的密钥
+		scoped.GET("/keys", adminScope, apiKey.ApiKeyList)
+		scoped.POST("/keys", adminScope, apiKey.ApiKeyAdd)
+		scoped.GET("/keys/:id", adminScope, apiKey.ApiKeyFind)
+		scoped.PUT("/keys/:id", adminScope, apiKey.ApiKeyUpdate)
+		scoped.DELETE("/keys/:id", adminScope, apiKey.ApiKeyDelete)
+		scoped.POST("/keys/:id/rotate", adminScope, apiKey.ApiKeyRotate)
+
+		// 仅管理员可访问
+		admin := apiV1.Group("", middlewares.RequireAdmin())
+		admin.GET("/maintenance/loops", shortener.ShortenLoops)
+
+		admin.GET("/users", user.UserList)
+		admin.POST("/users", user.UserAdd)
+		admin.GET("/users/:id", user.UserFind)
+		admin.PUT("/users/:id", user.UserUpdate)
+		admin.DELETE("/users/:id", user.UserDelete)
+		admin.DELETE("/users/:id/totp", user.UserTotpReset)
+
+		admin.GET("/audit", audit.AuditList)
+
+		admin.GET("/workspaces", workspace.WorkspaceList)
+		admin.POST("/workspaces", workspace.WorkspaceAdd)
+		admin.GET("/workspaces/:id", workspace.WorkspaceFind)
+		admin.PUT("/workspaces/:id", workspace.WorkspaceUpdate)
+		admin.DELETE("/workspaces/:id", workspace.WorkspaceDelete)
+		admin.GET("/workspaces/:id/members", workspace.MemberList)
+		admin.POST("/workspaces/:id/members", workspace.MemberAdd)
+		admin.PUT("/workspaces/:id/members/:user_id", workspace.MemberUpdate)
+		admin.DELETE("/workspaces/:id/members/:user_id", workspace.MemberDelete)
 	}
 
 	// 短链接跳转路由
-	g.GET("/:code", shortener.ShortenRedirect)
-	g.HEAD("/:code", shortener.ShortenRedirect)
+	g.GET("/", shortener.ShortenRoot)
+	g.HEAD("/", shortener.ShortenRoot)
+	redirectLimit := middlewares.RateLimit(shared.GlobalRateRedirect, middlewares.RateLimitByIP)
+	g.GET("/:code", redirectLimit, shortener.ShortenRedirect)
+	g.HEAD("/:code", redirectLimit, shortener.ShortenRedirect)
+	g.NoRoute(shortener.ShortenNoRoute)
 
 	return g
 }
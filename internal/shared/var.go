@@ -1,22 +1,60 @@
 package shared
 
 import (
-	"sync"
+	"crypto/tls"
+	"log/slog"
+	"sync/atomic"
 
 	"gorm.io/gorm"
 
 	"go.xoder.cn/shortener/internal/cache"
+	"go.xoder.cn/shortener/internal/pkgs/certauth"
+	"go.xoder.cn/shortener/internal/pkgs/certwatch"
 	"go.xoder.cn/shortener/internal/pkgs/geoip"
+	"go.xoder.cn/shortener/internal/pkgs/hmacsign"
+	"go.xoder.cn/shortener/internal/pkgs/jwtauth"
+	"go.xoder.cn/shortener/internal/pkgs/lockout"
+	"go.xoder.cn/shortener/internal/pkgs/policy"
+	"go.xoder.cn/shortener/internal/pkgs/ratelimit"
+	"go.xoder.cn/shortener/internal/pkgs/sso"
+	"go.xoder.cn/shortener/internal/pkgs/urlnorm"
 	"go.xoder.cn/shortener/internal/types"
 )
 
 var (
-	GlobalShorten *types.CfgShorten
-	GlobalDB      *gorm.DB
-	GlobalAPIKey  string
-	GlobalCache   *cache.CacheManager
-	GlobalGeoIP   *geoip.GeoIPManager
+	GlobalShorten      *types.CfgShorten
+	GlobalLogLevel     = new(slog.LevelVar)
+	GlobalAccessLog    bool
+	GlobalDB           *gorm.DB
+	GlobalAPIKey       string
+	GlobalCache        *cache.CacheManager
+	GlobalGeoIP        *geoip.GeoIPManager
+	GlobalPolicy       atomic.Pointer[policy.Policy]      // 配置重载时整体替换
+	GlobalURLNorm      atomic.Pointer[urlnorm.Normalizer] // 配置重载时整体替换
+	GlobalChain        *types.CfgChain
+	GlobalDeepLink     *types.CfgDeepLink
+	GlobalJWT          *jwtauth.Manager
+	GlobalSSO          *sso.Client
+	GlobalLockoutUser  *lockout.Guard
+	GlobalLockoutIP    *lockout.Guard
+	GlobalProxies      []string // 可信反向代理的 IP 或网段
+	GlobalRateRedirect *ratelimit.Limiter
+	GlobalRateLogin    *ratelimit.Limiter
+	GlobalRateAPI      *ratelimit.Limiter
+	GlobalSigner       *hmacsign.Verifier
+	GlobalCertAuth     *certauth.Authority
+	GlobalTLS          *tls.Config
+	GlobalTLSCert      *certwatch.Watcher
+	GlobalHSTS         string
+	GlobalAltSvc       string
+	GlobalHTTP3        *types.CfgHTTP3
+	GlobalCORS         *types.CfgCORS
+	GlobalMetrics      *types.CfgMetrics
 
 	GlobalUser      *types.User
-	GlobalUserCache sync.Map
+	GlobalSession   *types.CfgSession
+	GlobalOIDC      *types.CfgOIDC
+	GlobalAudit     *types.CfgAudit
+	GlobalHMAC      *types.CfgHMAC
+	GlobalWorkspace *types.CfgWorkspace
 )
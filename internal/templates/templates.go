@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+//go:embed *.html
+var files embed.FS
+
+// pages 当前使用的模板，配置重载时整体替换
+var pages atomic.Pointer[template.Template]
+
+func init() {
+	pages.Store(template.Must(template.ParseFS(files, "*.html")))
+}
+
+// Load 加载自定义模板目录，同名模板覆盖内置模板
+func Load(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil || len(matches) == 0 {
+		return err
+	}
+
+	custom := template.Must(template.ParseFS(files, "*.html"))
+	for _, file := range matches {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := custom.New(filepath.Base(file)).Parse(string(content)); err != nil {
+			return err
+		}
+	}
+
+	pages.Store(custom)
+	return nil
+}
+
+// Render 渲染页面模板
+func Render(w io.Writer, name string, data any) error {
+	return pages.Load().ExecuteTemplate(w, name, data)
+}
+
+// RenderError 渲染错误页，优先使用 <kind>.html，不存在时使用 error.html
+func RenderError(w io.Writer, kind string, data ErrorPage) error {
+	current := pages.Load()
+	if current.Lookup(kind+".html") != nil {
+		return current.ExecuteTemplate(w, kind+".html", data)
+	}
+	return current.ExecuteTemplate(w, "error.html", data)
+}
+
+// BridgePage 唤起应用的中转页数据
+type BridgePage struct {
+	AppURL      template.URL // 已按配置校验过协议的应用地址
+	FallbackURL string
+	Timeout     int // 唤起失败后回退到网页的等待时间（毫秒）
+}
+
+// ErrorPage 错误页数据
+type ErrorPage struct {
+	StatusCode int
+	Title      string
+	Message    string
+	Code       string // 访问的短码，访问根路径时为空
+	SiteName   string
+	HomeURL    string
+}
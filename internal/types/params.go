@@ -1,12 +1,19 @@
 package types
 
+import (
+	"slices"
+
+	"go.xoder.cn/shortener/internal/access"
+)
+
 // HistoryParams 历史记录的参数
 type HistoryParams struct {
-	URLID     int64
-	ShortCode string
-	IPAddress string
-	UserAgent string
-	Referer   string
+	WorkspaceID int64
+	URLID       int64
+	ShortCode   string
+	IPAddress   string
+	UserAgent   string
+	Referer     string
 }
 
 // User 用户信息
@@ -14,3 +21,45 @@ type User struct {
 	Username string `json:"Username"`
 	Password string `json:"password,omitempty"`
 }
+
+// Principal 当前请求的身份
+type Principal struct {
+	UserID    int64
+	Username  string
+	Role      string
+	Scopes    []string // 通过 API Key 访问时的权限范围，登录会话为 nil 表示不受限制
+	SessionID int64    // 通过登录会话访问时的会话ID
+	ApiKeyID  int64    // 通过 API Key 访问时的密钥ID
+	Client    string   // 通过客户端证书访问时匹配的身份名称
+
+	WorkspaceID   int64  // 当前请求的工作区ID，API Key 固定为其所属工作区
+	WorkspaceRole string // 在当前工作区中的成员角色
+}
+
+// AuditEvent 审计事件
+type AuditEvent struct {
+	Actor      Principal // 操作者，为空时使用当前请求的身份
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any // 操作前的数据，与 After 比较后仅记录有变化的字段
+	After      any // 操作后的数据
+}
+
+// IsAdmin 是否为管理员，API Key 还需具有 admin 权限范围
+func (p Principal) IsAdmin() bool {
+	return p.Role == access.RoleAdmin && p.HasScope(access.ScopeAdmin)
+}
+
+// IsWorkspaceAdmin 是否可管理当前工作区的所有数据
+func (p Principal) IsWorkspaceAdmin() bool {
+	return p.IsAdmin() || p.WorkspaceRole == access.WorkspaceRoleAdmin
+}
+
+// HasScope 是否具有指定权限范围
+func (p Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	return slices.Contains(p.Scopes, scope)
+}
@@ -18,6 +18,8 @@ type ReqQueryShorten struct {
 	Code        string `form:"code,omitempty" binding:"omitempty"`
 	OriginalURL string `form:"original_url,omitempty" binding:"omitempty"`
 	Status      int64  `form:"status,omitempty,default=-1" binding:"omitempty"`
+	Health      string `form:"health,omitempty" binding:"omitempty,oneof=healthy broken blocked unchecked"`
+	UserID      *int64 `form:"user_id,omitempty" binding:"omitempty"` // 仅管理员或工作区管理员可用，0 表示无所属用户
 }
 
 type ReqQueryHistory struct {
@@ -26,16 +28,70 @@ type ReqQueryHistory struct {
 	IP   string `form:"ip_address,omitempty" binding:"omitempty"`
 }
 
+type ReqQueryUser struct {
+	ReqQuery
+	Username string `form:"username,omitempty" binding:"omitempty"`
+	Role     string `form:"role,omitempty" binding:"omitempty,oneof=admin user"`
+}
+
+type ReqQueryApiKey struct {
+	ReqQuery
+	UserID *int64 `form:"user_id,omitempty" binding:"omitempty"` // 仅管理员可用
+}
+
+type ReqQueryAudit struct {
+	ReqQuery
+	ActorID     int64  `form:"actor_id,omitempty" binding:"omitempty,min=1"`
+	ApiKeyID    int64  `form:"api_key_id,omitempty" binding:"omitempty,min=1"`
+	WorkspaceID int64  `form:"workspace_id,omitempty" binding:"omitempty,min=1"`
+	Action      string `form:"action,omitempty" binding:"omitempty,max=64"`
+	TargetType  string `form:"target_type,omitempty" binding:"omitempty,max=32"`
+	TargetID    string `form:"target_id,omitempty" binding:"omitempty,max=255"`
+	IP          string `form:"ip,omitempty" binding:"omitempty,max=64"`
+	Start       string `form:"start,omitempty" binding:"omitempty"` // 起始时间（含），格式 2006-01-02 15:04:05
+	End         string `form:"end,omitempty" binding:"omitempty"`   // 截止时间（不含）
+}
+
+type ReqQueryWorkspace struct {
+	ReqQuery
+	Name string `form:"name,omitempty" binding:"omitempty,max=64"`
+}
+
+// ReqID 数字 ID
+type ReqID struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// ReqTotpCode 两步验证码，部分接口也接受恢复码
+type ReqTotpCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
 // ResShorten 短链接响应
 type ResShorten struct {
-	ID          int64  `json:"id"`
+	ID              int64  `json:"id"`
+	WorkspaceID     int64  `json:"workspace_id"`
+	UserID          int64  `json:"user_id"`
+	Code            string `json:"code"`
+	ShortURL        string `json:"short_url"`
+	OriginalURL     string `json:"original_url"`
+	CanonicalURL    string `json:"canonical_url"`
+	FallbackURL     string `json:"fallback_url"`
+	Describe        string `json:"describe"`
+	Status          int8   `json:"status"`
+	HealthCode      int    `json:"health_code"`
+	HealthLatency   int64  `json:"health_latency"`
+	HealthCheckedAt string `json:"health_checked_at"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// ResShortenLoop 循环跳转检测结果
+type ResShortenLoop struct {
 	Code        string `json:"code"`
-	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
-	Describe    string `json:"describe"`
-	Status      int8   `json:"status"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ErrCode     int    `json:"errcode"`
+	ErrInfo     string `json:"errinfo"`
 }
 
 // ResHistory 历史记录响应
@@ -58,6 +114,125 @@ type ResHistory struct {
 	CreatedTime  string `json:"created_at"`
 }
 
+// ResUser 用户响应
+type ResUser struct {
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	Role        string `json:"role"`
+	TotpEnabled bool   `json:"totp_enabled"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// ResSession 登录会话响应
+type ResSession struct {
+	ID         int64  `json:"id"`
+	Current    bool   `json:"current"` // 是否为当前请求使用的会话
+	Kind       string `json:"kind"`    // login 或 refresh
+	Remember   bool   `json:"remember"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	ExpiresAt  string `json:"expires_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// ResLogin 登录响应
+type ResLogin struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// ResChallenge 两步验证挑战，使用 challenge 与验证码完成登录
+type ResChallenge struct {
+	MfaRequired bool   `json:"mfa_required"`
+	Challenge   string `json:"challenge"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+// ResTotpSetup TOTP 绑定信息
+type ResTotpSetup struct {
+	Secret string `json:"secret"`  // Base32 密钥，用于手动输入
+	URI    string `json:"uri"`     // otpauth 地址
+	QRCode string `json:"qr_code"` // otpauth 地址的二维码（PNG Data URI）
+}
+
+// ResTotpStatus TOTP 状态
+type ResTotpStatus struct {
+	Enabled       bool     `json:"enabled"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"` // 仅在启用与重新生成时返回
+	RecoveryCount int      `json:"recovery_count"`           // 剩余可用的恢复码数量
+}
+
+// ResToken JWT 登录响应
+type ResToken struct {
+	TokenType        string   `json:"token_type"`
+	AccessToken      string   `json:"access_token"`
+	ExpiresIn        int64    `json:"expires_in"` // 访问令牌剩余有效期（秒）
+	ExpiresAt        string   `json:"expires_at"`
+	RefreshToken     string   `json:"refresh_token"` // 每次刷新后轮换
+	RefreshExpiresAt string   `json:"refresh_expires_at"`
+	Scopes           []string `json:"scopes"`
+}
+
+// ResApiKey API Key 响应
+type ResApiKey struct {
+	ID          int64    `json:"id"`
+	WorkspaceID int64    `json:"workspace_id"`
+	UserID      int64    `json:"user_id"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	Key         string   `json:"key,omitempty"`    // 仅在创建与轮换时返回
+	Secret      string   `json:"secret,omitempty"` // 请求签名密钥，仅在创建与轮换时返回
+	Scopes      []string `json:"scopes"`
+	ExpiresAt   string   `json:"expires_at"`
+	LastUsedAt  string   `json:"last_used_at"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// ResAudit 审计日志响应
+type ResAudit struct {
+	ID          int64  `json:"id"`
+	ActorType   string `json:"actor_type"`
+	ActorID     int64  `json:"actor_id"`
+	ActorName   string `json:"actor_name"`
+	ApiKeyID    int64  `json:"api_key_id"`
+	WorkspaceID int64  `json:"workspace_id"`
+	Action      string `json:"action"`
+	TargetType  string `json:"target_type"`
+	TargetID    string `json:"target_id"`
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	Before      any    `json:"before"` // 操作前有变化的字段
+	After       any    `json:"after"`  // 操作后有变化的字段
+	CreatedAt   string `json:"created_at"`
+}
+
+// ResWorkspace 工作区响应
+type ResWorkspace struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Slug       string `json:"slug"`
+	Domain     string `json:"domain"`
+	LinkQuota  int64  `json:"link_quota"`
+	ClickQuota int64  `json:"click_quota"`
+	Links      int64  `json:"links"`          // 短链接数量
+	Clicks     int64  `json:"clicks"`         // 本月访问次数
+	Role       string `json:"role,omitempty"` // 当前用户在工作区中的角色，仅在获取自己的工作区时返回
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// ResWorkspaceMember 工作区成员响应
+type ResWorkspaceMember struct {
+	WorkspaceID int64  `json:"workspace_id"`
+	UserID      int64  `json:"user_id"`
+	Username    string `json:"username"`
+	Role        string `json:"role"`
+	CreatedAt   string `json:"created_at"`
+}
+
 // ResPage 分页响应
 type ResPage struct {
 	Page         int64 `json:"page"`          // 当前页码（从1开始）
@@ -109,3 +284,224 @@ type CfgCacheValkey struct {
 	Password string `json:"password"`
 	DB       int    `json:"db"`
 }
+
+// CfgSession 登录会话配置
+type CfgSession struct {
+	TTL           int `json:"ttl"`                                          // 会话有效期（秒），访问时顺延
+	RememberTTL   int `json:"remember_ttl" mapstructure:"remember_ttl"`     // 记住登录时的会话有效期（秒）
+	PurgeInterval int `json:"purge_interval" mapstructure:"purge_interval"` // 清理过期会话的周期（秒）
+}
+
+// CfgLockout 登录失败锁定配置
+type CfgLockout struct {
+	Enabled    bool `json:"enabled"`
+	Attempts   int  `json:"attempts"`                               // 每个用户名允许连续失败的次数
+	IPAttempts int  `json:"ip_attempts" mapstructure:"ip_attempts"` // 每个 IP 允许连续失败的次数
+	Backoff    int  `json:"backoff"`                                // 首次锁定时长（秒），此后每次失败翻倍
+	MaxLockout int  `json:"max_lockout" mapstructure:"max_lockout"` // 最长锁定时长（秒）
+	Window     int  `json:"window"`                                 // 最后一次失败后记录的保留时长（秒）
+}
+
+// CfgServer 服务器配置
+type CfgServer struct {
+	Address         string `json:"address"`
+	Socket          string `json:"socket"`                                           // Unix 套接字路径，设置后监听该套接字而不是 address
+	SocketMode      string `json:"socket_mode" mapstructure:"socket_mode"`           // Unix 套接字文件权限（八进制）
+	ReadTimeout     int    `json:"read_timeout" mapstructure:"read_timeout"`         // 读取请求的超时时间（秒），0 表示不限制
+	WriteTimeout    int    `json:"write_timeout" mapstructure:"write_timeout"`       // 写入响应的超时时间（秒），0 表示不限制
+	IdleTimeout     int    `json:"idle_timeout" mapstructure:"idle_timeout"`         // 空闲连接的超时时间（秒）
+	ShutdownTimeout int    `json:"shutdown_timeout" mapstructure:"shutdown_timeout"` // 关闭服务时等待请求处理完成的最长时间（秒）
+	TLS             CfgTLS `json:"tls"`
+}
+
+// CfgLog 日志配置
+type CfgLog struct {
+	Level  string `json:"level"`  // 日志级别：debug、info、warn、error
+	Format string `json:"format"` // 日志格式：text 或 json
+	Access bool   `json:"access"` // 是否记录访问日志
+}
+
+// CfgMetrics Prometheus 指标配置
+type CfgMetrics struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`    // 指标路径，在主服务上提供时挂载在 /api 下
+	Address string `json:"address"` // 单独监听的地址，为空时在主服务上提供
+	Token   string `json:"token"`   // 访问令牌，通过 Authorization: Bearer 传递
+}
+
+// CfgCORS 跨域资源共享配置
+type CfgCORS struct {
+	Enabled          bool     `json:"enabled"`
+	AllowOrigins     []string `json:"allow_origins" mapstructure:"allow_origins"`         // 允许的来源，支持 * 及 https://*.example.com 形式的通配
+	AllowMethods     []string `json:"allow_methods" mapstructure:"allow_methods"`         // 允许的请求方法
+	AllowHeaders     []string `json:"allow_headers" mapstructure:"allow_headers"`         // 允许的请求头
+	ExposeHeaders    []string `json:"expose_headers" mapstructure:"expose_headers"`       // 允许浏览器读取的响应头
+	AllowCredentials bool     `json:"allow_credentials" mapstructure:"allow_credentials"` // 是否允许携带 Cookie 等凭据
+	MaxAge           int      `json:"max_age" mapstructure:"max_age"`                     // 预检结果的缓存时长（秒）
+}
+
+// CfgRateLimit 请求限流配置
+type CfgRateLimit struct {
+	Enabled  bool               `json:"enabled"`
+	Redirect CfgRateLimitPolicy `json:"redirect"` // 短链接跳转，按客户端 IP 计数
+	Login    CfgRateLimitPolicy `json:"login"`    // 登录相关接口，按客户端 IP 计数
+	API      CfgRateLimitPolicy `json:"api"`      // 其他接口，按 API Key、用户或客户端 IP 计数
+}
+
+// CfgRateLimitPolicy 限流策略
+type CfgRateLimitPolicy struct {
+	Limit  int `json:"limit"`  // 每个窗口内允许的请求数，0 表示不限制
+	Window int `json:"window"` // 窗口时长（秒）
+}
+
+// CfgTLS HTTPS 配置
+type CfgTLS struct {
+	Enabled    bool   `json:"enabled"`
+	CertFile   string `json:"cert_file" mapstructure:"cert_file"`     // 服务器证书文件（PEM）
+	KeyFile    string `json:"key_file" mapstructure:"key_file"`       // 服务器私钥文件（PEM）
+	ClientCA   string `json:"client_ca" mapstructure:"client_ca"`     // 客户端证书的 CA 文件（PEM）
+	ClientAuth string `json:"client_auth" mapstructure:"client_auth"` // 客户端证书校验方式：none、optional、require
+
+	MinVersion     string   `json:"min_version" mapstructure:"min_version"`         // 最低 TLS 版本：1.2、1.3
+	CipherSuites   []string `json:"cipher_suites" mapstructure:"cipher_suites"`     // TLS 1.2 允许的密码套件，为空使用默认
+	ReloadInterval int      `json:"reload_interval" mapstructure:"reload_interval"` // 检查证书文件变更的周期（秒），0 表示不检查
+
+	RedirectAddress string `json:"redirect_address" mapstructure:"redirect_address"` // HTTP 跳转 HTTPS 的监听地址，为空不启用
+
+	HSTSMaxAge            int  `json:"hsts_max_age" mapstructure:"hsts_max_age"`                       // HSTS 有效期（秒），0 表示不启用
+	HSTSIncludeSubdomains bool `json:"hsts_include_subdomains" mapstructure:"hsts_include_subdomains"` // HSTS 是否包含子域名
+	HSTSPreload           bool `json:"hsts_preload" mapstructure:"hsts_preload"`                       // HSTS 是否申请加入浏览器预加载列表
+}
+
+// CfgHTTP3 HTTP/3 配置
+type CfgHTTP3 struct {
+	Enabled      bool   `json:"enabled"`
+	Address      string `json:"address"`                                        // UDP 监听地址，为空时与 server.address 相同
+	AltSvcMaxAge int    `json:"alt_svc_max_age" mapstructure:"alt_svc_max_age"` // Alt-Svc 有效期（秒）
+}
+
+// CfgMTLS 客户端证书认证配置
+type CfgMTLS struct {
+	Enabled          bool              `json:"enabled"`
+	CRLFiles         []string          `json:"crl_files" mapstructure:"crl_files"`                 // 证书吊销列表文件（PEM 或 DER）
+	CRLReload        int               `json:"crl_reload" mapstructure:"crl_reload"`               // 重新加载吊销列表的周期（秒），0 表示不重新加载
+	DenyFingerprints []string          `json:"deny_fingerprints" mapstructure:"deny_fingerprints"` // 拒绝的证书指纹或公钥指纹（SHA-256）
+	Identities       []CfgMTLSIdentity `json:"identities"`                                         // 证书身份映射规则，按顺序匹配
+}
+
+// CfgMTLSIdentity 证书身份映射规则，已设置的条件须全部满足
+type CfgMTLSIdentity struct {
+	Name     string   `json:"name"`     // 身份名称，用于审计
+	Subject  string   `json:"subject"`  // 证书主题的 CommonName
+	SAN      string   `json:"san"`      // DNS、URI、邮箱或 IP 类型的 SAN
+	SPKI     string   `json:"spki"`     // 公钥指纹，SubjectPublicKeyInfo 的 SHA-256（十六进制）
+	Username string   `json:"username"` // 映射的本地用户名
+	Scopes   []string `json:"scopes"`   // 权限范围
+}
+
+// CfgHMAC 请求签名配置
+type CfgHMAC struct {
+	Enabled    bool  `json:"enabled"`
+	MaxSkew    int   `json:"max_skew" mapstructure:"max_skew"`       // 签名时间与服务器时间允许的最大偏差（秒）
+	MaxBody    int64 `json:"max_body" mapstructure:"max_body"`       // 签名请求体的最大字节数
+	StaticKeys bool  `json:"static_keys" mapstructure:"static_keys"` // 是否仍允许直接发送 API Key
+}
+
+// CfgWorkspace 工作区配置
+type CfgWorkspace struct {
+	JoinDefault bool  `json:"join_default" mapstructure:"join_default"` // 新用户是否自动加入默认工作区
+	DefaultID   int64 `json:"-" mapstructure:"-"`                       // 默认工作区ID，启动时确定
+}
+
+// CfgAudit 审计日志配置
+type CfgAudit struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days" mapstructure:"retention_days"` // 保留天数，0 表示永久保留
+	PurgeInterval int  `json:"purge_interval" mapstructure:"purge_interval"` // 清理过期日志的周期（秒）
+}
+
+// CfgJWT JWT 配置
+type CfgJWT struct {
+	Enabled    bool   `json:"enabled"`
+	Algorithm  string `json:"algorithm"`                              // HS256、RS256 或 EdDSA
+	Secret     string `json:"secret"`                                 // HS256 密钥
+	PrivateKey string `json:"private_key" mapstructure:"private_key"` // RS256/EdDSA 私钥文件
+	PublicKey  string `json:"public_key" mapstructure:"public_key"`   // RS256/EdDSA 公钥文件
+	Issuer     string `json:"issuer"`                                 // 签发者
+	Audience   string `json:"audience"`                               // 接收方，为空表示不校验
+	AccessTTL  int    `json:"access_ttl" mapstructure:"access_ttl"`   // 访问令牌有效期（秒）
+	Leeway     int    `json:"leeway"`                                 // 校验时间时允许的误差（秒）
+}
+
+// CfgOIDC OIDC 单点登录配置
+type CfgOIDC struct {
+	Enabled          bool     `json:"enabled"`
+	Issuer           string   `json:"issuer"`                                             // 身份提供方地址
+	ClientID         string   `json:"client_id" mapstructure:"client_id"`                 // 客户端ID
+	ClientSecret     string   `json:"client_secret" mapstructure:"client_secret"`         // 客户端密钥
+	RedirectURL      string   `json:"redirect_url" mapstructure:"redirect_url"`           // 授权回调地址
+	Scopes           []string `json:"scopes"`                                             // 申请的权限范围
+	UsernameClaim    string   `json:"username_claim" mapstructure:"username_claim"`       // 作为本地用户名的声明
+	RoleClaim        string   `json:"role_claim" mapstructure:"role_claim"`               // 用于映射角色的声明
+	AdminValues      []string `json:"admin_values" mapstructure:"admin_values"`           // 映射为管理员的声明值
+	AllowedValues    []string `json:"allowed_values" mapstructure:"allowed_values"`       // 允许登录的声明值，为空表示不限制
+	AutoCreate       bool     `json:"auto_create" mapstructure:"auto_create"`             // 首次登录时自动创建本地用户
+	LinkExisting     bool     `json:"link_existing" mapstructure:"link_existing"`         // 允许关联同名的已有本地用户
+	PasswordLogin    bool     `json:"password_login" mapstructure:"password_login"`       // 是否允许账号密码登录
+	AllowedRedirects []string `json:"allowed_redirects" mapstructure:"allowed_redirects"` // 登录成功后允许跳转的地址
+}
+
+// CfgHealth 目标地址健康检测配置
+type CfgHealth struct {
+	Enabled     bool   `json:"enabled"`
+	Interval    int    `json:"interval"`                             // 检测周期（秒）
+	Timeout     int    `json:"timeout"`                              // 单次请求超时（秒）
+	Concurrency int    `json:"concurrency"`                          // 最大并发数
+	HostDelay   int    `json:"host_delay" mapstructure:"host_delay"` // 同一主机两次请求的最小间隔（毫秒）
+	Method      string `json:"method"`                               // HEAD 或 GET
+	UserAgent   string `json:"user_agent" mapstructure:"user_agent"` // 请求使用的 User-Agent
+	BatchSize   int    `json:"batch_size" mapstructure:"batch_size"` // 每批读取的短链接数量
+}
+
+// CfgPolicy 目标地址策略配置
+type CfgPolicy struct {
+	Enabled        bool     `json:"enabled"`
+	AllowedDomains []string `json:"allowed_domains" mapstructure:"allowed_domains"`   // 允许的域名，为空表示不限制
+	BlockedDomains []string `json:"blocked_domains" mapstructure:"blocked_domains"`   // 禁止的域名
+	BlockIPLiteral bool     `json:"block_ip_literal" mapstructure:"block_ip_literal"` // 禁止以 IP 作为主机名
+	BlockPrivate   bool     `json:"block_private" mapstructure:"block_private"`       // 禁止指向内网地址
+	ResolveDNS     bool     `json:"resolve_dns" mapstructure:"resolve_dns"`           // 解析域名后检查是否为内网地址
+	DNSTimeout     int      `json:"dns_timeout" mapstructure:"dns_timeout"`           // 域名解析超时（秒）
+}
+
+// CfgChain 跳转链路检测配置
+type CfgChain struct {
+	OwnDomains      []string `json:"own_domains" mapstructure:"own_domains"`           // 除 server.site_url 外的本站域名
+	ExternalDomains []string `json:"external_domains" mapstructure:"external_domains"` // 其他短网址服务的域名，通过 HTTP 跟随跳转
+	Collapse        bool     `json:"collapse"`                                         // 折叠链路，直接保存最终目标地址
+	MaxHops         int      `json:"max_hops" mapstructure:"max_hops"`                 // 最大跳转次数
+	Timeout         int      `json:"timeout"`                                          // 跟随外部跳转的请求超时（秒）
+}
+
+// CfgNormalize URL 规范化配置
+type CfgNormalize struct {
+	StripTracking  bool     `json:"strip_tracking" mapstructure:"strip_tracking"`   // 规范化时移除跟踪参数
+	TrackingParams []string `json:"tracking_params" mapstructure:"tracking_params"` // 跟踪参数，以 * 结尾表示前缀匹配
+	Dedupe         bool     `json:"dedupe"`                                         // 未指定短码时复用相同规范化地址的短链接
+}
+
+// CfgFallback 兜底页面配置
+type CfgFallback struct {
+	RootURL     string `json:"root_url" mapstructure:"root_url"`           // 访问根路径时跳转的地址
+	NotFound    string `json:"not_found" mapstructure:"not_found"`         // 短码不存在时的处理方式：auto、json、html、redirect
+	NotFoundURL string `json:"not_found_url" mapstructure:"not_found_url"` // redirect 方式的跳转地址
+	CodeParam   string `json:"code_param" mapstructure:"code_param"`       // redirect 方式携带短码的参数名
+	TemplateDir string `json:"template_dir" mapstructure:"template_dir"`   // 自定义页面模板目录
+	SiteName    string `json:"site_name" mapstructure:"site_name"`         // 页面中展示的站点名称
+}
+
+// CfgDeepLink 应用深度链接配置
+type CfgDeepLink struct {
+	Schemes []string `json:"schemes"` // 允许作为目标地址的非 http(s) 协议
+	Timeout int      `json:"timeout"` // 中转页唤起应用失败后回退的等待时间（毫秒）
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword 计算密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 校验密码与哈希是否匹配
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// secretCharset 密钥字符集
+const secretCharset = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// GenerateSecret 使用加密安全的随机数生成密钥
+func GenerateSecret(length int) string {
+	// 丢弃大于字符集整数倍的字节，避免取模偏差
+	limit := byte(256 - 256%len(secretCharset))
+	result := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(result) < length {
+		_, _ = rand.Read(buf)
+		for _, b := range buf {
+			if b < limit && len(result) < length {
+				result = append(result, secretCharset[int(b)%len(secretCharset)])
+			}
+		}
+	}
+	return string(result)
+}
+
+// HashSecret 计算密钥的 SHA-256，用于存储与查找高熵密钥
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
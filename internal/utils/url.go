@@ -1,8 +1,48 @@
 package utils
 
-import "strings"
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
 
-// IsURL 判断是否为URL
-func IsURL(url string) bool {
-	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+// IsURL 判断是否为带主机名的 http(s) URL
+func IsURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+// intentFallbackParam Android intent 链接中网页回退地址的参数前缀
+const intentFallbackParam = "S.browser_fallback_url="
+
+// SplitIntentFallback 拆分 Android intent 链接中的网页回退地址（S.browser_fallback_url）
+// 返回去掉该参数后的链接及解码后的回退地址，没有回退地址时 fallback 为空
+func SplitIntentFallback(intentURL string) (rest string, fallback string, err error) {
+	start := strings.Index(intentURL, "#Intent;")
+	if start < 0 {
+		return intentURL, "", nil
+	}
+	start += len("#Intent;")
+
+	params := strings.Split(intentURL[start:], ";")
+	kept := make([]string, 0, len(params))
+	for _, param := range params {
+		value, ok := strings.CutPrefix(param, intentFallbackParam)
+		if !ok {
+			kept = append(kept, param)
+			continue
+		}
+		if fallback != "" {
+			return "", "", errors.New("duplicate browser fallback url")
+		}
+		if fallback, err = url.QueryUnescape(value); err != nil {
+			return "", "", err
+		}
+	}
+
+	return intentURL[:start] + strings.Join(kept, ";"), fallback, nil
 }
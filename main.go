@@ -1,12 +1,26 @@
 package main
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
-	_ "go.xoder.cn/shortener/internal/bootstrap"
+	"go.xoder.cn/shortener/internal/bootstrap"
+	"go.xoder.cn/shortener/internal/middlewares"
+	"go.xoder.cn/shortener/internal/pkgs/listener"
+	"go.xoder.cn/shortener/internal/pkgs/metrics"
 	"go.xoder.cn/shortener/internal/shared"
+	"go.xoder.cn/shortener/internal/types"
 
+	"github.com/quic-go/quic-go/http3"
 	"github.com/spf13/viper"
 
 	"go.xoder.cn/shortener/internal/routers"
@@ -31,20 +45,169 @@ var description = `
 `
 
 func main() {
-	addr := viper.GetString("server.address")
-	if addr == "" {
-		addr = ":8080"
+	var serverCfg types.CfgServer
+	if err := viper.UnmarshalKey("server", &serverCfg); err != nil {
+		panic("server config unmarshal failed: " + err.Error())
+	}
+	if serverCfg.Address == "" {
+		serverCfg.Address = ":8080"
 	}
 
 	fmt.Printf(description, version)
-	fmt.Printf(" api key: %s \n username: %s \n password: %s \n\n",
-		shared.GlobalAPIKey,
-		shared.GlobalUser.Username,
-		shared.GlobalUser.Password,
-	)
+	// 仅首次启动创建管理员时输出账号
+	if shared.GlobalUser != nil {
+		fmt.Printf(" username: %s \n password: %s \n", shared.GlobalUser.Username, shared.GlobalUser.Password)
+	}
+	fmt.Println()
 
 	r := routers.NewRouter()
-	if err := r.Run(addr); err != nil {
-		panic("run server failed: " + err.Error())
+	// 证书已加载到 TLSConfig 中
+	server := &http.Server{
+		Addr:         serverCfg.Address,
+		Handler:      middlewares.LocalPeer(r.Handler()),
+		TLSConfig:    shared.GlobalTLS,
+		ReadTimeout:  time.Duration(serverCfg.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(serverCfg.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(serverCfg.IdleTimeout) * time.Second,
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	servers := []shutdowner{server}
+
+	listeners, err := listen(&serverCfg)
+	if err != nil {
+		panic("listen failed: " + err.Error())
+	}
+
+	serveErr := make(chan error, len(listeners)+3)
+	for _, ln := range listeners {
+		go func() {
+			slog.Info("listening", "address", ln.Addr().String())
+			if shared.GlobalTLS != nil {
+				serveErr <- server.ServeTLS(ln, "", "")
+			} else {
+				serveErr <- server.Serve(ln)
+			}
+		}()
+	}
+
+	// HTTP/3 与 HTTPS 共用 TLS 配置
+	if shared.GlobalHTTP3 != nil {
+		quicServer := &http3.Server{
+			Addr:        shared.GlobalHTTP3.Address,
+			Handler:     r.Handler(),
+			TLSConfig:   http3.ConfigureTLSConfig(shared.GlobalTLS),
+			IdleTimeout: time.Duration(serverCfg.IdleTimeout) * time.Second,
+		}
+		servers = append(servers, quicServer)
+		go func() {
+			slog.Info("listening", "address", shared.GlobalHTTP3.Address, "protocol", "http/3")
+			serveErr <- quicServer.ListenAndServe()
+		}()
+	}
+
+	// HTTP 跳转到 HTTPS
+	if shared.GlobalTLS != nil && serverCfg.TLS.RedirectAddress != "" {
+		_, httpsPort, _ := net.SplitHostPort(serverCfg.Address)
+		redirect := &http.Server{
+			Addr:              serverCfg.TLS.RedirectAddress,
+			Handler:           middlewares.HTTPSRedirect(httpsPort),
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       time.Duration(serverCfg.IdleTimeout) * time.Second,
+		}
+		servers = append(servers, redirect)
+		go func() {
+			slog.Info("redirecting http to https", "address", serverCfg.TLS.RedirectAddress)
+			serveErr <- redirect.ListenAndServe()
+		}()
+	}
+
+	// Prometheus 指标单独监听
+	if cfg := shared.GlobalMetrics; cfg != nil && cfg.Address != "" {
+		var handler http.Handler = metrics.Handler()
+		if cfg.Token != "" {
+			handler = middlewares.BearerToken(cfg.Token, handler)
+		}
+		mux := http.NewServeMux()
+		mux.Handle(cfg.Path, handler)
+		metricsServer := &http.Server{
+			Addr:              cfg.Address,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       time.Duration(serverCfg.IdleTimeout) * time.Second,
+		}
+		servers = append(servers, metricsServer)
+		go func() {
+			slog.Info("serving metrics", "address", cfg.Address, "path", cfg.Path)
+			serveErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for {
+		select {
+		case err := <-serveErr:
+			panic("run server failed: " + err.Error())
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				if err := bootstrap.Reload(); err != nil {
+					slog.Error("reload config failed", "err", err)
+				}
+				continue
+			}
+			shutdown(servers, time.Duration(serverCfg.ShutdownTimeout)*time.Second)
+			return
+		}
+	}
+}
+
+// listen 创建 HTTP(S) 监听器，优先使用 systemd 套接字激活传递的套接字，其次为 Unix 套接字与 TCP 地址
+// 套接字由 systemd 持有时，重启期间的新连接在队列中等待而不会被拒绝
+func listen(serverCfg *types.CfgServer) ([]net.Listener, error) {
+	listeners, err := listener.Systemd()
+	if err != nil || len(listeners) > 0 {
+		return listeners, err
+	}
+
+	if serverCfg.Socket != "" {
+		mode, err := strconv.ParseUint(serverCfg.SocketMode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid socket_mode %q", serverCfg.SocketMode)
+		}
+		ln, err := listener.Unix(serverCfg.Socket, os.FileMode(mode))
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{ln}, nil
+	}
+
+	ln, err := net.Listen("tcp", serverCfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	return []net.Listener{ln}, nil
+}
+
+// shutdowner 可优雅关闭的服务
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// shutdown 停止接收新连接，等待处理中的请求完成后关闭后台任务与资源
+func shutdown(servers []shutdowner, timeout time.Duration) {
+	slog.Info("shutting down", "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("shutdown server failed", "err", err)
+		}
+	}
+	if err := bootstrap.Shutdown(ctx); err != nil {
+		slog.Error("shutdown failed", "err", err)
+		return
 	}
+	slog.Info("server stopped")
 }
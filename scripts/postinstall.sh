@@ -20,6 +20,11 @@ fi
 
 ln -s /opt/shortener-server/shortener-server /usr/local/bin/shortener-server
 
+# 套接字激活需手动启用：systemctl enable --now shortener-server.socket
+if [[ -f /opt/shortener-server/shortener-server.socket ]]; then
+  cp /opt/shortener-server/shortener-server.socket /etc/systemd/system/shortener-server.socket
+fi
+
 if [[ -f /opt/shortener-server/shortener-server.service ]]; then
   cp /opt/shortener-server/shortener-server.service /etc/systemd/system/shortener-server.service
 
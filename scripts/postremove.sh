@@ -5,6 +5,10 @@ set -e
 
 # echo "Postremove script"
 
+if [[ -f /etc/systemd/system/shortener-server.socket ]]; then
+  rm -rf /etc/systemd/system/shortener-server.socket
+fi
+
 if [[ -f /etc/systemd/system/shortener-server.service ]]; then
   rm -rf /etc/systemd/system/shortener-server.service
 fi
@@ -5,6 +5,11 @@ set -e
 
 # echo "Preremove script"
 
+if systemctl list-units --type=socket | grep -q 'shortener-server.socket'; then
+  systemctl stop shortener-server.socket
+  systemctl disable shortener-server.socket
+fi
+
 if systemctl list-units --type=service | grep -q 'shortener-server.service'; then
   systemctl stop shortener-server.service
   systemctl disable shortener-server.service